@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/concrete"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
@@ -97,6 +98,7 @@ type simulatedBackendConfig struct {
 	database    ethdb.Database
 	vmConfig    vm.Config
 	consensus   consensus.Engine
+	concrete    concrete.PrecompileRegistry
 }
 
 type SimulatedBackendOpt func(s *simulatedBackendConfig)
@@ -143,6 +145,13 @@ func WithConsensus(consensus consensus.Engine) SimulatedBackendOpt {
 	}
 }
 
+// WithConcrete sets the concrete precompiles of the simulated blockchain.
+func WithConcrete(registry concrete.PrecompileRegistry) SimulatedBackendOpt {
+	return func(s *simulatedBackendConfig) {
+		s.concrete = registry
+	}
+}
+
 // NewSimulatedBackendWithOpts creates a new binding backend based on the given database
 // and uses a simulated blockchain for testing purposes. It exposes additional configuration
 // options that are useful to
@@ -159,6 +168,9 @@ func NewSimulatedBackendWithOpts(opts ...SimulatedBackendOpt) *SimulatedBackend
 
 	config.genesis.MustCommit(config.database)
 	blockchain, _ := core.NewBlockChain(config.database, config.cacheConfig, &config.genesis, nil, config.consensus, config.vmConfig, nil, nil)
+	if config.concrete != nil {
+		blockchain.SetConcrete(config.concrete)
+	}
 
 	backend := &SimulatedBackend{
 		database:   config.database,
@@ -216,7 +228,7 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) rollback(parent *types.Block) {
-	blocks, _ := core.GenerateChain(b.config, parent, b.consensus, b.database, 1, func(int, *core.BlockGen) {})
+	blocks, _ := core.GenerateChainWithConcrete(b.config, parent, b.consensus, b.database, 1, b.blockchain.Concrete(), func(int, *core.BlockGen) {})
 
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.blockchain.StateCache(), nil)
@@ -766,7 +778,7 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 		return fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce)
 	}
 	// Include tx in chain
-	blocks, receipts := core.GenerateChain(b.config, block, b.consensus, b.database, 1, func(number int, block *core.BlockGen) {
+	blocks, receipts := core.GenerateChainWithConcrete(b.config, block, b.consensus, b.database, 1, b.blockchain.Concrete(), func(number int, block *core.BlockGen) {
 		for _, tx := range b.pendingBlock.Transactions() {
 			block.AddTxWithChain(b.blockchain, tx)
 		}
@@ -890,7 +902,7 @@ func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 		return fmt.Errorf("could not find parent")
 	}
 
-	blocks, _ := core.GenerateChain(b.config, block, b.consensus, b.database, 1, func(number int, block *core.BlockGen) {
+	blocks, _ := core.GenerateChainWithConcrete(b.config, block, b.consensus, b.database, 1, b.blockchain.Concrete(), func(number int, block *core.BlockGen) {
 		block.OffsetTime(int64(adjustment.Seconds()))
 	})
 	stateDB, _ := b.blockchain.State()
@@ -343,7 +343,7 @@ func geth(ctx *cli.Context) error {
 	return nil
 }
 
-func newConcreteGeth(concreteRegistry concrete.PrecompileRegistry) func(ctx *cli.Context) error {
+func newConcreteGeth(newRegistry func(stack *node.Node) concrete.PrecompileRegistry) func(ctx *cli.Context) error {
 	return func(ctx *cli.Context) error {
 		if args := ctx.Args().Slice(); len(args) > 0 {
 			return fmt.Errorf("invalid command: %q", args[0])
@@ -353,7 +353,7 @@ func newConcreteGeth(concreteRegistry concrete.PrecompileRegistry) func(ctx *cli
 		stack, backend := makeFullNode(ctx)
 		defer stack.Close()
 
-		backend.SetConcrete(concreteRegistry)
+		backend.SetConcrete(newRegistry(stack))
 
 		startNode(ctx, stack, backend, false)
 		stack.Wait()
@@ -361,9 +361,9 @@ func newConcreteGeth(concreteRegistry concrete.PrecompileRegistry) func(ctx *cli
 	}
 }
 
-func newConcreteGethApp(concreteRegistry concrete.PrecompileRegistry) *cli.App {
+func newConcreteGethApp(newRegistry func(stack *node.Node) concrete.PrecompileRegistry) *cli.App {
 	ccApp := flags.NewApp("the concrete-geth command line interface")
-	ccApp.Action = newConcreteGeth(concreteRegistry)
+	ccApp.Action = newConcreteGeth(newRegistry)
 	ccApp.Copyright = "Copyright 2013-2023 The go-ethereum Authors & 2023 The concrete-geth Authors"
 	ccApp.Commands = app.Commands
 	ccApp.Flags = app.Flags
@@ -373,7 +373,14 @@ func newConcreteGethApp(concreteRegistry concrete.PrecompileRegistry) *cli.App {
 }
 
 func NewConcreteGethApp(concreteRegistry concrete.PrecompileRegistry) *cli.App {
-	return newConcreteGethApp(concreteRegistry)
+	return newConcreteGethApp(func(*node.Node) concrete.PrecompileRegistry { return concreteRegistry })
+}
+
+// NewConcreteGethAppWithNode is like NewConcreteGethApp, but creates the
+// registry once the node is set up, e.g. to cache wasm precompiles in the data
+// directory of the node with stack.ResolvePath(wasm.CacheDirName).
+func NewConcreteGethAppWithNode(newRegistry func(stack *node.Node) concrete.PrecompileRegistry) *cli.App {
+	return newConcreteGethApp(newRegistry)
 }
 
 // startNode boots up the system node and all registered protocols, after which
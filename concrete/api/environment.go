@@ -81,6 +81,9 @@ type Environment interface {
 	StorageStore(key common.Hash, value common.Hash)
 	// Log
 	Log(topics []common.Hash, data []byte)
+	// Snapshots
+	Snapshot() int
+	RevertToSnapshot(id int)
 
 	// EXTERNAL - READ
 	// Balance
@@ -134,6 +137,9 @@ type Env struct {
 	envErr error
 
 	callGasTemp uint64
+
+	// Snapshots taken during this call, in increasing order
+	snapshots []int
 }
 
 func NewEnvironment(
@@ -270,6 +276,10 @@ func (env *Env) Gas() uint64 {
 	return env.gas
 }
 
+func (env *Env) MeterGas() bool {
+	return env.meterGas
+}
+
 func (env *Env) Error() error {
 	return env.envErr
 }
@@ -515,6 +525,19 @@ func (env *Env) Log(topics []common.Hash, data []byte) {
 	env.execute(Log_OpCode, input)
 }
 
+func (env *Env) Snapshot() int {
+	output, err := env.execute(Snapshot_OpCode, nil)
+	if err != nil {
+		return -1
+	}
+	return int(utils.BytesToUint64(output[0]))
+}
+
+func (env *Env) RevertToSnapshot(id int) {
+	input := [][]byte{utils.Uint64ToBytes(uint64(id))}
+	env.execute(RevertToSnapshot_OpCode, input)
+}
+
 func (env *Env) GetExternalBalance(address common.Address) *big.Int {
 	input := [][]byte{address.Bytes()}
 	output, err := env.execute(GetExternalBalance_OpCode, input)
@@ -45,6 +45,9 @@ type StateDB interface {
 	AddRefund(uint64)
 	SubRefund(uint64)
 	GetRefund() uint64
+	// Snapshots
+	Snapshot() int
+	RevertToSnapshot(int)
 	// Storage
 	GetCommittedState(addr common.Address, key common.Hash) common.Hash
 	// Storage -- Concrete
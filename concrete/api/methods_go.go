@@ -185,6 +185,16 @@ func newEnvironmentMethods() JumpTable {
 			dynamicGas: gasLog,
 			static:     false,
 		},
+		Snapshot_OpCode: {
+			execute:     opSnapshot,
+			constantGas: GasQuickStep,
+			static:      true,
+		},
+		RevertToSnapshot_OpCode: {
+			execute:     opRevertToSnapshot,
+			constantGas: GasFastStep,
+			static:      true,
+		},
 		GetExternalBalance_OpCode: {
 			execute:     opGetExternalBalance,
 			constantGas: params.WarmStorageReadCostEIP2929,
@@ -698,6 +708,35 @@ func opLog(env *Env, args [][]byte) ([][]byte, error) {
 	return nil, nil
 }
 
+func opSnapshot(env *Env, args [][]byte) ([][]byte, error) {
+	if len(args) != 0 {
+		return nil, ErrInvalidInput
+	}
+	id := env.statedb.Snapshot()
+	env.snapshots = append(env.snapshots, id)
+	return [][]byte{utils.Uint64ToBytes(uint64(id))}, nil
+}
+
+// Reverting discards the state changes made since the snapshot, including
+// refunds and access list additions, but not the gas already used to make
+// them, the same as a reverted call frame in the EVM.
+func opRevertToSnapshot(env *Env, args [][]byte) ([][]byte, error) {
+	if len(args) != 1 || len(args[0]) != 8 {
+		return nil, ErrInvalidInput
+	}
+	id := int(utils.BytesToUint64(args[0]))
+	// Only snapshots taken during this call can be reverted to, as reverting
+	// to an outer snapshot would undo changes made by the callers.
+	for ii, snapshot := range env.snapshots {
+		if snapshot == id {
+			env.statedb.RevertToSnapshot(id)
+			env.snapshots = env.snapshots[:ii]
+			return nil, nil
+		}
+	}
+	return nil, ErrInvalidSnapshot
+}
+
 func gasGetExternalBalance(env *Env, args [][]byte) (uint64, error) {
 	if len(args) != 1 {
 		return 0, ErrInvalidInput
@@ -72,6 +72,9 @@ func (m *mockStateDB) AddRefund(uint64)  {}
 func (m *mockStateDB) SubRefund(uint64)  {}
 func (m *mockStateDB) GetRefund() uint64 { return 0 }
 
+func (m *mockStateDB) Snapshot() int          { return 0 }
+func (m *mockStateDB) RevertToSnapshot(_ int) {}
+
 var _ StateDB = (*mockStateDB)(nil)
 
 type mockBlockContext struct{}
@@ -61,8 +61,10 @@ const (
 	GetCode_OpCode            OpCode = 0x42
 	GetCodeSize_OpCode        OpCode = 0x43
 	// Internal writes
-	StorageStore_OpCode OpCode = 0x51
-	Log_OpCode          OpCode = 0x52
+	StorageStore_OpCode     OpCode = 0x51
+	Log_OpCode              OpCode = 0x52
+	Snapshot_OpCode         OpCode = 0x53
+	RevertToSnapshot_OpCode OpCode = 0x54
 	// External reads
 	GetExternalBalance_OpCode  OpCode = 0x60
 	CallStatic_OpCode          OpCode = 0x61
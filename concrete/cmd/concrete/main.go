@@ -17,13 +17,19 @@ package main
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/decoder"
+	"github.com/ethereum/go-ethereum/concrete/codegen/gogen"
 	"github.com/ethereum/go-ethereum/concrete/codegen/solgen"
+	"github.com/ethereum/go-ethereum/concrete/wasm"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/internal/version"
 	"github.com/spf13/cobra"
 )
@@ -85,18 +91,86 @@ func main() {
 	cmdSolgen.Flags().StringP("address", "a", "", "precompile address")
 	rootCmd.AddCommand(cmdSolgen)
 
+	var cmdGogen = &cobra.Command{
+		Use:   "gogen",
+		Short: "Generate a go precompile interface and adapter, or client bindings, from an ABI file",
+		Run:   runGogen,
+	}
+
+	cmdGogen.Flags().String("abi", "", "path to the ABI file")
+	cmdGogen.Flags().String("out", "./", "path to the output file")
+	cmdGogen.Flags().String("pkg", "main", "package name for the generated file")
+	cmdGogen.Flags().StringP("name", "n", "", "name for the generated interface, or binding with --client")
+	cmdGogen.Flags().Bool("client", false, "generate client bindings calling the precompile instead")
+	cmdGogen.Flags().StringP("address", "a", "", "precompile address, required with --client")
+	rootCmd.AddCommand(cmdGogen)
+
+	var cmdSmtgen = &cobra.Command{
+		Use:   "smtgen",
+		Short: "Generate a solidity library to verify sparse merkle tree proofs",
+		Run:   runSmtgen,
+	}
+
+	cmdSmtgen.Flags().String("out", "./", "path to the output file")
+	cmdSmtgen.Flags().StringP("name", "n", "SparseMerkleVerifier", "name for the generated library")
+	rootCmd.AddCommand(cmdSmtgen)
+
 	var cmdDatamod = &cobra.Command{
 		Use:   "datamod <path>",
-		Short: "Generate type safe go wrappers for datastore structures from a json definition",
+		Short: "Generate type safe go or solidity wrappers for datastore structures from a json definition or an annotated go package",
 		Args:  cobra.MinimumNArgs(1),
 		Run:   runDatamod,
 	}
 
 	cmdDatamod.Flags().String("out", "./", "dir to write the generated files to")
 	cmdDatamod.Flags().String("pkg", "main", "package name for the generated files")
-	cmdDatamod.Flags().Bool("table-type-experimental", false, "whether to enable experimental table value type")
+	cmdDatamod.Flags().Bool("table-type-experimental", false, "")
+	cmdDatamod.Flags().MarkDeprecated("table-type-experimental", "table values are always enabled")
+	cmdDatamod.Flags().String("lang", "go", "language of the generated files (go or solidity)")
+	cmdDatamod.Flags().StringP("address", "a", "", "precompile address, required for solidity")
+	cmdDatamod.Flags().String("previous", "", "previous version of the schema, to check changes against and generate migrations")
+	cmdDatamod.Flags().Int("previous-layout", datamod.NestedTableLayout, "nested table layout the previous schema was generated with, 1 for code generated before nested tables were rooted at keccak256 of their field slot")
+	cmdDatamod.Flags().String("emit-json", "", "path to write the JSON schema to, e.g. when deriving it from a Go package")
+	cmdDatamod.Flags().String("rpc-namespace", "", "generate a JSON-RPC service reading the tables, registered under this namespace by default")
 	rootCmd.AddCommand(cmdDatamod)
 
+	var cmdDecode = &cobra.Command{
+		Use:   "decode <path>",
+		Short: "Decode datamod table rows from the storage of a precompile",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runDecode,
+	}
+
+	cmdDecode.Flags().StringP("address", "a", "", "precompile address")
+	cmdDecode.Flags().StringP("table", "t", "", "name of the table to decode")
+	cmdDecode.Flags().StringArrayP("key", "k", nil, "row key, in schema order (repeatable)")
+	cmdDecode.Flags().String("slot", "", "slot of the table, defaults to the datamod default key")
+	cmdDecode.Flags().String("rpc", "", "RPC endpoint to read storage from")
+	cmdDecode.Flags().Int64("block", -1, "block number to read storage at, only with --rpc, defaults to latest")
+	cmdDecode.Flags().String("dump", "", "state dump file to read storage from")
+	cmdDecode.Flags().String("chaindata", "", "chaindata dir to read storage from")
+	cmdDecode.Flags().Bool("table-type-experimental", false, "")
+	cmdDecode.Flags().MarkDeprecated("table-type-experimental", "table values are always enabled")
+	rootCmd.AddCommand(cmdDecode)
+
+	var cmdWasmCache = &cobra.Command{
+		Use:   "wasmcache",
+		Short: "Manage the compilation cache of wasm precompiles",
+	}
+
+	var cmdWasmCachePrune = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove stale and corrupted entries from the wasm compilation cache",
+		Args:  cobra.NoArgs,
+		Run:   runWasmCachePrune,
+	}
+
+	cmdWasmCachePrune.Flags().String("dir", "", "cache directory, defaults to "+wasm.DefaultCacheDir)
+	cmdWasmCachePrune.Flags().String("datadir", "", "data directory of the node, to prune the cache of instead of --dir")
+	cmdWasmCachePrune.Flags().Bool("all", false, "remove all entries")
+	cmdWasmCache.AddCommand(cmdWasmCachePrune)
+	rootCmd.AddCommand(cmdWasmCache)
+
 	if err := rootCmd.Execute(); err != nil {
 		exit(err.Error())
 	}
@@ -168,19 +242,152 @@ Solidity : %s
 	fmt.Printf("Library generated successfully.\nLibrary written to: %s\n", outPath)
 }
 
+func runGogen(cmd *cobra.Command, args []string) {
+	abiPath, err := cmd.Flags().GetString("abi")
+	checkErr(err)
+	outPath, err := cmd.Flags().GetString("out")
+	checkErr(err)
+	pkg, err := cmd.Flags().GetString("pkg")
+	checkErr(err)
+	name, err := cmd.Flags().GetString("name")
+	checkErr(err)
+	client, err := cmd.Flags().GetBool("client")
+	checkErr(err)
+	address, err := cmd.Flags().GetString("address")
+	checkErr(err)
+
+	if abiPath == "" {
+		exit("ABI file path (--abi) must be provided")
+	}
+	if outPath == "" {
+		exit("Output file path (--out) must be provided")
+	}
+
+	abiIsDir, err := isDir(abiPath)
+	checkErr(err)
+	if abiIsDir {
+		exit("ABI path must be a file")
+	}
+
+	if client {
+		if address == "" {
+			exit("Precompile address (--address) must be provided with --client")
+		} else if !common.IsHexAddress(common.HexToAddress(address).Hex()) {
+			exit("Precompile address (--address) must be a valid hex address")
+		}
+	} else if address != "" {
+		exit("Precompile address (--address) is only used with --client")
+	}
+
+	if name == "" {
+		name = abi.ToCamelCase(fileName(abiPath))
+	}
+
+	outIsDir, err := isDir(outPath)
+	if err == nil && outIsDir {
+		outPath = filepath.Join(outPath, strings.ToLower(name)+".go")
+	}
+
+	config := gogen.Config{
+		Name:    name,
+		Package: pkg,
+		ABI:     abiPath,
+		Out:     outPath,
+		Address: common.HexToAddress(address),
+	}
+
+	if client {
+		fmt.Printf(`Generating go client bindings
+Name    : %s
+Package : %s
+Address : %s
+ABI     : %s
+Output  : %s
+`, name, pkg, config.Address.Hex(), abiPath, outPath)
+
+		err = gogen.GenerateClient(config)
+		checkErr(err)
+
+		fmt.Printf("Bindings generated successfully.\nBindings written to: %s\n", outPath)
+		return
+	}
+
+	fmt.Printf(`Generating go precompile stub
+Name    : %s
+Package : %s
+ABI     : %s
+Output  : %s
+`, name, pkg, abiPath, outPath)
+
+	err = gogen.GenerateGoStub(config)
+	checkErr(err)
+
+	fmt.Printf("Stub generated successfully.\nStub written to: %s\n", outPath)
+}
+
+func runSmtgen(cmd *cobra.Command, args []string) {
+	outPath, err := cmd.Flags().GetString("out")
+	checkErr(err)
+	name, err := cmd.Flags().GetString("name")
+	checkErr(err)
+
+	if outPath == "" {
+		exit("Output file path (--out) must be provided")
+	}
+
+	outIsDir, err := isDir(outPath)
+	if err == nil && outIsDir {
+		outPath = filepath.Join(outPath, name+".sol")
+	}
+
+	config := solgen.SMTConfig{
+		Name: name,
+		Out:  outPath,
+	}
+
+	err = solgen.GenerateSparseMerkleVerifier(config)
+	checkErr(err)
+
+	fmt.Printf("Library generated successfully.\nLibrary written to: %s\n", outPath)
+}
+
 func runDatamod(cmd *cobra.Command, args []string) {
 	jsonPath := args[0]
 	outPath, err := cmd.Flags().GetString("out")
 	checkErr(err)
 	pkg, err := cmd.Flags().GetString("pkg")
 	checkErr(err)
-	allowTableTypes, err := cmd.Flags().GetBool("table-type-experimental")
+	lang, err := cmd.Flags().GetString("lang")
 	checkErr(err)
-
-	jsonIsDir, err := isDir(jsonPath)
+	address, err := cmd.Flags().GetString("address")
+	checkErr(err)
+	previous, err := cmd.Flags().GetString("previous")
 	checkErr(err)
-	if jsonIsDir {
-		exit("JSON path must be a file")
+	previousLayout, err := cmd.Flags().GetInt("previous-layout")
+	checkErr(err)
+	emitJSON, err := cmd.Flags().GetString("emit-json")
+	checkErr(err)
+	rpcNamespace, err := cmd.Flags().GetString("rpc-namespace")
+	checkErr(err)
+
+	if lang != "go" && lang != "solidity" {
+		exit("Language (--lang) must be go or solidity")
+	}
+	if lang == "solidity" && !common.IsHexAddress(address) {
+		exit("Precompile address (--address) must be a valid hex address")
+	}
+	if lang == "solidity" && rpcNamespace != "" {
+		exit("RPC services (--rpc-namespace) can only be generated in go")
+	}
+	if previousLayout < 1 || previousLayout > datamod.NestedTableLayout {
+		exit(fmt.Sprintf("Previous layout (--previous-layout) must be between 1 and %d", datamod.NestedTableLayout))
+	}
+
+	if emitJSON != "" {
+		jsonContent, err := datamod.ReadSchema(jsonPath)
+		checkErr(err)
+		checkErr(os.WriteFile(emitJSON, jsonContent, 0644))
+		fmt.Println("JSON schema written to:", emitJSON)
 	}
 
 	outIsDir, err := isDir(outPath)
@@ -190,15 +397,137 @@ func runDatamod(cmd *cobra.Command, args []string) {
 	}
 
 	config := datamod.Config{
-		JSON:    jsonPath,
-		Out:     outPath,
-		Package: pkg,
+		JSON:           jsonPath,
+		Out:            outPath,
+		Package:        pkg,
+		Address:        common.HexToAddress(address),
+		Previous:       previous,
+		PreviousLayout: previousLayout,
+		RPCNamespace:   rpcNamespace,
+	}
+
+	if previous != "" {
+		diff, err := datamod.DiffSchemas(previous, jsonPath, previousLayout)
+		checkErr(err)
+		if len(diff.Changes) == 0 {
+			fmt.Println("No schema changes from:", previous)
+		} else {
+			fmt.Printf("Schema changes from %s:\n%s\n", previous, diff)
+		}
+		if !diff.Compatible() {
+			exit("Schema changes are incompatible with the previous schema")
+		}
 	}
 
 	fmt.Println("Generating data model wrappers for:", jsonPath)
 
-	err = datamod.GenerateDataModel(config, allowTableTypes)
+	if lang == "solidity" {
+		err = datamod.GenerateSolidity(config)
+	} else {
+		err = datamod.GenerateDataModel(config)
+	}
 	checkErr(err)
 
 	fmt.Println("Data model wrappers generated successfully.\nFiles written to:", outPath)
 }
+
+func runDecode(cmd *cobra.Command, args []string) {
+	jsonPath := args[0]
+	address, err := cmd.Flags().GetString("address")
+	checkErr(err)
+	table, err := cmd.Flags().GetString("table")
+	checkErr(err)
+	keys, err := cmd.Flags().GetStringArray("key")
+	checkErr(err)
+	slot, err := cmd.Flags().GetString("slot")
+	checkErr(err)
+	rpcURL, err := cmd.Flags().GetString("rpc")
+	checkErr(err)
+	block, err := cmd.Flags().GetInt64("block")
+	checkErr(err)
+	dumpPath, err := cmd.Flags().GetString("dump")
+	checkErr(err)
+	chaindataPath, err := cmd.Flags().GetString("chaindata")
+	checkErr(err)
+
+	if !common.IsHexAddress(address) {
+		exit("Precompile address (--address) must be a valid hex address")
+	}
+	if table == "" {
+		exit("Table name (--table) must be provided")
+	}
+
+	nSources := 0
+	for _, source := range []string{rpcURL, dumpPath, chaindataPath} {
+		if source != "" {
+			nSources++
+		}
+	}
+	if nSources != 1 {
+		exit("Exactly one storage source (--rpc, --dump or --chaindata) must be provided")
+	}
+	if cmd.Flags().Changed("block") && rpcURL == "" {
+		exit("Block number (--block) can only be used with --rpc")
+	}
+
+	jsonContent, err := datamod.ReadSchema(jsonPath)
+	checkErr(err)
+	schemas, err := datamod.ParseTableSchemas(jsonContent)
+	checkErr(err)
+
+	var source decoder.StorageSource
+	switch {
+	case rpcURL != "":
+		client, err := ethclient.Dial(rpcURL)
+		checkErr(err)
+		defer client.Close()
+		var blockNumber *big.Int
+		if block >= 0 {
+			blockNumber = big.NewInt(block)
+		}
+		source = decoder.NewRPCSource(client, blockNumber)
+	case dumpPath != "":
+		source, err = decoder.LoadDumpSource(dumpPath)
+		checkErr(err)
+	default:
+		stateSource, err := decoder.OpenChaindata(chaindataPath)
+		checkErr(err)
+		defer stateSource.Close()
+		source = stateSource
+	}
+
+	dec := decoder.NewDecoder(schemas, source, common.HexToAddress(address))
+
+	var row *decoder.Row
+	if slot != "" {
+		row, err = dec.RowAt(table, common.HexToHash(slot), keys)
+	} else {
+		row, err = dec.Row(table, keys)
+	}
+	checkErr(err)
+
+	fmt.Print(row)
+}
+
+func runWasmCachePrune(cmd *cobra.Command, args []string) {
+	dir, err := cmd.Flags().GetString("dir")
+	checkErr(err)
+	dataDir, err := cmd.Flags().GetString("datadir")
+	checkErr(err)
+	all, err := cmd.Flags().GetBool("all")
+	checkErr(err)
+
+	if dataDir != "" {
+		if dir != "" {
+			exit("Only one of --dir and --datadir can be given")
+		}
+		// Nodes resolve the cache directory under their instance directory
+		dir = filepath.Join(dataDir, "geth", wasm.CacheDirName)
+	}
+	cache, err := wasm.OpenCache(dir)
+	checkErr(err)
+	removed, err := cache.Prune(all)
+	checkErr(err)
+
+	fmt.Printf("Cache pruned successfully.\nRemoved %d entries from: %s\n", removed, cache.Dir())
+}
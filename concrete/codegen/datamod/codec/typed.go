@@ -0,0 +1,79 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package codec
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Codec bundles the encoding functions of a datamod field type with its
+// size, so it can be used by the generic containers in lib.
+type Codec[T any] struct {
+	// Size of the encoded value in bytes. Dynamic values always take a full
+	// slot for their length.
+	Size int
+	// Dynamic values are stored as length-prefixed bytes, like the datamod
+	// bytes and string types.
+	Dynamic bool
+	encode  func(int, T) []byte
+	decode  func(int, []byte) T
+}
+
+func NewCodec[T any](size int, dynamic bool, encode func(int, T) []byte, decode func(int, []byte) T) Codec[T] {
+	return Codec[T]{Size: size, Dynamic: dynamic, encode: encode, decode: decode}
+}
+
+func (c Codec[T]) Encode(value T) []byte {
+	return c.encode(c.Size, value)
+}
+
+func (c Codec[T]) Decode(data []byte) T {
+	return c.decode(c.Size, data)
+}
+
+// EncodeAll encodes each of the given values.
+func (c Codec[T]) EncodeAll(values []T) [][]byte {
+	encoded := make([][]byte, len(values))
+	for ii, value := range values {
+		encoded[ii] = c.Encode(value)
+	}
+	return encoded
+}
+
+var (
+	Address = NewCodec[common.Address](20, false, EncodeAddress, DecodeAddress)
+	Bool    = NewCodec[bool](1, false, EncodeBool, DecodeBool)
+	Hash    = NewCodec[common.Hash](32, false, EncodeHash, DecodeHash)
+	Bytes   = NewCodec[[]byte](32, true, EncodeBytes, DecodeBytes)
+	String  = NewCodec[string](32, true, EncodeString, DecodeString)
+	Uint256 = NewCodec[*big.Int](32, false, EncodeUint256, DecodeUint256)
+	Int256  = NewCodec[*big.Int](32, false, EncodeInt256, DecodeInt256)
+	Uint8   = NewCodec[uint8](1, false, EncodeSmallUint8, DecodeSmallUint8)
+	Uint16  = NewCodec[uint16](2, false, EncodeSmallUint16, DecodeSmallUint16)
+	Uint32  = NewCodec[uint32](4, false, EncodeSmallUint32, DecodeSmallUint32)
+	Uint64  = NewCodec[uint64](8, false, EncodeSmallUint64, DecodeSmallUint64)
+	Int8    = NewCodec[int8](1, false, EncodeSmallInt8, DecodeSmallInt8)
+	Int16   = NewCodec[int16](2, false, EncodeSmallInt16, DecodeSmallInt16)
+	Int32   = NewCodec[int32](4, false, EncodeSmallInt32, DecodeSmallInt32)
+	Int64   = NewCodec[int64](8, false, EncodeSmallInt64, DecodeSmallInt64)
+)
+
+// FixedBytes returns the codec for the datamod bytesN type, with N < 32.
+func FixedBytes(size int) Codec[[]byte] {
+	return NewCodec[[]byte](size, false, EncodeFixedBytes, DecodeFixedBytes)
+}
@@ -25,23 +25,79 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
 	"github.com/iancoleman/orderedmap"
 )
 
 //go:embed table.tpl
 var tableTpl string
 
+//go:embed schema.tpl
+var schemaTpl string
+
+//go:embed enum.tpl
+var enumTpl string
+
+//go:embed struct.tpl
+var structTpl string
+
 type FieldSchema struct {
 	Name  string
 	Title string
 	Index int
 	Type  FieldType
+	// Path is the name used for accessors, prefixed by the titles of parent
+	// struct fields.
+	Path string
+	// Fields holds the child fields of struct fields.
+	Fields []FieldSchema
+	// Indexed is set for value fields that are part of a secondary index.
+	Indexed bool
 }
 
-type TableSchema struct {
+type IndexSchema struct {
 	Name   string
-	Keys   []FieldSchema
-	Values []FieldSchema
+	Title  string
+	Fields []FieldSchema
+}
+
+type TableSchema struct {
+	Name    string
+	Keys    []FieldSchema
+	Values  []FieldSchema
+	Indexes []IndexSchema
+	// Enumerable tables keep a set of their rows, so that they can be
+	// checked, listed and deleted.
+	Enumerable bool
+	// Events enables the emission of change events, see lib.TableEvents.
+	Events bool
+}
+
+// Tracked reports whether the rows of the table are tracked by indexes, a
+// row set or events.
+func (s TableSchema) Tracked() bool {
+	return s.Enumerable || s.Events || len(s.Indexes) > 0
+}
+
+// AllValues returns the value fields depth-first, including struct fields
+// and their children.
+func (s TableSchema) AllValues() []FieldSchema {
+	return appendFields(s.Values, nil, false)
+}
+
+// Leaves returns the value fields stored in the row, in row order.
+func (s TableSchema) Leaves() []FieldSchema {
+	return appendFields(s.Values, nil, true)
+}
+
+type dataModel struct {
+	Tables  []TableSchema
+	Enums   []*EnumSchema
+	Structs []StructSchema
+	// NestedLayout is the layout version of nested tables, see
+	// NestedTableLayout.
+	NestedLayout int
 }
 
 func newFieldSchema(name string, index int, typeStr string) (FieldSchema, error) {
@@ -60,11 +116,36 @@ func newFieldSchema(name string, index int, typeStr string) (FieldSchema, error)
 	}, nil
 }
 
-func unmarshalTableSchemas(jsonContent []byte, allowTableTypes bool) ([]TableSchema, error) {
+func unmarshalTableSchemas(jsonContent []byte) ([]TableSchema, error) {
+	model, err := unmarshalDataModel(jsonContent)
+	if err != nil {
+		return []TableSchema{}, err
+	}
+	return model.Tables, nil
+}
+
+func unmarshalDataModel(jsonContent []byte) (*dataModel, error) {
 	jsonSchemas := orderedmap.New()
 	err := json.Unmarshal(jsonContent, &jsonSchemas)
 	if err != nil {
-		return []TableSchema{}, err
+		return nil, err
+	}
+
+	defs, err := unmarshalTypeDefs(jsonSchemas)
+	if err != nil {
+		return nil, err
+	}
+	structSchemas, err := defs.structSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames := make(map[string]bool)
+	for _, tableName := range jsonSchemas.Keys() {
+		_jsonTableSchema, _ := jsonSchemas.Get(tableName)
+		if jsonTableSchema, ok := _jsonTableSchema.(orderedmap.OrderedMap); !ok || !isTypeDef(jsonTableSchema) {
+			tableNames[tableName] = true
+		}
 	}
 
 	var tableSchemas []TableSchema
@@ -72,14 +153,17 @@ func unmarshalTableSchemas(jsonContent []byte, allowTableTypes bool) ([]TableSch
 		_jsonTableSchema, _ := jsonSchemas.Get(tableName)
 		jsonTableSchema, ok := _jsonTableSchema.(orderedmap.OrderedMap)
 		if !ok {
-			return []TableSchema{}, fmt.Errorf("invalid schema for table '%s'", tableName)
+			return nil, fmt.Errorf("invalid schema for table '%s'", tableName)
+		}
+		if isTypeDef(jsonTableSchema) {
+			continue
 		}
 
 		if !isValidName(tableName) {
-			return []TableSchema{}, fmt.Errorf("invalid table name '%s'", tableName)
+			return nil, fmt.Errorf("invalid table name '%s'", tableName)
 		}
 		if len(jsonTableSchema.Keys()) == 0 {
-			return []TableSchema{}, fmt.Errorf("no schema for table '%s'", tableName)
+			return nil, fmt.Errorf("no schema for table '%s'", tableName)
 		}
 
 		tableSchema := TableSchema{Name: upperFirstLetter(tableName)}
@@ -88,20 +172,29 @@ func unmarshalTableSchemas(jsonContent []byte, allowTableTypes bool) ([]TableSch
 		if ok {
 			jsonKeySchema, ok := _jsonKeySchema.(orderedmap.OrderedMap)
 			if !ok {
-				return []TableSchema{}, fmt.Errorf("invalid key schema for table '%s'", tableName)
+				return nil, fmt.Errorf("invalid key schema for table '%s'", tableName)
 			}
 			for _, keyName := range jsonKeySchema.Keys() {
 				_keyType, _ := jsonKeySchema.Get(keyName)
 				keyType, ok := _keyType.(string)
 				if !ok {
-					return []TableSchema{}, fmt.Errorf("invalid schema for key '%s' in table '%s'", keyName, tableName)
+					return nil, fmt.Errorf("invalid schema for key '%s' in table '%s'", keyName, tableName)
 				}
 				fieldSchema, err := newFieldSchema(keyName, len(tableSchema.Keys), keyType)
 				if err != nil {
-					return []TableSchema{}, err
+					return nil, err
 				}
 				if fieldSchema.Type.Type == TableType {
-					return []TableSchema{}, fmt.Errorf("table '%s' cannot have table keys", tableName)
+					return nil, fmt.Errorf("table '%s' cannot have table keys", tableName)
+				}
+				if fieldSchema.Type.Type == ArrayType {
+					return nil, fmt.Errorf("table '%s' cannot have array keys", tableName)
+				}
+				if fieldSchema.Type.Type == StructType {
+					return nil, fmt.Errorf("table '%s' cannot have struct keys", tableName)
+				}
+				if err := defs.resolveField(&fieldSchema, nil); err != nil {
+					return nil, fmt.Errorf("invalid type '%s' for key '%s': %w", keyType, keyName, err)
 				}
 				tableSchema.Keys = append(tableSchema.Keys, fieldSchema)
 			}
@@ -109,58 +202,267 @@ func unmarshalTableSchemas(jsonContent []byte, allowTableTypes bool) ([]TableSch
 
 		_jsonValueSchema, ok := jsonTableSchema.Get("schema")
 		if !ok {
-			return []TableSchema{}, fmt.Errorf("no value schema for table '%s'", tableName)
+			return nil, fmt.Errorf("no value schema for table '%s'", tableName)
 		}
 		jsonValueSchema, ok := _jsonValueSchema.(orderedmap.OrderedMap)
 		if !ok {
-			return []TableSchema{}, fmt.Errorf("invalid value schema for table '%s'", tableName)
+			return nil, fmt.Errorf("invalid value schema for table '%s'", tableName)
 		}
 		for _, valueName := range jsonValueSchema.Keys() {
 			_valueType, _ := jsonValueSchema.Get(valueName)
 			valueType, ok := _valueType.(string)
 			if !ok {
-				return []TableSchema{}, fmt.Errorf("invalid schema for value '%s' in table '%s'", valueName, tableName)
+				return nil, fmt.Errorf("invalid schema for value '%s' in table '%s'", valueName, tableName)
 			}
 			fieldSchema, err := newFieldSchema(valueName, len(tableSchema.Values), valueType)
 			if err != nil {
-				return []TableSchema{}, err
+				return nil, err
 			}
 			if fieldSchema.Type.Type == TableType {
-				if !allowTableTypes {
-					return []TableSchema{}, fmt.Errorf("invalid type '%s' for field '%s': table values cannot be tables", fieldSchema.Type.Name, fieldSchema.Name)
-				}
-				_, ok := jsonSchemas.Get(fieldSchema.Type.Name)
-				if !ok {
-					return []TableSchema{}, fmt.Errorf("table '%s' does not exist", fieldSchema.Type.Name)
+				if !tableNames[fieldSchema.Type.Name] {
+					return nil, fmt.Errorf("table '%s' does not exist", fieldSchema.Type.Name)
 				}
 			}
+			if err := defs.resolveField(&fieldSchema, nil); err != nil {
+				return nil, fmt.Errorf("invalid type '%s' for field '%s': %w", valueType, valueName, err)
+			}
 			tableSchema.Values = append(tableSchema.Values, fieldSchema)
 		}
+		nextIndex := 0
+		layoutFields(tableSchema.Values, "", &nextIndex)
+
+		_jsonIndexes, ok := jsonTableSchema.Get("indexes")
+		if ok {
+			jsonIndexes, ok := _jsonIndexes.(orderedmap.OrderedMap)
+			if !ok {
+				return nil, fmt.Errorf("invalid indexes for table '%s'", tableName)
+			}
+			indexes, err := unmarshalIndexes(jsonIndexes, &tableSchema)
+			if err != nil {
+				return nil, fmt.Errorf("invalid indexes for table '%s': %w", tableName, err)
+			}
+			tableSchema.Indexes = indexes
+		}
+
+		_enumerable, ok := jsonTableSchema.Get("enumerable")
+		if ok {
+			enumerable, ok := _enumerable.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid enumerable flag for table '%s'", tableName)
+			}
+			if enumerable && len(tableSchema.Keys) == 0 {
+				return nil, fmt.Errorf("enumerable table '%s' must have keys", tableName)
+			}
+			tableSchema.Enumerable = enumerable
+		}
+
+		_events, ok := jsonTableSchema.Get("events")
+		if ok {
+			events, ok := _events.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid events flag for table '%s'", tableName)
+			}
+			tableSchema.Events = events
+		}
+
 		tableSchemas = append(tableSchemas, tableSchema)
 	}
-	return tableSchemas, nil
+
+	if err := validateNestedTables(tableSchemas); err != nil {
+		return nil, err
+	}
+	if err := validateEnumerable(tableSchemas); err != nil {
+		return nil, err
+	}
+
+	model := &dataModel{
+		Tables:       tableSchemas,
+		Enums:        defs.enumList,
+		Structs:      structSchemas,
+		NestedLayout: NestedTableLayout,
+	}
+	return model, nil
+}
+
+// unmarshalIndexes reads the secondary indexes of a table, given as a map
+// from index name to the list of indexed value fields.
+func unmarshalIndexes(jsonIndexes orderedmap.OrderedMap, tableSchema *TableSchema) ([]IndexSchema, error) {
+	var indexes []IndexSchema
+	for _, indexName := range jsonIndexes.Keys() {
+		if !isValidName(indexName) {
+			return nil, fmt.Errorf("invalid index name '%s'", indexName)
+		}
+		_jsonFields, _ := jsonIndexes.Get(indexName)
+		jsonFields, ok := _jsonFields.([]interface{})
+		if !ok || len(jsonFields) == 0 {
+			return nil, fmt.Errorf("invalid fields for index '%s'", indexName)
+		}
+		index := IndexSchema{
+			Name:  indexName,
+			Title: upperFirstLetter(indexName),
+		}
+		seen := make(map[string]bool)
+		for _, _fieldName := range jsonFields {
+			fieldName, ok := _fieldName.(string)
+			if !ok || seen[fieldName] {
+				return nil, fmt.Errorf("invalid field '%v' for index '%s'", _fieldName, indexName)
+			}
+			seen[fieldName] = true
+			field := findField(tableSchema.Values, fieldName)
+			if field == nil {
+				return nil, fmt.Errorf("field '%s' in index '%s' does not exist", fieldName, indexName)
+			}
+			if field.Type.Type != ValueType && field.Type.Type != BytesType {
+				return nil, fmt.Errorf("field '%s' of type '%s' cannot be indexed", fieldName, field.Type.Name)
+			}
+			field.Indexed = true
+			index.Fields = append(index.Fields, *field)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// validateNestedTables checks that tables do not contain themselves, directly
+// or through other tables.
+func validateNestedTables(tableSchemas []TableSchema) error {
+	nested := make(map[string][]string)
+	for _, schema := range tableSchemas {
+		name := lowerFirstLetter(schema.Name)
+		for _, field := range schema.Leaves() {
+			if field.Type.Type == TableType {
+				nested[name] = append(nested[name], lowerFirstLetter(field.Type.Name))
+			}
+		}
+	}
+	done := make(map[string]bool)
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		for ii, parent := range stack {
+			if parent == name {
+				cycle := append(stack[ii:len(stack):len(stack)], name)
+				return fmt.Errorf("table '%s' is recursive: %s", name, strings.Join(cycle, " -> "))
+			}
+		}
+		if done[name] {
+			return nil
+		}
+		stack = append(stack, name)
+		for _, child := range nested[name] {
+			if err := visit(child, stack); err != nil {
+				return err
+			}
+		}
+		done[name] = true
+		return nil
+	}
+	for _, schema := range tableSchemas {
+		if err := visit(lowerFirstLetter(schema.Name), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateEnumerable checks that the nested tables of enumerable tables are
+// enumerable too, so that deleting a row also deletes its nested rows.
+func validateEnumerable(tableSchemas []TableSchema) error {
+	enumerable := make(map[string]bool)
+	for _, schema := range tableSchemas {
+		enumerable[schema.Name] = schema.Enumerable
+	}
+	for _, schema := range tableSchemas {
+		if !schema.Enumerable {
+			continue
+		}
+		for _, field := range schema.AllValues() {
+			if field.Type.Type == TableType && !enumerable[upperFirstLetter(field.Type.Name)] {
+				return fmt.Errorf("field '%s' of enumerable table '%s' must be an enumerable table", field.Name, lowerFirstLetter(schema.Name))
+			}
+		}
+	}
+	return nil
+}
+
+func findField(fields []FieldSchema, name string) *FieldSchema {
+	for ii := range fields {
+		if fields[ii].Name == lowerFirstLetter(name) {
+			return &fields[ii]
+		}
+	}
+	return nil
+}
+
+// ParseTableSchemas parses a JSON data model definition.
+func ParseTableSchemas(jsonContent []byte) ([]TableSchema, error) {
+	return unmarshalTableSchemas(jsonContent)
+}
+
+// TableDefaultKey returns the datastore key under which generated code stores
+// the given table.
+func TableDefaultKey(tableName string) []byte {
+	return crypto.Keccak256([]byte("datamod.v1." + formatTableName(tableName)))
+}
+
+// Sizes returns the field sizes of a row, as passed to lib.NewDatastoreStruct.
+func (s TableSchema) Sizes() []int {
+	leaves := s.Leaves()
+	sizes := make([]int, len(leaves))
+	for i, field := range leaves {
+		sizes[i] = field.Type.Size
+	}
+	return sizes
 }
 
 type Config struct {
+	// JSON is the path to the schema, or to a Go package to derive it from.
 	JSON    string
 	Out     string
 	Package string
+	// Address is the address of the precompile, used by the generated
+	// Solidity libraries.
+	Address common.Address
+	// Previous is the path to a previous version of the schema. Generation
+	// fails if the schema changed incompatibly, and fields that moved are
+	// migrated by MigrateSchema.
+	Previous string
+	// PreviousLayout is the nested table layout the previous schema was
+	// generated with, NestedTableLayout if zero.
+	PreviousLayout int
+	// RPCNamespace enables the generation of a JSON-RPC service reading the
+	// tables, with the given default namespace.
+	RPCNamespace string
 }
 
-func GenerateDataModel(config Config, allowTableTypes bool) error {
+func GenerateDataModel(config Config) error {
 	if !isValidName(config.Package) {
 		return fmt.Errorf("invalid package name: %s", config.Package)
 	}
 
-	jsonContent, err := os.ReadFile(config.JSON)
+	jsonContent, err := ReadSchema(config.JSON)
 	if err != nil {
 		return err
 	}
-	schemas, err := unmarshalTableSchemas(jsonContent, allowTableTypes)
+	model, err := unmarshalDataModel(jsonContent)
 	if err != nil {
 		return err
 	}
 
+	diff := &SchemaDiff{Fingerprint: model.fingerprint()}
+	if config.Previous != "" {
+		prev, err := readDataModel(config.Previous)
+		if err != nil {
+			return fmt.Errorf("previous schema: %w", err)
+		}
+		if config.PreviousLayout != 0 {
+			prev.NestedLayout = config.PreviousLayout
+		}
+		diff = diffDataModels(prev, model)
+		if !diff.Compatible() {
+			return fmt.Errorf("incompatible schema changes:\n%s", diff)
+		}
+	}
+
 	funcMap := template.FuncMap{
 		"sub": func(a, b int) int { return a - b },
 	}
@@ -168,21 +470,63 @@ func GenerateDataModel(config Config, allowTableTypes bool) error {
 	if err != nil {
 		return err
 	}
+	enumTpl, err := template.New("enum").Funcs(funcMap).Parse(enumTpl)
+	if err != nil {
+		return err
+	}
+	structTpl, err := template.New("struct").Funcs(funcMap).Parse(structTpl)
+	if err != nil {
+		return err
+	}
+	schemaTpl, err := template.New("schema").Parse(schemaTpl)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"Package":             config.Package,
+		"Fingerprint":         diff.Fingerprint,
+		"PreviousFingerprint": diff.PreviousFingerprint,
+		"Migrations":          diff.migrations,
+	}
+	if err := writeTemplate(schemaTpl, data, config.Out, "datamodSchema"); err != nil {
+		return err
+	}
+
+	for _, enum := range model.Enums {
+		data := map[string]interface{}{
+			"Package": config.Package,
+			"Enum":    enum,
+		}
+		if err := writeTemplate(enumTpl, data, config.Out, enum.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, schema := range model.Structs {
+		data := map[string]interface{}{
+			"Package": config.Package,
+			"Struct":  schema,
+		}
+		if err := writeTemplate(structTpl, data, config.Out, schema.Name); err != nil {
+			return err
+		}
+	}
 
-	for _, schema := range schemas {
+	for _, schema := range model.Tables {
 		tableName := formatTableName(schema.Name)
 		rowName := formatRowName(schema.Name)
 
-		_sizes := make([]string, len(schema.Values))
-		for i, field := range schema.Values {
-			_sizes[i] = fmt.Sprint(field.Type.Size)
-		}
-		sizesStr := fmt.Sprintf("[]int{%s}", strings.Join(_sizes, ", "))
+		sizesStr := sizesString(schema.Sizes())
 
-		_keys := make([]string, len(schema.Keys))
+		_keys := make([]int, len(schema.Keys))
+		_dynamic := make([]string, len(schema.Keys))
 		for i, field := range schema.Keys {
-			_keys[i] = fmt.Sprint(field.Type.Size)
+			_keys[i] = field.Type.Size
+			_dynamic[i] = fmt.Sprint(field.Type.Type == BytesType)
 		}
+		keySizesStr := sizesString(_keys)
+		keyDynamicStr := fmt.Sprintf("[]bool{%s}", strings.Join(_dynamic, ", "))
 
 		data := map[string]interface{}{
 			"Package":         config.Package,
@@ -190,18 +534,30 @@ func GenerateDataModel(config Config, allowTableTypes bool) error {
 			"TableStructName": tableName,
 			"RowStructName":   rowName,
 			"SizesStr":        sizesStr,
+			"KeySizesStr":     keySizesStr,
+			"KeyDynamicStr":   keyDynamicStr,
 		}
 
-		var buf bytes.Buffer
-		if err := tpl.Execute(&buf, data); err != nil {
+		if err := writeTemplate(tpl, data, config.Out, tableName); err != nil {
 			return err
 		}
-		filename := camelToSnake(lowerFirstLetter(tableName)) + ".go"
-		outPath := filepath.Join(config.Out, filename)
-		err := os.WriteFile(outPath, buf.Bytes(), 0644)
-		if err != nil {
-			return err
+	}
+
+	if config.RPCNamespace != "" {
+		if !isValidName(config.RPCNamespace) {
+			return fmt.Errorf("invalid rpc namespace: %s", config.RPCNamespace)
 		}
+		return generateRPC(model, config)
 	}
 	return nil
 }
+
+func writeTemplate(tpl *template.Template, data interface{}, outDir string, typeName string) error {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return err
+	}
+	filename := camelToSnake(lowerFirstLetter(typeName)) + ".go"
+	outPath := filepath.Join(outDir, filename)
+	return os.WriteFile(outPath, buf.Bytes(), 0644)
+}
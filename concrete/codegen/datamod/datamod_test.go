@@ -22,10 +22,17 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
 	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/testdata"
 	"github.com/ethereum/go-ethereum/concrete/lib"
 	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/require"
 )
 
@@ -41,7 +48,7 @@ func TestBadDatamod(t *testing.T) {
 				JSON:    dirPath + file.Name(),
 				Out:     "./",
 				Package: "test",
-			}, true)
+			})
 			if err == nil {
 				t.Fatalf("Expected error but got nil")
 			}
@@ -167,3 +174,759 @@ func TestTables(t *testing.T) {
 		})
 	})
 }
+
+func TestNestedTables(t *testing.T) {
+	var (
+		r        = require.New(t)
+		addr     = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		config   = api.EnvConfig{}
+		meterGas = false
+		gas      = uint64(0)
+		env      = mock.NewMockEnvironment(addr, config, meterGas, gas)
+		ds       = lib.NewDatastore(env)
+		table    = testdata.NewMultiTableValue(ds)
+		row      = table.Get(uintVal)
+	)
+
+	row.Set(uintVal, boolVal)
+
+	t.Run("KeyedTable", func(t *testing.T) {
+		testRow(t, func() testRowInterface {
+			return row.GetKeyedTable().Get(uintVal, intVal, stringVal, bytesVal, boolVal, addrVal, bytes16Val)
+		})
+	})
+
+	t.Run("KeylessTable", func(t *testing.T) {
+		testRow(t, func() testRowInterface {
+			return table.Get(uintVal).GetKeylessTable()
+		})
+	})
+
+	t.Run("DeeplyNestedTable", func(t *testing.T) {
+		testRow(t, func() testRowInterface {
+			return row.GetNestedTable().Get(intVal).GetValueTable()
+		})
+	})
+
+	// Nested tables do not overlap the fields of their parent row, or each other
+	valueUint, _, _, _, valueBool := row.Get()
+	r.Equal(uintVal, valueUint)
+	r.Equal(boolVal, valueBool)
+	r.Equal(addrVal, row.GetKeylessTable().GetValueAddress())
+	r.Equal(common.Address{}, row.GetNestedTable().Get(uintVal).GetValueTable().GetValueAddress())
+	r.Equal(common.Address{}, table.Get(intVal).GetKeylessTable().GetValueAddress())
+
+	// Nested tables are rooted at the hash of their field slot
+	fieldSlot := row.GetField_slot(2)
+	nested := testdata.NewKeylessTableFromSlot(lib.NestedTableSlot(fieldSlot))
+	r.Equal(addrVal, nested.GetValueAddress())
+	r.Equal(common.Address{}, testdata.NewKeylessTableFromSlot(fieldSlot).GetValueAddress())
+}
+
+// Get returns the scalars of rows mixing scalar and table fields in field
+// order, along with the nested tables, which rows only holding tables return
+// too.
+func TestRowGetWithTables(t *testing.T) {
+	var (
+		r     = require.New(t)
+		env   = mock.NewMockEnvironment(common.Address{}, api.EnvConfig{}, false, 0)
+		ds    = lib.NewDatastore(env)
+		row   = testdata.NewMultiTableValue(ds).Get(uintVal)
+		other = big.NewInt(2)
+	)
+	row.Set(other, boolVal)
+	row.GetKeyedTable().Get(uintVal, intVal, stringVal, bytesVal, boolVal, addrVal, bytes16Val).SetValueUint(uintVal)
+	row.GetKeylessTable().SetValueAddress(addrVal)
+	row.GetNestedTable().Get(intVal).GetValueTable().SetValueString(stringVal)
+
+	valueUint, keyedTable, keylessTable, nestedTable, valueBool := row.Get()
+	r.Equal(other, valueUint)
+	r.Equal(boolVal, valueBool)
+	r.Equal(uintVal, keyedTable.Get(uintVal, intVal, stringVal, bytesVal, boolVal, addrVal, bytes16Val).GetValueUint())
+	r.Equal(addrVal, keylessTable.GetValueAddress())
+	r.Equal(stringVal, nestedTable.Get(intVal).GetValueTable().GetValueString())
+
+	tableOnly := testdata.NewKeylessWithKeylessTableValue(ds)
+	tableOnly.Set()
+	tableOnly.GetValueTable().SetValueBool(boolVal)
+	r.Equal(boolVal, tableOnly.Get().GetValueBool())
+}
+
+func TestNestedTableErrors(t *testing.T) {
+	cases := []struct {
+		schema string
+		err    string
+	}{
+		{`{"t": {"schema": {"a": "table t"}}}`, "table 't' is recursive: t -> t"},
+		{`{"a": {"keySchema": {"k": "uint"}, "schema": {"b": "table b"}}, "b": {"schema": {"a": "table a"}}}`, "table 'a' is recursive: a -> b -> a"},
+		{`{"a": {"schema": {"b": "table b"}}, "b": {"schema": {"c": "table c", "v": "uint"}}, "c": {"schema": {"b": "table b"}}}`, "table 'b' is recursive: b -> c -> b"},
+		{`{"t": {"schema": {"a": "table missing"}}}`, "table 'missing' does not exist"},
+		{`{"a": {"schema": {"v": "uint"}}, "t": {"keySchema": {"k": "table a"}, "schema": {"v": "uint"}}}`, "table 't' cannot have table keys"},
+	}
+	for _, c := range cases {
+		_, err := unmarshalTableSchemas([]byte(c.schema))
+		require.ErrorContains(t, err, c.err, c.schema)
+	}
+
+	// The same table can be nested several times without forming a cycle
+	_, err := unmarshalTableSchemas([]byte(`{
+		"a": {"schema": {"v": "uint"}},
+		"b": {"schema": {"x": "table a", "y": "table a"}},
+		"c": {"schema": {"a": "table a", "b": "table b"}}
+	}`))
+	require.NoError(t, err)
+}
+
+func TestArrayFieldType(t *testing.T) {
+	r := require.New(t)
+
+	fieldType, err := nameToFieldType("uint256[]")
+	r.NoError(err)
+	r.Equal(ArrayType, fieldType.Type)
+	r.Equal(0, fieldType.Length)
+	r.Equal("[]*big.Int", fieldType.GoType)
+	r.Equal("Uint256", fieldType.Elem.Codec)
+
+	fieldType, err = nameToFieldType("bytes32[4]")
+	r.NoError(err)
+	r.Equal(4, fieldType.Length)
+	r.Equal("Hash", fieldType.Elem.Codec)
+
+	for _, name := range []string{"uint[][]", "uint[0]", "table foo[]", "foo[]", "uint[-1]"} {
+		_, err = nameToFieldType(name)
+		r.Error(err, name)
+	}
+}
+
+func TestArrayTable(t *testing.T) {
+	var (
+		r        = require.New(t)
+		addr     = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		config   = api.EnvConfig{}
+		meterGas = false
+		gas      = uint64(0)
+		env      = mock.NewMockEnvironment(addr, config, meterGas, gas)
+		ds       = lib.NewDatastore(env)
+		table    = testdata.NewArrayTable(ds)
+		row      = table.Get(uintVal)
+	)
+
+	uints, addrs, hashes, strs, uint8s, boolVal := row.Get()
+	r.Empty(uints)
+	r.Empty(addrs)
+	r.Equal(make([]common.Hash, 4), hashes)
+	r.Empty(strs)
+	r.Equal([]uint8{0, 0, 0}, uint8s)
+	r.False(boolVal)
+
+	row.Set(
+		[]*big.Int{big.NewInt(1), big.NewInt(2)},
+		[]common.Address{addrVal},
+		[]common.Hash{{0x01}, {0x02}, {0x03}, {0x04}},
+		[]string{"a", "b", "c"},
+		[]uint8{7, 8, 9},
+		true,
+	)
+
+	row = table.Get(uintVal)
+	uints, addrs, hashes, strs, uint8s, boolVal = row.Get()
+	r.Equal([]*big.Int{big.NewInt(1), big.NewInt(2)}, uints)
+	r.Equal([]common.Address{addrVal}, addrs)
+	r.Equal([]common.Hash{{0x01}, {0x02}, {0x03}, {0x04}}, hashes)
+	r.Equal([]string{"a", "b", "c"}, strs)
+	r.Equal([]uint8{7, 8, 9}, uint8s)
+	r.True(boolVal)
+
+	arr := row.GetValueStringsArray()
+	arr.Push("d")
+	arr.Set(0, "z")
+	r.Equal(uint64(4), arr.Length())
+	r.Equal("d", arr.Pop())
+	r.Equal([]string{"z", "b", "c"}, row.GetValueStrings())
+
+	fixed := row.GetValueHashesArray()
+	fixed.Set(3, common.Hash{0xff})
+	r.Equal(common.Hash{0xff}, row.GetValueHashes()[3])
+	r.Panics(func() { row.SetValueHashes(nil) })
+}
+
+func TestEnumAndStructTable(t *testing.T) {
+	var (
+		r        = require.New(t)
+		addr     = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		config   = api.EnvConfig{}
+		meterGas = false
+		gas      = uint64(0)
+		env      = mock.NewMockEnvironment(addr, config, meterGas, gas)
+		ds       = lib.NewDatastore(env)
+		table    = testdata.NewShapeTable(ds)
+	)
+
+	r.True(testdata.ColorBlue.IsValid())
+	r.False(testdata.Color(3).IsValid())
+	r.Equal("Green", testdata.ColorGreen.String())
+
+	shape := testdata.Shape{
+		Color:   testdata.ColorGreen,
+		Origin:  testdata.Point{X: -1, Y: 2},
+		Name:    "square",
+		Palette: []testdata.Color{testdata.ColorRed, testdata.ColorBlue},
+	}
+	center := testdata.Point{X: 3, Y: -4}
+
+	row := table.Get(1, testdata.ColorRed)
+	row.Set(shape, true, center)
+
+	row = table.Get(1, testdata.ColorRed)
+	shapeCur, visibleCur, centerCur := row.Get()
+	r.Equal(shape, shapeCur)
+	r.True(visibleCur)
+	r.Equal(center, centerCur)
+	r.Equal(int32(2), row.GetShapeOriginY())
+
+	row.SetShapeOriginX(5)
+	r.Equal(testdata.Point{X: 5, Y: 2}, row.GetShapeOrigin())
+	r.Equal(center, row.GetCenter())
+
+	// Struct fields are packed into the parent row
+	r.Equal([]byte{byte(testdata.ColorGreen)}, row.GetField(0))
+	r.Equal(codec.EncodeSmallInt32(4, 5), row.GetField(1))
+
+	r.Panics(func() { row.SetShapeColor(testdata.Color(3)) })
+	r.Equal(testdata.Color(0), table.Get(2, testdata.ColorRed).GetShapeColor())
+}
+
+func TestEnumAndStructErrors(t *testing.T) {
+	cases := []struct {
+		schema string
+		err    string
+	}{
+		{`{"t": {"schema": {"a": "enum missing"}}}`, "enum 'missing' is not defined"},
+		{`{"t": {"schema": {"a": "struct missing"}}}`, "struct 'missing' is not defined"},
+		{`{"t": {"keySchema": {"k": "enum missing"}, "schema": {"a": "uint"}}}`, "enum 'missing' is not defined"},
+		{`{"s": {"struct": {"a": "struct s"}}}`, "struct 's' is recursive: s -> s"},
+		{`{"a": {"struct": {"b": "struct b"}}, "b": {"struct": {"a": "struct a"}}}`, "struct 'a' is recursive: a -> b -> a"},
+		{`{"e": {"enum": []}}`, "invalid values for enum 'e'"},
+		{`{"e": {"enum": ["a", "a"]}}`, "duplicate value 'A' for enum 'e'"},
+		{`{"s": {"struct": {"a": "uint"}}, "t": {"keySchema": {"k": "struct s"}, "schema": {"a": "uint"}}}`, "cannot have struct keys"},
+		{`{"e": {"enum": ["a"]}, "t": {"schema": {"a": "table e"}}}`, "table 'e' does not exist"},
+	}
+	for _, c := range cases {
+		_, err := unmarshalTableSchemas([]byte(c.schema))
+		require.ErrorContains(t, err, c.err, c.schema)
+	}
+}
+
+func TestIndexedTable(t *testing.T) {
+	var (
+		r        = require.New(t)
+		addr     = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		config   = api.EnvConfig{}
+		meterGas = false
+		gas      = uint64(0)
+		env      = mock.NewMockEnvironment(addr, config, meterGas, gas)
+		ds       = lib.NewDatastore(env)
+		table    = testdata.NewOwnedTable(ds)
+		alice    = common.Address{0x0a}
+		bob      = common.Address{0x0b}
+	)
+
+	for ii := uint64(0); ii < 5; ii++ {
+		table.Get(ii, "row").Set(alice, testdata.ColorRed, "label", big.NewInt(int64(ii)), nil)
+	}
+	table.Get(5, "other").Set(bob, testdata.ColorBlue, "label", big.NewInt(5), []uint8{1, 2})
+
+	r.Equal(uint64(5), table.CountByOwner(alice))
+	r.Equal(uint64(1), table.CountByOwner(bob))
+	r.Equal(uint64(6), table.CountByLabel("label"))
+	r.Equal(uint64(0), table.CountByLabel("missing"))
+	r.Equal(uint64(5), table.CountByOwnerAndKind(alice, testdata.ColorRed))
+	r.Equal(uint64(0), table.CountByOwnerAndKind(alice, testdata.ColorBlue))
+
+	// Pagination
+	r.Equal([]testdata.OwnedTableKey{{Id: 0, Name: "row"}, {Id: 1, Name: "row"}}, table.FindByOwner(alice, 0, 2))
+	r.Equal([]testdata.OwnedTableKey{{Id: 4, Name: "row"}}, table.FindByOwner(alice, 4, 2))
+	r.Equal([]testdata.OwnedTableKey{}, table.FindByOwner(alice, 5, 2))
+	r.Equal([]testdata.OwnedTableKey{{Id: 5, Name: "other"}}, table.FindByOwnerAndKind(bob, testdata.ColorBlue, 0, 10))
+
+	// Updating an indexed field moves the row to its new groups
+	row := table.Get(2, "row")
+	row.SetOwner(bob)
+	r.Equal(uint64(4), table.CountByOwner(alice))
+	r.Equal(uint64(2), table.CountByOwner(bob))
+	r.Equal(uint64(1), table.CountByOwnerAndKind(bob, testdata.ColorRed))
+	r.Equal(uint64(6), table.CountByLabel("label"))
+
+	row.SetKind(testdata.ColorBlue)
+	r.Equal([]testdata.OwnedTableKey{{Id: 5, Name: "other"}, {Id: 2, Name: "row"}}, table.FindByOwnerAndKind(bob, testdata.ColorBlue, 0, 10))
+
+	// Setting the same value, or a non-indexed field, leaves indexes unchanged
+	row.SetOwner(bob)
+	row.SetAmount(big.NewInt(100))
+	row.SetTags([]uint8{3})
+	r.Equal(uint64(2), table.CountByOwner(bob))
+
+	row.Set(alice, testdata.ColorRed, "new label", big.NewInt(2), nil)
+	r.Equal(uint64(5), table.CountByOwner(alice))
+	r.Equal(uint64(5), table.CountByLabel("label"))
+	r.Equal([]testdata.OwnedTableKey{{Id: 2, Name: "row"}}, table.FindByLabel("new label", 0, 10))
+
+	// Delete clears the row and removes it from all indexes
+	row.Delete()
+	r.Equal(uint64(4), table.CountByOwner(alice))
+	r.Equal(uint64(0), table.CountByLabel("new label"))
+	owner, kind, label, amount, tags := table.Get(2, "row").Get()
+	r.Equal(common.Address{}, owner)
+	r.Equal(testdata.ColorRed, kind)
+	r.Equal("", label)
+	r.Equal(0, amount.Sign())
+	r.Empty(tags)
+
+	// Keyless tables index their single row
+	singleton := testdata.NewOwnedSingleton(ds)
+	singleton.Set(alice, big.NewInt(1))
+	r.Equal([]testdata.OwnedSingletonKey{{}}, singleton.FindByOwner(alice, 0, 10))
+	singleton.SetOwner(bob)
+	r.Equal(uint64(0), singleton.CountByOwner(alice))
+	r.Equal(uint64(1), singleton.CountByOwner(bob))
+	singleton.Delete()
+	r.Equal(uint64(0), singleton.CountByOwner(bob))
+}
+
+func TestIndexedTableGas(t *testing.T) {
+	var (
+		r        = require.New(t)
+		addr     = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		config   = api.EnvConfig{}
+		meterGas = true
+		gas      = uint64(1e9)
+		env      = mock.NewMockEnvironment(addr, config, meterGas, gas)
+		ds       = lib.NewDatastore(env)
+		table    = testdata.NewOwnedTable(ds)
+		alice    = common.Address{0x0a}
+		bob      = common.Address{0x0b}
+	)
+
+	gasUsed := func(f func()) uint64 {
+		before := env.Gas()
+		f()
+		return before - env.Gas()
+	}
+
+	for ii := uint64(0); ii < 50; ii++ {
+		table.Get(ii, "row").Set(alice, testdata.ColorRed, "label", big.NewInt(1), nil)
+	}
+	table.Get(50, "row").Set(bob, testdata.ColorRed, "label", big.NewInt(1), nil)
+
+	// Updating a non-indexed field does not touch the indexes
+	row := table.Get(0, "row")
+	amountGas := gasUsed(func() { row.SetAmount(big.NewInt(2)) })
+	plain := lib.NewDatastoreStruct(ds.Get([]byte("plain")), []int{32})
+	plain.SetField(0, common.Hash{0x01}.Bytes())
+	plainGas := gasUsed(func() { plain.SetField(0, common.Hash{0x02}.Bytes()) })
+	r.InDelta(float64(plainGas), float64(amountGas), float64(plainGas)/10)
+
+	// Updating an indexed field is bounded, regardless of the size of the groups
+	ownerGas := gasUsed(func() { row.SetOwner(bob) })
+	backGas := gasUsed(func() { table.Get(1, "row").SetOwner(bob) })
+	r.InDelta(float64(ownerGas), float64(backGas), float64(ownerGas)/10)
+
+	// Queries scale with the page size, not with the size of the group
+	smallGas := gasUsed(func() { table.FindByOwner(bob, 0, 3) })
+	largeGas := gasUsed(func() { table.FindByOwner(alice, 0, 3) })
+	r.InDelta(float64(smallGas), float64(largeGas), float64(smallGas)/10)
+	pageGas := gasUsed(func() { table.FindByOwner(alice, 0, 30) })
+	r.Greater(pageGas, 5*largeGas)
+}
+
+func TestEnumerableTable(t *testing.T) {
+	var (
+		r         = require.New(t)
+		addr      = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		config    = api.EnvConfig{}
+		meterGas  = false
+		gas       = uint64(0)
+		env       = mock.NewMockEnvironment(addr, config, meterGas, gas)
+		ds        = lib.NewDatastore(env)
+		inventory = testdata.NewInventory(ds)
+		alice     = common.Address{0x0a}
+		bob       = common.Address{0x0b}
+	)
+
+	r.Equal(uint64(0), inventory.Len())
+	r.False(inventory.Has(alice))
+	r.Equal([]testdata.InventoryKey{}, inventory.Keys(0, 10))
+
+	// Getting a row does not add it
+	inventory.Get(alice).GetTotal()
+	r.False(inventory.Has(alice))
+
+	inventory.Get(alice).SetTotal(big.NewInt(1))
+	inventory.Get(bob).Set(big.NewInt(2))
+	inventory.Get(alice).SetTotal(big.NewInt(3))
+	r.True(inventory.Has(alice))
+	r.Equal(uint64(2), inventory.Len())
+	r.Equal([]testdata.InventoryKey{{Owner: alice}, {Owner: bob}}, inventory.Keys(0, 10))
+	r.Equal([]testdata.InventoryKey{{Owner: bob}}, inventory.Keys(1, 10))
+
+	// Nested tables keep their own row sets
+	aliceItems := inventory.Get(alice).GetItems()
+	bobItems := inventory.Get(bob).GetItems()
+	aliceItems.Get(1, "sword").Set(1, "weapon")
+	aliceItems.Get(2, "shield").SetCount(1)
+	bobItems.Get(1, "bow").SetLabel("weapon")
+	r.Equal(uint64(2), aliceItems.Len())
+	r.Equal(uint64(1), bobItems.Len())
+	r.True(aliceItems.Has(1, "sword"))
+	r.False(aliceItems.Has(1, "bow"))
+	r.Equal([]testdata.ItemKey{{Id: 1, Name: "sword"}, {Id: 2, Name: "shield"}}, aliceItems.Keys(0, 10))
+	r.Equal([]testdata.ItemKey{{Id: 1, Name: "sword"}}, aliceItems.FindByLabel("weapon", 0, 10))
+
+	aliceItems.Delete(1, "sword")
+	r.False(aliceItems.Has(1, "sword"))
+	r.Equal(uint64(0), aliceItems.CountByLabel("weapon"))
+	r.Equal([]testdata.ItemKey{{Id: 2, Name: "shield"}}, aliceItems.Keys(0, 10))
+	count, label := aliceItems.Get(1, "sword").Get()
+	r.Equal(uint32(0), count)
+	r.Equal("", label)
+
+	// Deleting a row deletes the rows of its nested tables
+	aliceItems.Get(3, "helmet").Set(2, "armor")
+	inventory.Delete(alice)
+	r.False(inventory.Has(alice))
+	r.Equal(uint64(1), inventory.Len())
+	r.Equal(0, inventory.Get(alice).GetTotal().Sign())
+	r.Equal(uint64(0), aliceItems.Len())
+	r.Equal(uint32(0), aliceItems.Get(3, "helmet").GetCount())
+	r.Equal(uint64(1), bobItems.Len())
+
+	// Deleted rows can be added again
+	inventory.Get(alice).SetTotal(big.NewInt(4))
+	r.Equal([]testdata.InventoryKey{{Owner: bob}, {Owner: alice}}, inventory.Keys(0, 10))
+
+	inventory.Clear()
+	r.Equal(uint64(0), inventory.Len())
+	r.Equal(uint64(0), bobItems.Len())
+	r.Equal(0, inventory.Get(bob).GetTotal().Sign())
+}
+
+func TestEnumerableErrors(t *testing.T) {
+	cases := []struct {
+		schema string
+		err    string
+	}{
+		{`{"t": {"schema": {"a": "uint"}, "enumerable": true}}`, "enumerable table 't' must have keys"},
+		{`{"t": {"keySchema": {"k": "uint"}, "schema": {"a": "uint"}, "enumerable": 1}}`, "invalid enumerable flag for table 't'"},
+		{`{"t": {"keySchema": {"k": "uint"}, "schema": {"a": "table u"}, "enumerable": true}, "u": {"keySchema": {"k": "uint"}, "schema": {"a": "uint"}}}`, "field 'a' of enumerable table 't' must be an enumerable table"},
+	}
+	for _, c := range cases {
+		_, err := unmarshalTableSchemas([]byte(c.schema))
+		require.ErrorContains(t, err, c.err, c.schema)
+	}
+}
+
+func TestSolidity(t *testing.T) {
+	var (
+		r      = require.New(t)
+		outDir = t.TempDir()
+	)
+	err := GenerateSolidity(Config{
+		JSON:    "./testdata/good-datamod.json",
+		Out:     outDir,
+		Address: common.HexToAddress("0x80"),
+	})
+	r.NoError(err)
+
+	files, err := os.ReadDir("./testdata/solidity")
+	r.NoError(err)
+	generated, err := os.ReadDir(outDir)
+	r.NoError(err)
+	r.Len(generated, len(files))
+	for _, file := range files {
+		want, err := os.ReadFile("./testdata/solidity/" + file.Name())
+		r.NoError(err)
+		got, err := os.ReadFile(outDir + "/" + file.Name())
+		r.NoError(err)
+		r.Equal(string(want), string(got), file.Name())
+	}
+}
+
+func TestSchemaDiff(t *testing.T) {
+	r := require.New(t)
+
+	diff, err := DiffSchemas("./testdata/migrations/v1.json", "./testdata/migrations/v2.json", 0)
+	r.NoError(err)
+	r.True(diff.Compatible())
+	r.Equal(`enum Color:
+  + values Blue
+Account:
+  + flag bool
+  + nonce uint64
+  ~ balance uint64 moved from slot 0 offset 0 to slot 0 offset 9
+Config:
+  + admin address
+  ~ fee uint32 moved from slot 0 offset 0 to slot 0 offset 20
+  + owner address
+  ~ hashes bytes32[2] moved from slot 2 offset 0 to slot 3 offset 0
+Log:
+  + extra bool
+Fresh:
+  + table`, diff.String())
+	r.Equal([]tableMigration{
+		{
+			Table:     "Account",
+			PrevSizes: "[]int{8, 32, 32}",
+			Sizes:     "[]int{1, 8, 8, 32, 32}",
+			Moves:     []fieldMigration{{From: 0, To: 2, Kind: "FieldValue"}},
+		},
+		{
+			Table:     "Config",
+			PrevSizes: "[]int{4, 32, 32}",
+			Sizes:     "[]int{20, 4, 32, 20, 32}",
+			Keyless:   true,
+			Moves: []fieldMigration{
+				{From: 0, To: 1, Kind: "FieldValue"},
+				{From: 2, To: 4, Kind: "FieldFixedArray", Length: 2},
+			},
+		},
+	}, diff.migrations)
+
+	diff, err = DiffSchemas("./testdata/migrations/v1.json", "./testdata/migrations/incompatible.json", 0)
+	r.NoError(err)
+	r.False(diff.Compatible())
+	var incompatible []string
+	for _, change := range diff.Changes {
+		if change.Reason != "" {
+			incompatible = append(incompatible, change.Table+": "+change.Detail)
+		}
+	}
+	r.Equal([]string{
+		"enum Color: values (Red, Green) -> (Green, Red)",
+		"Account: balance uint64 -> uint32",
+		"Account: name string",
+		"Log: index byValue (value)",
+		"Log: value uint256 moved from slot 0 offset 0 to slot 1 offset 0",
+		"Config: table",
+	}, incompatible)
+
+	err = GenerateDataModel(Config{
+		JSON:     "./testdata/migrations/incompatible.json",
+		Out:      t.TempDir(),
+		Package:  "test",
+		Previous: "./testdata/migrations/v1.json",
+	})
+	r.ErrorContains(err, "incompatible schema changes")
+	r.ErrorContains(err, "- name string (incompatible: removed fields would be left in the row)")
+}
+
+func TestSchemaFingerprint(t *testing.T) {
+	r := require.New(t)
+	fingerprint := func(schema string) string {
+		model, err := unmarshalDataModel([]byte(schema))
+		r.NoError(err)
+		return model.fingerprint().Hex()
+	}
+	var (
+		a = `{"a": {"schema": {"x": "uint8", "y": "string"}}, "b": {"keySchema": {"k": "uint"}, "schema": {"z": "bool"}}}`
+		b = `{"b": {"keySchema": {"k": "uint256"}, "schema": {"z": "bool"}}, "a": {"schema": {"x": "uint8", "y": "string"}}}`
+		c = `{"a": {"schema": {"y": "string", "x": "uint8"}}, "b": {"keySchema": {"k": "uint"}, "schema": {"z": "bool"}}}`
+	)
+	r.Equal(fingerprint(a), fingerprint(b))
+	r.NotEqual(fingerprint(a), fingerprint(c))
+	r.Equal(testdata.SchemaFingerprint.Hex(), fingerprint(mustReadFile(t, "./testdata/good-datamod.json")))
+}
+
+func TestNestedTableLayout(t *testing.T) {
+	r := require.New(t)
+	fingerprint := func(schema string, layout int) common.Hash {
+		model, err := unmarshalDataModel([]byte(schema))
+		r.NoError(err)
+		model.NestedLayout = layout
+		return model.fingerprint()
+	}
+	var (
+		flat   = `{"a": {"schema": {"x": "uint8"}}}`
+		nested = `{"a": {"schema": {"x": "uint8"}}, "b": {"keySchema": {"k": "uint"}, "schema": {"t": "table a"}}}`
+	)
+	r.Equal(fingerprint(flat, 1), fingerprint(flat, NestedTableLayout))
+	r.NotEqual(fingerprint(nested, 1), fingerprint(nested, NestedTableLayout))
+
+	// Nested tables written with the previous layout cannot be migrated
+	diff, err := DiffSchemas("./testdata/good-datamod.json", "./testdata/good-datamod.json", NestedTableLayout)
+	r.NoError(err)
+	r.Empty(diff.Changes)
+	r.Equal(diff.PreviousFingerprint, diff.Fingerprint)
+
+	diff, err = DiffSchemas("./testdata/good-datamod.json", "./testdata/good-datamod.json", 1)
+	r.NoError(err)
+	r.False(diff.Compatible())
+	r.NotEqual(diff.PreviousFingerprint, diff.Fingerprint)
+	r.Contains(diff.String(), "Inventory:\n  ~ items table item moved from nested table layout 1 to 2 (incompatible: the rows of nested tables cannot be migrated)")
+
+	err = GenerateDataModel(Config{
+		JSON:           "./testdata/good-datamod.json",
+		Out:            t.TempDir(),
+		Package:        "test",
+		Previous:       "./testdata/good-datamod.json",
+		PreviousLayout: 1,
+	})
+	r.ErrorContains(err, "incompatible schema changes")
+}
+
+func mustReadFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestSchemaFromGoPackage(t *testing.T) {
+	var (
+		r      = require.New(t)
+		pkgDir = "./testdata/gostructs"
+		outDir = t.TempDir()
+	)
+	content, err := SchemaFromGoPackage(pkgDir)
+	r.NoError(err)
+	r.Equal(mustReadFile(t, pkgDir+"/schema.json"), string(content))
+
+	// The wrappers are the same as those generated from good-datamod.json.
+	err = GenerateDataModel(Config{JSON: pkgDir, Out: outDir, Package: "testdata"})
+	r.NoError(err)
+	for _, name := range []string{"color", "point", "shape", "shapeTable", "ownedTable", "inventory", "item", "ledger"} {
+		got := mustReadFile(t, outDir+"/"+camelToSnake(name)+".go")
+		r.Equal(mustReadFile(t, "./testdata/"+name+".go"), got, name)
+	}
+
+	for _, tc := range []struct {
+		src string
+		err string
+	}{
+		{"type T struct {\n\tK uint64 `datamod:\"key\"`\n\tV map[string]int\n}", "cannot infer the datamod type of"},
+		{"//datamod:table sorted\ntype T struct {\n\tV uint64\n}", "invalid option 'sorted' for table 'T'"},
+		{"type S struct{}\ntype T struct {\n\tS\n\tV uint64 `datamod:\"uint32\"`\n}", "embedded fields are not supported"},
+		{"type P struct {\n\tX uint8 `datamod:\"key\"`\n}\ntype T struct {\n\tV P `datamod:\"struct p\"`\n}", "cannot be a key or be indexed"},
+		{"type T struct {\n\tV uint64\n}", "no datamod tables found"},
+		{"type T struct {\n\tV uint64 `datamod:\"uint7\"`\n}", "invalid type 'uint7'"},
+	} {
+		dir := t.TempDir()
+		src := "package model\n\n" + tc.src + "\n"
+		r.NoError(os.WriteFile(dir+"/model.go", []byte(src), 0644))
+		_, err := SchemaFromGoPackage(dir)
+		r.ErrorContains(err, tc.err, tc.src)
+	}
+}
+
+type testRPCBackend struct {
+	db      state.Database
+	headers []*types.Header
+}
+
+func (b *testRPCBackend) CurrentBlock() *types.Header      { return b.headers[len(b.headers)-1] }
+func (b *testRPCBackend) CurrentSafeBlock() *types.Header  { return b.headers[0] }
+func (b *testRPCBackend) CurrentFinalBlock() *types.Header { return b.headers[0] }
+
+func (b *testRPCBackend) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(b.headers)) {
+		return nil
+	}
+	return b.headers[number]
+}
+
+func (b *testRPCBackend) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range b.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return nil
+}
+
+func (b *testRPCBackend) StateAt(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, b.db, nil)
+}
+
+type testRegistrar struct {
+	apis []rpc.API
+}
+
+func (r *testRegistrar) RegisterAPIs(apis []rpc.API) {
+	r.apis = append(r.apis, apis...)
+}
+
+func TestRPC(t *testing.T) {
+	var (
+		r          = require.New(t)
+		addr       = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		db         = state.NewDatabase(rawdb.NewMemoryDatabase())
+		statedb, _ = state.New(types.EmptyRootHash, db, nil)
+		env        = api.NewNoCallEnvironment(addr, api.EnvConfig{}, statedb, false, 0)
+		ds         = lib.NewDatastore(env)
+		backend    = &testRPCBackend{db: db}
+	)
+
+	commit := func() {
+		root, err := statedb.Commit(false)
+		r.NoError(err)
+		backend.headers = append(backend.headers, &types.Header{Number: big.NewInt(int64(len(backend.headers))), Root: root})
+	}
+
+	commit()
+	keyed := testdata.NewKeyedTable(ds).Get(uintVal, intVal, stringVal, bytesVal, boolVal, addrVal, bytes16Val)
+	keyed.Set(uintVal, intVal, stringVal, bytesVal, boolVal, addrVal, bytes16Val)
+	multi := testdata.NewMultiTableValue(ds).Get(uintVal)
+	multi.Set(uintVal, boolVal)
+	multi.GetKeylessTable().SetValueString(stringVal)
+	testdata.NewArrayTable(ds).Get(uintVal).SetValueUints([]*big.Int{big.NewInt(1), big.NewInt(2)})
+	testdata.NewShapeTable(ds).Get(1, testdata.ColorGreen).SetShapeOrigin(testdata.Point{X: 3, Y: -4})
+	commit()
+
+	server := rpc.NewServer()
+	r.NoError(server.RegisterName(testdata.RPCNamespace, testdata.NewRPC(backend, addr)))
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	keys := []interface{}{(*hexutil.Big)(uintVal), (*math.Decimal256)(intVal), stringVal, hexutil.Bytes(bytesVal), boolVal, addrVal, hexutil.Bytes(bytes16Val)}
+	var record map[string]interface{}
+	r.NoError(client.Call(&record, "testdata_getKeyedTable", keys...))
+	r.Equal(map[string]interface{}{
+		"valueUint":    "0x1",
+		"valueInt":     "-1",
+		"valueString":  "string",
+		"valueBytes":   "0x6279746573",
+		"valueBool":    true,
+		"valueAddress": "0x1234567890123456789012345678901234567890",
+		"valueBytes16": "0x12345678901234567890123456789012",
+	}, record)
+
+	// Reads default to the latest block, and can be made at any block
+	r.NoError(client.Call(&record, "testdata_getKeyedTable", append(keys, "0x0")...))
+	r.Equal("0x0", record["valueUint"])
+	r.NoError(client.Call(&record, "testdata_getKeyedTable", append(keys, "safe")...))
+	r.Equal("0x0", record["valueUint"])
+	r.NoError(client.Call(&record, "testdata_getKeyedTable", append(keys, backend.headers[1].Hash())...))
+	r.Equal("0x1", record["valueUint"])
+	r.ErrorContains(client.Call(&record, "testdata_getKeyedTable", append(keys, "0x2")...), "header not found")
+
+	// Nested tables are returned as slots, which can be read with At methods
+	r.NoError(client.Call(&record, "testdata_getMultiTableValue", (*hexutil.Big)(uintVal)))
+	r.Equal("0x1", record["valueUint"])
+	r.Equal(true, record["valueBool"])
+	nestedSlot := lib.NestedTableSlot(multi.GetField_slot(2)).Slot()
+	r.Equal(nestedSlot.Hex(), record["keylessTable"])
+	r.NoError(client.Call(&record, "testdata_getKeylessTableAt", nestedSlot))
+	r.Equal("string", record["valueString"])
+
+	// Arrays and structs
+	r.NoError(client.Call(&record, "testdata_getArrayTable", (*hexutil.Big)(uintVal)))
+	r.Equal([]interface{}{"0x1", "0x2"}, record["valueUints"])
+	r.Equal([]interface{}{float64(0), float64(0), float64(0)}, record["valueUint8s"])
+	r.NoError(client.Call(&record, "testdata_getShapeTable", hexutil.Uint64(1), testdata.ColorGreen))
+	r.Equal(map[string]interface{}{"x": float64(3), "y": float64(-4)}, record["shape"].(map[string]interface{})["origin"])
+
+	registrar := &testRegistrar{}
+	testdata.RegisterRPC(registrar, "", backend, addr)
+	testdata.RegisterRPC(registrar, "game", backend, addr)
+	r.Len(registrar.apis, 2)
+	r.Equal("testdata", registrar.apis[0].Namespace)
+	r.Equal("game", registrar.apis[1].Namespace)
+}
@@ -0,0 +1,249 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+)
+
+type Field struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+type Row struct {
+	Table  string
+	Slot   common.Hash
+	Fields []Field
+}
+
+func (r *Row) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s @ %s\n", r.Table, r.Slot.Hex())
+	for _, field := range r.Fields {
+		fmt.Fprintf(&b, "  %s (%s): %s\n", field.Name, field.Type, field.Value)
+	}
+	return b.String()
+}
+
+// sourceKV adapts a StorageSource to a read-only lib.KeyValueStore. Reads
+// never fail, so the first error is kept and checked after decoding.
+type sourceKV struct {
+	source  StorageSource
+	address common.Address
+	err     error
+}
+
+func (kv *sourceKV) Get(key common.Hash) common.Hash {
+	value, err := kv.source.GetState(kv.address, key)
+	if err != nil && kv.err == nil {
+		kv.err = err
+	}
+	return value
+}
+
+func (kv *sourceKV) Set(key common.Hash, value common.Hash) {
+	panic("decoder storage is read-only")
+}
+
+var _ lib.KeyValueStore = (*sourceKV)(nil)
+
+// Decoder reads datamod tables from the storage of a precompile. Slots are
+// computed with the same lib primitives used by generated code.
+type Decoder struct {
+	tables map[string]datamod.TableSchema
+	kv     *sourceKV
+	ds     lib.Datastore
+}
+
+func NewDecoder(schemas []datamod.TableSchema, source StorageSource, address common.Address) *Decoder {
+	tables := make(map[string]datamod.TableSchema, len(schemas))
+	for _, schema := range schemas {
+		tables[schema.Name] = schema
+	}
+	kv := &sourceKV{source: source, address: address}
+	return &Decoder{
+		tables: tables,
+		kv:     kv,
+		ds:     lib.NewKVDatastore(kv),
+	}
+}
+
+func (d *Decoder) table(name string) (datamod.TableSchema, error) {
+	if len(name) > 0 {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	schema, ok := d.tables[name]
+	if !ok {
+		return datamod.TableSchema{}, fmt.Errorf("table '%s' does not exist", name)
+	}
+	return schema, nil
+}
+
+// TableSlot returns the slot at which a table is stored by default.
+func (d *Decoder) TableSlot(table string) (common.Hash, error) {
+	schema, err := d.table(table)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return d.ds.Get(datamod.TableDefaultKey(schema.Name)).Slot(), nil
+}
+
+func (d *Decoder) rowSlot(schema datamod.TableSchema, tableSlot common.Hash, keys []string) (lib.DatastoreSlot, error) {
+	if len(keys) != len(schema.Keys) {
+		return nil, fmt.Errorf("table '%s' has %d keys, got %d", schema.Name, len(schema.Keys), len(keys))
+	}
+	dsSlot := d.ds.Get(tableSlot.Bytes())
+	if len(keys) == 0 {
+		return dsSlot, nil
+	}
+	encodedKeys := make([][]byte, len(keys))
+	for ii, key := range schema.Keys {
+		parse, ok := parserFor(&key.Type)
+		if !ok {
+			return nil, fmt.Errorf("unsupported type '%s' for key '%s'", key.Type.Name, key.Name)
+		}
+		encoded, err := parse(key.Type.Size, keys[ii])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for key '%s': %w", key.Name, err)
+		}
+		encodedKeys[ii] = encoded
+	}
+	return dsSlot.Mapping().GetNested(encodedKeys...), nil
+}
+
+// RowSlot returns the slot of the row with the given keys in a table stored
+// at tableSlot.
+func (d *Decoder) RowSlot(table string, tableSlot common.Hash, keys []string) (common.Hash, error) {
+	schema, err := d.table(table)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	dsSlot, err := d.rowSlot(schema, tableSlot, keys)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return dsSlot.Slot(), nil
+}
+
+// Row decodes the row with the given keys from a table at its default slot.
+func (d *Decoder) Row(table string, keys []string) (*Row, error) {
+	tableSlot, err := d.TableSlot(table)
+	if err != nil {
+		return nil, err
+	}
+	return d.RowAt(table, tableSlot, keys)
+}
+
+// RowAt decodes the row with the given keys from a table stored at tableSlot.
+// Table fields are returned as the slot of the nested table, which can in
+// turn be passed to RowAt.
+func (d *Decoder) RowAt(table string, tableSlot common.Hash, keys []string) (*Row, error) {
+	schema, err := d.table(table)
+	if err != nil {
+		return nil, err
+	}
+	dsSlot, err := d.rowSlot(schema, tableSlot, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	d.kv.err = nil
+	row := lib.NewDatastoreStruct(dsSlot, schema.Sizes())
+	fields, err := d.decodeFields(row, schema.Values, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if d.kv.err != nil {
+		return nil, d.kv.err
+	}
+
+	return &Row{Table: schema.Name, Slot: dsSlot.Slot(), Fields: fields}, nil
+}
+
+// decodeFields appends the decoded fields to fields. Struct fields are
+// flattened, with their names prefixed by the name of the struct field.
+func (d *Decoder) decodeFields(row *lib.DatastoreStruct, values []datamod.FieldSchema, prefix string, fields []Field) ([]Field, error) {
+	for _, value := range values {
+		field := Field{Name: prefix + value.Name, Type: value.Type.Name}
+		switch value.Type.Type {
+		case datamod.StructType:
+			var err error
+			fields, err = d.decodeFields(row, value.Fields, field.Name+".", fields)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		case datamod.TableType:
+			field.Type = "table " + value.Type.Name
+			field.Value = lib.NestedTableSlot(row.GetField_slot(value.Index)).Slot().Hex()
+		case datamod.ArrayType:
+			elems, err := d.arrayElems(row.GetField_slot(value.Index), value.Type)
+			if err != nil {
+				return nil, fmt.Errorf("unsupported type '%s' for field '%s': %w", value.Type.Name, value.Name, err)
+			}
+			field.Value = "[" + strings.Join(elems, ", ") + "]"
+		default:
+			format, ok := formatterFor(&value.Type)
+			if !ok {
+				return nil, fmt.Errorf("unsupported type '%s' for field '%s'", value.Type.Name, value.Name)
+			}
+			if value.Type.Type == datamod.BytesType {
+				field.Value = format(value.Type.Size, row.GetField_bytes(value.Index))
+			} else {
+				field.Value = format(value.Type.Size, row.GetField(value.Index))
+			}
+		}
+		if value.Type.Enum != nil {
+			field.Type = "enum " + value.Type.Name
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func rawCodec(elemType *datamod.FieldType) codec.Codec[[]byte] {
+	identity := func(_ int, data []byte) []byte { return data }
+	return codec.NewCodec(elemType.Size, elemType.Type == datamod.BytesType, identity, identity)
+}
+
+// arrayElems formats the elements of an array field, read through the same
+// lib containers used by generated code.
+func (d *Decoder) arrayElems(dsSlot lib.DatastoreSlot, fieldType datamod.FieldType) ([]string, error) {
+	elemType := fieldType.Elem
+	format, ok := formatterFor(elemType)
+	if !ok {
+		return nil, fmt.Errorf("unsupported element type '%s'", elemType.Name)
+	}
+	var data [][]byte
+	if fieldType.Length > 0 {
+		data = lib.NewFixedArray(dsSlot, fieldType.Length, rawCodec(elemType)).Values()
+	} else {
+		data = lib.NewArray(dsSlot, rawCodec(elemType)).Values()
+	}
+	elems := make([]string, len(data))
+	for ii, elem := range data {
+		elems[ii] = format(elemType.Size, elem)
+	}
+	return elems, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/testdata"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+)
+
+type memKV map[common.Hash]common.Hash
+
+func (kv memKV) Get(key common.Hash) common.Hash {
+	return kv[key]
+}
+
+func (kv memKV) Set(key common.Hash, value common.Hash) {
+	kv[key] = value
+}
+
+// newTestDump commits the storage of an account to a state database and
+// dumps it, like `geth dump`.
+func newTestDump(t *testing.T, kv memKV, address common.Address, preimages bool) *state.Dump {
+	db := state.NewDatabaseWithConfig(rawdb.NewMemoryDatabase(), &trie.Config{Preimages: preimages})
+	statedb, err := state.New(types.EmptyRootHash, db, nil)
+	require.NoError(t, err)
+	statedb.SetNonce(address, 1)
+	for key, value := range kv {
+		statedb.SetState(address, key, value)
+	}
+	root, err := statedb.Commit(false)
+	require.NoError(t, err)
+	statedb, err = state.New(root, db, nil)
+	require.NoError(t, err)
+	dump := statedb.RawDump(nil)
+	return &dump
+}
+
+func newTestDecoder(t *testing.T, kv memKV, address common.Address) *Decoder {
+	jsonContent, err := os.ReadFile("../testdata/good-datamod.json")
+	require.NoError(t, err)
+	schemas, err := datamod.ParseTableSchemas(jsonContent)
+	require.NoError(t, err)
+	return NewDecoder(schemas, NewDumpSource(newTestDump(t, kv, address, true)), address)
+}
+
+func TestDumpSourceMissingPreimages(t *testing.T) {
+	var (
+		r       = require.New(t)
+		address = common.HexToAddress("0xcc")
+		kv      = memKV{{0x01}: {0x02}, {0x03}: {0x04}}
+	)
+	source := NewDumpSource(newTestDump(t, kv, address, true))
+	value, err := source.GetState(address, common.Hash{0x01})
+	r.NoError(err)
+	r.Equal(common.Hash{0x02}, value)
+
+	source = NewDumpSource(newTestDump(t, kv, address, false))
+	_, err = source.GetState(address, common.Hash{0x01})
+	r.ErrorIs(err, ErrMissingPreimages)
+
+	// Storage dumped under hashed slots
+	dump := newTestDump(t, kv, address, true)
+	account := dump.Accounts[address]
+	account.Storage = map[common.Hash]string{{}: account.Storage[common.Hash{0x03}]}
+	dump.Accounts[address] = account
+	_, err = NewDumpSource(dump).GetState(address, common.Hash{0x01})
+	r.ErrorIs(err, ErrMissingPreimages)
+}
+
+func TestDecodeRows(t *testing.T) {
+	var (
+		r       = require.New(t)
+		kv      = make(memKV)
+		ds      = lib.NewKVDatastore(kv)
+		address = common.HexToAddress("0xc0ffee")
+		addr    = common.HexToAddress("0x01")
+	)
+
+	keyless := testdata.NewKeylessTable(ds)
+	keyless.Set(big.NewInt(1), big.NewInt(-2), "three", []byte{0x04}, true, addr, []byte{0x07})
+
+	keyed := testdata.NewKeyedTable(ds)
+	keyedRow := keyed.Get(big.NewInt(1), big.NewInt(-1), "key", []byte{0xff}, true, addr, []byte{0xaa})
+	keyedRow.Set(big.NewInt(10), big.NewInt(-20), "value", []byte{0x01, 0x02}, false, addr, []byte{0xbb})
+
+	nested := testdata.NewKeylessWithKeyedTableValue(ds)
+	nested.GetValueTable().Get(big.NewInt(2), big.NewInt(0), "", nil, false, common.Address{}, nil).SetValueUint(big.NewInt(42))
+
+	arrays := testdata.NewArrayTable(ds).Get(big.NewInt(1))
+	arrays.SetValueUints([]*big.Int{big.NewInt(1), big.NewInt(2)})
+	arrays.SetValueHashes([]common.Hash{{0x01}, {}, {}, {0x04}})
+
+	shapes := testdata.NewShapeTable(ds)
+	shapes.Get(7, testdata.ColorBlue).Set(testdata.Shape{
+		Color:   testdata.ColorGreen,
+		Origin:  testdata.Point{X: -1, Y: 2},
+		Name:    "square",
+		Palette: []testdata.Color{testdata.ColorRed},
+	}, true, testdata.Point{X: 3, Y: 4})
+
+	decoder := newTestDecoder(t, kv, address)
+
+	row, err := decoder.Row("keylessTable", nil)
+	r.NoError(err)
+	r.Equal(keyless.GetField_slot(0).Slot(), row.Slot)
+	r.Equal([]Field{
+		{"valueUint", "uint", "1"},
+		{"valueInt", "int", "-2"},
+		{"valueString", "string", `"three"`},
+		{"valueBytes", "bytes", "0x04"},
+		{"valueBool", "bool", "true"},
+		{"valueAddress", "address", addr.Hex()},
+		{"valueBytes16", "bytes16", "0x07000000000000000000000000000000"},
+	}, row.Fields)
+
+	keys := []string{"1", "-1", "key", "0xff", "true", addr.Hex(), "0xaa"}
+	row, err = decoder.Row("KeyedTable", keys)
+	r.NoError(err)
+	r.Equal(keyedRow.GetField_slot(0).Slot(), row.Slot)
+	r.Equal("10", row.Fields[0].Value)
+	r.Equal("-20", row.Fields[1].Value)
+	r.Equal(`"value"`, row.Fields[2].Value)
+	r.Equal("false", row.Fields[4].Value)
+
+	row, err = decoder.Row("keylessWithKeyedTableValue", nil)
+	r.NoError(err)
+	r.Equal("table keyedTable", row.Fields[0].Type)
+	tableSlot := common.HexToHash(row.Fields[0].Value)
+	row, err = decoder.RowAt("keyedTable", tableSlot, []string{"2", "0", "", "0x", "false", common.Address{}.Hex(), "0x"})
+	r.NoError(err)
+	r.Equal("42", row.Fields[0].Value)
+
+	row, err = decoder.Row("arrayTable", []string{"1"})
+	r.NoError(err)
+	r.Equal("[1, 2]", row.Fields[0].Value)
+	r.Equal("[]", row.Fields[1].Value)
+	r.Equal("["+common.Hash{0x01}.Hex()+", "+common.Hash{}.Hex()+", "+common.Hash{}.Hex()+", "+common.Hash{0x04}.Hex()+"]", row.Fields[2].Value)
+	r.Equal("[0, 0, 0]", row.Fields[4].Value)
+
+	row, err = decoder.Row("shapeTable", []string{"7", "blue"})
+	r.NoError(err)
+	r.Equal([]Field{
+		{"shape.color", "enum color", "Green"},
+		{"shape.origin.x", "int32", "-1"},
+		{"shape.origin.y", "int32", "2"},
+		{"shape.name", "string", `"square"`},
+		{"shape.palette", "enum color[]", "[Red]"},
+		{"visible", "bool", "true"},
+		{"center.x", "int32", "3"},
+		{"center.y", "int32", "4"},
+	}, row.Fields)
+	row2, err := decoder.Row("shapeTable", []string{"7", "2"})
+	r.NoError(err)
+	r.Equal(row.Slot, row2.Slot)
+
+	_, err = decoder.Row("keyedTable", keys[:1])
+	r.Error(err)
+	_, err = decoder.Row("missingTable", nil)
+	r.Error(err)
+	_, err = decoder.Row("keyedTable", []string{"x", "-1", "key", "0xff", "true", addr.Hex(), "0xaa"})
+	r.Error(err)
+}
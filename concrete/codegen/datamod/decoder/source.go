@@ -0,0 +1,237 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var ErrMissingPreimages = errors.New("state dump is missing preimages or incomplete")
+
+// StorageSource provides read access to the storage of an account.
+type StorageSource interface {
+	GetState(address common.Address, slot common.Hash) (common.Hash, error)
+}
+
+// RPCSource reads storage through eth_getStorageAt.
+type RPCSource struct {
+	client      *ethclient.Client
+	blockNumber *big.Int
+}
+
+// NewRPCSource returns a source reading at the given block, or at the latest
+// block if blockNumber is nil.
+func NewRPCSource(client *ethclient.Client, blockNumber *big.Int) *RPCSource {
+	return &RPCSource{client: client, blockNumber: blockNumber}
+}
+
+func (s *RPCSource) GetState(address common.Address, slot common.Hash) (common.Hash, error) {
+	value, err := s.client.StorageAt(context.Background(), address, slot, s.blockNumber)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// DumpSource reads storage from a state dump, as produced by `geth dump`.
+// Without preimages, geth leaves out accounts and dumps storage under the zero
+// slot instead of the actual slots, which would read as empty. The dump is
+// checked against its state root, and the storage of accounts against their
+// storage roots, so dumps must be complete and produced by nodes recording
+// preimages, e.g. with --cache.preimages.
+type DumpSource struct {
+	dump       *state.Dump
+	dumpErr    error
+	checked    bool
+	storageErr map[common.Address]error
+}
+
+func NewDumpSource(dump *state.Dump) *DumpSource {
+	return &DumpSource{dump: dump, storageErr: make(map[common.Address]error)}
+}
+
+// LoadDumpSource reads a state dump from a JSON file.
+func LoadDumpSource(path string) (*DumpSource, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var dump state.Dump
+	if err := json.Unmarshal(content, &dump); err != nil {
+		return nil, err
+	}
+	return NewDumpSource(&dump), nil
+}
+
+func (s *DumpSource) GetState(address common.Address, slot common.Hash) (common.Hash, error) {
+	if !s.checked {
+		s.dumpErr = verifyDumpAccounts(s.dump)
+		s.checked = true
+	}
+	if s.dumpErr != nil {
+		return common.Hash{}, s.dumpErr
+	}
+	account, ok := s.dump.Accounts[address]
+	if !ok {
+		return common.Hash{}, nil
+	}
+	err, ok := s.storageErr[address]
+	if !ok {
+		if err = verifyDumpStorage(account); err != nil {
+			err = fmt.Errorf("account %x: %w", address, err)
+		}
+		s.storageErr[address] = err
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	value, ok := account.Storage[slot]
+	if !ok {
+		return common.Hash{}, nil
+	}
+	return common.HexToHash(value), nil
+}
+
+// verifyDumpAccounts returns an error if the accounts of a dump do not hash
+// to its state root.
+func verifyDumpAccounts(dump *state.Dump) error {
+	entries := make([]trieEntry, 0, len(dump.Accounts))
+	for address, account := range dump.Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 10)
+		if !ok {
+			return fmt.Errorf("account %x: invalid balance %q", address, account.Balance)
+		}
+		encoded, err := rlp.EncodeToBytes(&types.StateAccount{
+			Nonce:    account.Nonce,
+			Balance:  balance,
+			Root:     common.BytesToHash(account.Root),
+			CodeHash: account.CodeHash,
+		})
+		if err != nil {
+			return err
+		}
+		entries = append(entries, trieEntry{crypto.Keccak256Hash(address.Bytes()), encoded})
+	}
+	if trieRoot(entries) != common.HexToHash(dump.Root) {
+		return fmt.Errorf("%w: accounts do not match the state root", ErrMissingPreimages)
+	}
+	return nil
+}
+
+// verifyDumpStorage returns an error if the storage of a dumped account does
+// not hash to its storage root.
+func verifyDumpStorage(account state.DumpAccount) error {
+	entries := make([]trieEntry, 0, len(account.Storage))
+	for slot, value := range account.Storage {
+		encoded, err := rlp.EncodeToBytes(common.TrimLeftZeroes(common.FromHex(value)))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, trieEntry{crypto.Keccak256Hash(slot.Bytes()), encoded})
+	}
+	if trieRoot(entries) != common.BytesToHash(account.Root) {
+		return fmt.Errorf("%w: storage does not match the storage root", ErrMissingPreimages)
+	}
+	return nil
+}
+
+type trieEntry struct {
+	key   common.Hash
+	value []byte
+}
+
+// trieRoot returns the root of the trie holding the given entries.
+func trieRoot(entries []trieEntry) common.Hash {
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key[:], entries[j].key[:]) < 0
+	})
+	tr := trie.NewStackTrie(nil)
+	for _, entry := range entries {
+		tr.Update(entry.key[:], entry.value)
+	}
+	return tr.Hash()
+}
+
+// StateSource reads storage from a state database.
+type StateSource struct {
+	statedb *state.StateDB
+	db      ethdb.Database
+}
+
+func NewStateSource(statedb *state.StateDB) *StateSource {
+	return &StateSource{statedb: statedb}
+}
+
+// OpenChaindata opens the chaindata directory of a node read-only and reads
+// from the state at the head block. The node must not be running.
+func OpenChaindata(dir string) (*StateSource, error) {
+	db, err := rawdb.Open(rawdb.OpenOptions{
+		Directory:         dir,
+		AncientsDirectory: filepath.Join(dir, "ancient"),
+		ReadOnly:          true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	head := rawdb.ReadHeadBlock(db)
+	if head == nil {
+		db.Close()
+		return nil, fmt.Errorf("no head block in %s", dir)
+	}
+	statedb, err := state.New(head.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &StateSource{statedb: statedb, db: db}, nil
+}
+
+func (s *StateSource) GetState(address common.Address, slot common.Hash) (common.Hash, error) {
+	value := s.statedb.GetState(address, slot)
+	return value, s.statedb.Error()
+}
+
+// Close releases the database if the source was opened with OpenChaindata.
+func (s *StateSource) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+var (
+	_ StorageSource = (*RPCSource)(nil)
+	_ StorageSource = (*DumpSource)(nil)
+	_ StorageSource = (*StateSource)(nil)
+)
@@ -0,0 +1,242 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package decoder
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
+)
+
+// Keys are parsed from and values formatted to strings using the codec
+// functions named in the field type, so encoding matches generated code.
+
+type keyParser func(size int, str string) ([]byte, error)
+
+type valueFormatter func(size int, data []byte) string
+
+func parseBig(str string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(str, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer '%s'", str)
+	}
+	return value, nil
+}
+
+func parseSmallUint(bits int, str string) (uint64, error) {
+	return strconv.ParseUint(str, 0, bits)
+}
+
+func parseSmallInt(bits int, str string) (int64, error) {
+	return strconv.ParseInt(str, 0, bits)
+}
+
+var keyParsers = map[string]keyParser{
+	"EncodeAddress": func(size int, str string) ([]byte, error) {
+		if !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address '%s'", str)
+		}
+		return codec.EncodeAddress(size, common.HexToAddress(str)), nil
+	},
+	"EncodeBool": func(size int, str string) ([]byte, error) {
+		value, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, err
+		}
+		return codec.EncodeBool(size, value), nil
+	},
+	"EncodeHash": func(size int, str string) ([]byte, error) {
+		value, err := hexutil.Decode(str)
+		if err != nil {
+			return nil, err
+		}
+		if len(value) > 32 {
+			return nil, fmt.Errorf("value '%s' is longer than 32 bytes", str)
+		}
+		return codec.EncodeHash(size, common.BytesToHash(value)), nil
+	},
+	"EncodeFixedBytes": func(size int, str string) ([]byte, error) {
+		value, err := hexutil.Decode(str)
+		if err != nil {
+			return nil, err
+		}
+		if len(value) > size {
+			return nil, fmt.Errorf("value '%s' is longer than %d bytes", str, size)
+		}
+		return codec.EncodeFixedBytes(size, value), nil
+	},
+	"EncodeBytes": func(size int, str string) ([]byte, error) {
+		value, err := hexutil.Decode(str)
+		if err != nil {
+			return nil, err
+		}
+		return codec.EncodeBytes(size, value), nil
+	},
+	"EncodeString": func(size int, str string) ([]byte, error) {
+		return codec.EncodeString(size, str), nil
+	},
+	"EncodeUint256": func(size int, str string) ([]byte, error) {
+		value, err := parseBig(str)
+		if err != nil {
+			return nil, err
+		}
+		if value.Sign() < 0 || value.Cmp(codec.MaxUint256) > 0 {
+			return nil, fmt.Errorf("value '%s' out of range", str)
+		}
+		return codec.EncodeUint256(size, value), nil
+	},
+	"EncodeInt256": func(size int, str string) ([]byte, error) {
+		value, err := parseBig(str)
+		if err != nil {
+			return nil, err
+		}
+		if value.BitLen() > 255 {
+			return nil, fmt.Errorf("value '%s' out of range", str)
+		}
+		return codec.EncodeInt256(size, value), nil
+	},
+	"EncodeSmallUint8": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallUint(8, str)
+		return codec.EncodeSmallUint8(size, uint8(value)), err
+	},
+	"EncodeSmallUint16": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallUint(16, str)
+		return codec.EncodeSmallUint16(size, uint16(value)), err
+	},
+	"EncodeSmallUint32": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallUint(32, str)
+		return codec.EncodeSmallUint32(size, uint32(value)), err
+	},
+	"EncodeSmallUint64": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallUint(64, str)
+		return codec.EncodeSmallUint64(size, value), err
+	},
+	"EncodeSmallInt8": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallInt(8, str)
+		return codec.EncodeSmallInt8(size, int8(value)), err
+	},
+	"EncodeSmallInt16": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallInt(16, str)
+		return codec.EncodeSmallInt16(size, int16(value)), err
+	},
+	"EncodeSmallInt32": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallInt(32, str)
+		return codec.EncodeSmallInt32(size, int32(value)), err
+	},
+	"EncodeSmallInt64": func(size int, str string) ([]byte, error) {
+		value, err := parseSmallInt(64, str)
+		return codec.EncodeSmallInt64(size, value), err
+	},
+}
+
+var valueFormatters = map[string]valueFormatter{
+	"DecodeAddress": func(size int, data []byte) string {
+		return codec.DecodeAddress(size, data).Hex()
+	},
+	"DecodeBool": func(size int, data []byte) string {
+		return strconv.FormatBool(codec.DecodeBool(size, data))
+	},
+	"DecodeHash": func(size int, data []byte) string {
+		return codec.DecodeHash(size, data).Hex()
+	},
+	"DecodeFixedBytes": func(size int, data []byte) string {
+		return hexutil.Encode(codec.DecodeFixedBytes(size, data))
+	},
+	"DecodeBytes": func(size int, data []byte) string {
+		return hexutil.Encode(codec.DecodeBytes(size, data))
+	},
+	"DecodeString": func(size int, data []byte) string {
+		return strconv.Quote(codec.DecodeString(size, data))
+	},
+	"DecodeUint256": func(size int, data []byte) string {
+		return codec.DecodeUint256(size, data).String()
+	},
+	"DecodeInt256": func(size int, data []byte) string {
+		return codec.DecodeInt256(size, data).String()
+	},
+	"DecodeSmallUint8": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallUint8(size, data))
+	},
+	"DecodeSmallUint16": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallUint16(size, data))
+	},
+	"DecodeSmallUint32": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallUint32(size, data))
+	},
+	"DecodeSmallUint64": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallUint64(size, data))
+	},
+	"DecodeSmallInt8": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallInt8(size, data))
+	},
+	"DecodeSmallInt16": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallInt16(size, data))
+	},
+	"DecodeSmallInt32": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallInt32(size, data))
+	},
+	"DecodeSmallInt64": func(size int, data []byte) string {
+		return fmt.Sprint(codec.DecodeSmallInt64(size, data))
+	},
+}
+
+func parserFor(fieldType *datamod.FieldType) (keyParser, bool) {
+	if fieldType.Enum != nil {
+		return enumParser(fieldType.Enum), true
+	}
+	parse, ok := keyParsers[fieldType.EncodeFunc]
+	return parse, ok
+}
+
+func formatterFor(fieldType *datamod.FieldType) (valueFormatter, bool) {
+	if fieldType.Enum != nil {
+		return enumFormatter(fieldType.Enum), true
+	}
+	format, ok := valueFormatters[fieldType.DecodeFunc]
+	return format, ok
+}
+
+// Enum keys are given by value name or index.
+func enumParser(enum *datamod.EnumSchema) keyParser {
+	return func(size int, str string) ([]byte, error) {
+		for ii, value := range enum.Values {
+			if strings.EqualFold(value, str) {
+				return codec.EncodeSmallUint8(size, uint8(ii)), nil
+			}
+		}
+		index, err := parseSmallUint(8, str)
+		if err != nil || index >= uint64(len(enum.Values)) {
+			return nil, fmt.Errorf("invalid %s value '%s'", enum.Name, str)
+		}
+		return codec.EncodeSmallUint8(size, uint8(index)), nil
+	}
+}
+
+func enumFormatter(enum *datamod.EnumSchema) valueFormatter {
+	return func(size int, data []byte) string {
+		index := codec.DecodeSmallUint8(size, data)
+		if int(index) < len(enum.Values) {
+			return enum.Values[index]
+		}
+		return fmt.Sprintf("%s(%d)", enum.Name, index)
+	}
+}
@@ -17,6 +17,7 @@ package datamod
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -25,6 +26,8 @@ const (
 	ValueType = iota
 	BytesType
 	TableType
+	ArrayType
+	StructType
 )
 
 type FieldType struct {
@@ -34,9 +37,46 @@ type FieldType struct {
 	GoType     string
 	EncodeFunc string
 	DecodeFunc string
+	// Codec is the name of the matching codec.Codec value, used for array
+	// elements.
+	Codec string
+	// Elem and Length are set for array types. Length is zero for dynamic
+	// arrays.
+	Elem   *FieldType
+	Length int
+	// Enum is set for enum types, which are encoded as a uint8 by functions
+	// generated alongside the enum.
+	Enum *EnumSchema
 }
 
+func (f FieldType) EncodeFuncRef() string {
+	if f.Enum != nil {
+		return f.EncodeFunc
+	}
+	return "codec." + f.EncodeFunc
+}
+
+func (f FieldType) DecodeFuncRef() string {
+	if f.Enum != nil {
+		return f.DecodeFunc
+	}
+	return "codec." + f.DecodeFunc
+}
+
+func (f FieldType) CodecRef() string {
+	if f.Enum != nil {
+		return f.Codec
+	}
+	return "codec." + f.Codec
+}
+
+var arrayTypeRegexp = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+
 func nameToFieldType(name string) (FieldType, error) {
+	if matches := arrayTypeRegexp.FindStringSubmatch(name); matches != nil {
+		return arrayFieldType(name, matches[1], matches[2])
+	}
+
 	switch name {
 	case "address":
 		return FieldType{
@@ -45,6 +85,7 @@ func nameToFieldType(name string) (FieldType, error) {
 			GoType:     "common.Address",
 			EncodeFunc: "EncodeAddress",
 			DecodeFunc: "DecodeAddress",
+			Codec:      "Address",
 		}, nil
 	case "bool":
 		return FieldType{
@@ -53,6 +94,7 @@ func nameToFieldType(name string) (FieldType, error) {
 			GoType:     "bool",
 			EncodeFunc: "EncodeBool",
 			DecodeFunc: "DecodeBool",
+			Codec:      "Bool",
 		}, nil
 	case "uint":
 		break
@@ -65,6 +107,7 @@ func nameToFieldType(name string) (FieldType, error) {
 			GoType:     "[]byte",
 			EncodeFunc: "EncodeBytes",
 			DecodeFunc: "DecodeBytes",
+			Codec:      "Bytes",
 			Type:       BytesType,
 		}, nil
 	case "string":
@@ -74,6 +117,7 @@ func nameToFieldType(name string) (FieldType, error) {
 			GoType:     "string",
 			EncodeFunc: "EncodeString",
 			DecodeFunc: "DecodeString",
+			Codec:      "String",
 			Type:       BytesType,
 		}, nil
 	default:
@@ -98,11 +142,13 @@ func nameToFieldType(name string) (FieldType, error) {
 			GoType:     "[]byte",
 			EncodeFunc: "EncodeFixedBytes",
 			DecodeFunc: "DecodeFixedBytes",
+			Codec:      fmt.Sprintf("FixedBytes(%d)", size),
 		}
 		if size == 32 {
 			fieldType.GoType = "common.Hash"
 			fieldType.EncodeFunc = "EncodeHash"
 			fieldType.DecodeFunc = "DecodeHash"
+			fieldType.Codec = "Hash"
 		}
 		return fieldType, nil
 	}
@@ -138,17 +184,21 @@ func nameToFieldType(name string) (FieldType, error) {
 		var (
 			goType     string
 			codecSufix string
+			codecName  string
 		)
 		if size <= 64 {
 			goType = noSizeTypeStr + fmt.Sprint(size)
 			codecSufix = fmt.Sprintf("Small%s%d", upperFirstLetter(noSizeTypeStr), size)
+			codecName = fmt.Sprintf("%s%d", upperFirstLetter(noSizeTypeStr), size)
 		} else {
 			goType = "*big.Int"
 			codecSufix = fmt.Sprintf("%s256", upperFirstLetter(noSizeTypeStr))
+			codecName = codecSufix
 		}
 		fieldType.GoType = goType
 		fieldType.EncodeFunc = "Encode" + codecSufix
 		fieldType.DecodeFunc = "Decode" + codecSufix
+		fieldType.Codec = codecName
 		return fieldType, nil
 	}
 
@@ -165,5 +215,67 @@ func nameToFieldType(name string) (FieldType, error) {
 		}, nil
 	}
 
+	// Enums and structs are resolved against their definitions once the
+	// whole schema has been read.
+	if strings.HasPrefix(name, "enum ") {
+		enumName := strings.TrimPrefix(name, "enum ")
+		if !isValidName(enumName) {
+			return FieldType{}, fmt.Errorf("invalid enum name %s", enumName)
+		}
+		goType := upperFirstLetter(enumName)
+		return FieldType{
+			Name:       enumName,
+			Size:       1,
+			GoType:     goType,
+			EncodeFunc: "Encode" + goType,
+			DecodeFunc: "Decode" + goType,
+			Codec:      goType + "Codec",
+			Enum:       &EnumSchema{Name: goType},
+		}, nil
+	}
+
+	if strings.HasPrefix(name, "struct ") {
+		structName := strings.TrimPrefix(name, "struct ")
+		if !isValidName(structName) {
+			return FieldType{}, fmt.Errorf("invalid struct name %s", structName)
+		}
+		return FieldType{
+			Name:   structName,
+			Type:   StructType,
+			GoType: upperFirstLetter(structName),
+		}, nil
+	}
+
 	return FieldType{}, fmt.Errorf("unknown field type %s", name)
 }
+
+// Arrays take a full slot in the row. Elements of dynamic arrays are stored
+// as a lib.DynamicArray and elements of fixed arrays as a lib.SlotArray, one
+// slot per element.
+func arrayFieldType(name string, elemName string, lengthStr string) (FieldType, error) {
+	elemType, err := nameToFieldType(elemName)
+	if err != nil {
+		return FieldType{}, err
+	}
+	if elemType.Type != ValueType && elemType.Type != BytesType {
+		return FieldType{}, fmt.Errorf("invalid array element type %s", elemName)
+	}
+	length := 0
+	if lengthStr != "" {
+		length, err = strconv.Atoi(lengthStr)
+		if err != nil {
+			return FieldType{}, err
+		}
+		if length < 1 {
+			return FieldType{}, fmt.Errorf("invalid array length %d", length)
+		}
+	}
+	return FieldType{
+		Name:   name,
+		Type:   ArrayType,
+		Size:   32,
+		GoType: "[]" + elemType.GoType,
+		Elem:   &elemType,
+		Length: length,
+	}, nil
+}
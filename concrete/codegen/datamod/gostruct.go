@@ -0,0 +1,483 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package datamod
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+// Schemas can also be derived from the structs of a Go package. A struct is a
+// table if its doc comment has a //datamod:table directive, optionally
+// followed by the enumerable and events options, or if any of its fields has
+// a datamod tag. Tags hold comma separated options:
+//
+//	key            the field is a key of the table
+//	index:<name>   the field is part of the index with the given name
+//	-              the field is ignored
+//	<type>         the datamod type of the field, e.g. uint32 or table item
+//
+// Field types are inferred from their Go type if not given. Named struct
+// types become datamod structs, and named uint8 types with constants become
+// enums, whose values are the names of the constants without the type name.
+
+const tableDirective = "//datamod:table"
+
+type goPackage struct {
+	types  map[string]*ast.TypeSpec
+	docs   map[string]*ast.CommentGroup
+	order  []string
+	consts map[string][]string
+
+	tables *orderedmap.OrderedMap
+	// defs holds the enum and struct definitions by Go type name.
+	defs map[string]orderedmap.OrderedMap
+}
+
+// ReadSchema returns the JSON schema at path, or derives it from the Go
+// package at path if it is a directory.
+func ReadSchema(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return SchemaFromGoPackage(path)
+	}
+	return os.ReadFile(path)
+}
+
+// SchemaFromGoPackage derives the JSON schema of the annotated structs of the
+// Go package in dir.
+func SchemaFromGoPackage(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	filter := func(info os.FileInfo) bool { return !strings.HasSuffix(info.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected a single Go package in %s, found %d", dir, len(pkgs))
+	}
+	var files []*ast.File
+	for _, pkg := range pkgs {
+		var names []string
+		for name := range pkg.Files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			files = append(files, pkg.Files[name])
+		}
+	}
+
+	p := &goPackage{
+		types:  make(map[string]*ast.TypeSpec),
+		docs:   make(map[string]*ast.CommentGroup),
+		consts: make(map[string][]string),
+		tables: orderedmap.New(),
+		defs:   make(map[string]orderedmap.OrderedMap),
+	}
+	for _, file := range files {
+		p.collect(file)
+	}
+	for _, name := range p.order {
+		structType, ok := p.types[name].Type.(*ast.StructType)
+		if !ok {
+			continue
+		}
+		options, isTable := p.tableOptions(name, structType)
+		if !isTable {
+			continue
+		}
+		table, err := p.table(name, structType, options)
+		if err != nil {
+			return nil, err
+		}
+		p.tables.Set(schemaName(name), *table)
+	}
+	if len(p.tables.Keys()) == 0 {
+		return nil, fmt.Errorf("no datamod tables found in %s", dir)
+	}
+
+	// Definitions are written before the tables, in declaration order.
+	schema := orderedmap.New()
+	for _, name := range p.order {
+		if def, ok := p.defs[name]; ok {
+			schema.Set(schemaName(name), def)
+		}
+	}
+	for _, name := range p.tables.Keys() {
+		table, _ := p.tables.Get(name)
+		schema.Set(name, table)
+	}
+	content, err := json.MarshalIndent(schema, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := unmarshalDataModel(content); err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+func (p *goPackage) collect(file *ast.File) {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		switch genDecl.Tok {
+		case token.TYPE:
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				name := typeSpec.Name.Name
+				p.types[name] = typeSpec
+				p.order = append(p.order, name)
+				if typeSpec.Doc != nil {
+					p.docs[name] = typeSpec.Doc
+				} else if len(genDecl.Specs) == 1 {
+					p.docs[name] = genDecl.Doc
+				}
+			}
+		case token.CONST:
+			// Constants without a type or value repeat the previous type,
+			// as in iota blocks.
+			var typeName string
+			for _, spec := range genDecl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if ident, ok := valueSpec.Type.(*ast.Ident); ok {
+					typeName = ident.Name
+				} else if valueSpec.Type != nil || len(valueSpec.Values) > 0 {
+					typeName = ""
+				}
+				if typeName == "" {
+					continue
+				}
+				for _, name := range valueSpec.Names {
+					p.consts[typeName] = append(p.consts[typeName], name.Name)
+				}
+			}
+		}
+	}
+}
+
+func (p *goPackage) tableOptions(name string, structType *ast.StructType) ([]string, bool) {
+	if doc := p.docs[name]; doc != nil {
+		for _, comment := range doc.List {
+			if comment.Text == tableDirective || strings.HasPrefix(comment.Text, tableDirective+" ") {
+				return strings.Fields(strings.TrimPrefix(comment.Text, tableDirective)), true
+			}
+		}
+	}
+	for _, field := range structType.Fields.List {
+		if _, ok := fieldTag(field); ok {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// schemaName converts a Go identifier to a schema name, lowering leading
+// initialisms, e.g. ID to id and URLPath to urlPath.
+func schemaName(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		upper--
+	}
+	for ii := 0; ii < upper; ii++ {
+		runes[ii] = unicode.ToLower(runes[ii])
+	}
+	return string(runes)
+}
+
+func fieldTag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(tag).Lookup("datamod")
+}
+
+type goField struct {
+	name    string
+	typeStr string
+	key     bool
+	indexes []string
+}
+
+func (p *goPackage) fields(structName string, structType *ast.StructType) ([]goField, error) {
+	var fields []goField
+	for _, field := range structType.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, fmt.Errorf("embedded fields are not supported in struct '%s'", structName)
+		}
+		tag, _ := fieldTag(field)
+		if tag == "-" {
+			continue
+		}
+		var f goField
+		for _, option := range strings.Split(tag, ",") {
+			option = strings.TrimSpace(option)
+			switch {
+			case option == "":
+			case option == "key":
+				f.key = true
+			case strings.HasPrefix(option, "index:"):
+				f.indexes = append(f.indexes, strings.TrimPrefix(option, "index:"))
+			default:
+				f.typeStr = option
+			}
+		}
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			f.name = schemaName(name.Name)
+			if f.typeStr == "" {
+				typeStr, err := p.fieldType(field.Type)
+				if err != nil {
+					return nil, fmt.Errorf("field '%s' of struct '%s': %w", name.Name, structName, err)
+				}
+				f.typeStr = typeStr
+			} else if err := p.defineTypes(f.typeStr); err != nil {
+				return nil, err
+			}
+			fields = append(fields, f)
+			f.typeStr = ""
+		}
+	}
+	return fields, nil
+}
+
+func (p *goPackage) table(name string, structType *ast.StructType, options []string) (*orderedmap.OrderedMap, error) {
+	fields, err := p.fields(name, structType)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		table      = orderedmap.New()
+		keys       = orderedmap.New()
+		values     = orderedmap.New()
+		indexes    = orderedmap.New()
+		indexNames []string
+	)
+	for _, field := range fields {
+		if field.key {
+			keys.Set(field.name, field.typeStr)
+		} else {
+			values.Set(field.name, field.typeStr)
+		}
+		for _, index := range field.indexes {
+			_indexFields, ok := indexes.Get(index)
+			if !ok {
+				indexNames = append(indexNames, index)
+			}
+			indexFields, _ := _indexFields.([]string)
+			indexes.Set(index, append(indexFields, field.name))
+		}
+	}
+	if len(keys.Keys()) > 0 {
+		table.Set("keySchema", *keys)
+	}
+	table.Set("schema", *values)
+	if len(indexNames) > 0 {
+		table.Set("indexes", *indexes)
+	}
+	for _, option := range options {
+		switch option {
+		case "enumerable", "events":
+			table.Set(option, true)
+		default:
+			return nil, fmt.Errorf("invalid option '%s' for table '%s'", option, name)
+		}
+	}
+	return table, nil
+}
+
+// fieldType infers the datamod type of a Go type, defining the enums and
+// structs it refers to.
+func (p *goPackage) fieldType(expr ast.Expr) (string, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		switch expr.Name {
+		case "bool", "string", "uint8", "uint16", "uint32", "uint64", "int8", "int16", "int32", "int64":
+			return expr.Name, nil
+		case "uint", "int":
+			return expr.Name + "64", nil
+		case "byte":
+			return "uint8", nil
+		}
+		if _, ok := p.types[expr.Name]; ok {
+			return p.namedType(expr.Name)
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := expr.X.(*ast.Ident); ok {
+			switch pkg.Name + "." + expr.Sel.Name {
+			case "common.Address":
+				return "address", nil
+			case "common.Hash":
+				return "bytes32", nil
+			}
+		}
+	case *ast.StarExpr:
+		if sel, ok := expr.X.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "big" && sel.Sel.Name == "Int" {
+				return "uint", nil
+			}
+		}
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			if expr.Len == nil {
+				if ident.Name == "byte" {
+					return "bytes", nil
+				}
+			} else if length, ok := arrayLength(expr.Len); ok && length <= 32 {
+				return fmt.Sprintf("bytes%d", length), nil
+			}
+		}
+		elem, err := p.fieldType(expr.Elt)
+		if err != nil {
+			return "", err
+		}
+		if expr.Len == nil {
+			return elem + "[]", nil
+		}
+		length, ok := arrayLength(expr.Len)
+		if !ok {
+			return "", fmt.Errorf("array length must be a constant")
+		}
+		return fmt.Sprintf("%s[%d]", elem, length), nil
+	}
+	return "", fmt.Errorf("cannot infer the datamod type of %s, use a datamod tag", exprString(expr))
+}
+
+func exprString(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return exprString(expr.X) + "." + expr.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprString(expr.X)
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return "[]" + exprString(expr.Elt)
+		}
+		return "[" + exprString(expr.Len) + "]" + exprString(expr.Elt)
+	case *ast.BasicLit:
+		return expr.Value
+	}
+	return fmt.Sprintf("%T", expr)
+}
+
+func arrayLength(expr ast.Expr) (int, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return 0, false
+	}
+	length, err := strconv.Atoi(lit.Value)
+	return length, err == nil
+}
+
+func (p *goPackage) namedType(name string) (string, error) {
+	switch typ := p.types[name].Type.(type) {
+	case *ast.StructType:
+		if err := p.defineStruct(name, typ); err != nil {
+			return "", err
+		}
+		return "struct " + schemaName(name), nil
+	case *ast.Ident:
+		if typ.Name == "uint8" && len(p.consts[name]) > 0 {
+			p.defineEnum(name)
+			return "enum " + schemaName(name), nil
+		}
+		return p.fieldType(typ)
+	}
+	return "", fmt.Errorf("cannot infer the datamod type of %s, use a datamod tag", name)
+}
+
+// defineTypes defines the enums and structs referred to by an explicit type.
+func (p *goPackage) defineTypes(typeStr string) error {
+	typeStr = strings.TrimRight(typeStr, "[]0123456789")
+	for _, prefix := range []string{"enum ", "struct "} {
+		if !strings.HasPrefix(typeStr, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(typeStr, prefix)
+		for _, goName := range p.order {
+			if schemaName(goName) == name {
+				_, err := p.namedType(goName)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *goPackage) defineEnum(name string) {
+	if _, ok := p.defs[name]; ok {
+		return
+	}
+	values := make([]string, len(p.consts[name]))
+	for ii, constName := range p.consts[name] {
+		values[ii] = schemaName(strings.TrimPrefix(constName, name))
+	}
+	def := orderedmap.New()
+	def.Set("enum", values)
+	p.defs[name] = *def
+}
+
+func (p *goPackage) defineStruct(name string, structType *ast.StructType) error {
+	if _, ok := p.defs[name]; ok {
+		return nil
+	}
+	// Recursive structs are reported when the schema is unmarshaled.
+	p.defs[name] = *orderedmap.New()
+	fields, err := p.fields(name, structType)
+	if err != nil {
+		return err
+	}
+	values := orderedmap.New()
+	for _, field := range fields {
+		if field.key || len(field.indexes) > 0 {
+			return fmt.Errorf("field '%s' of struct '%s' cannot be a key or be indexed", field.name, name)
+		}
+		values.Set(field.name, field.typeStr)
+	}
+	def := orderedmap.New()
+	def.Set("struct", *values)
+	p.defs[name] = *def
+	return nil
+}
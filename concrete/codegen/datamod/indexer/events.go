@@ -0,0 +1,181 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EventsABI describes the change events emitted by datamod tables, see
+// lib.TableEvents.
+const EventsABI = `[
+	{"type": "event", "name": "Datamod_SetField", "inputs": [
+		{"name": "tableId", "type": "bytes32", "indexed": true},
+		{"name": "tableSlot", "type": "bytes32", "indexed": true},
+		{"name": "keys", "type": "bytes[]"},
+		{"name": "field", "type": "uint8"},
+		{"name": "data", "type": "bytes"}
+	]},
+	{"type": "event", "name": "Datamod_SetArray", "inputs": [
+		{"name": "tableId", "type": "bytes32", "indexed": true},
+		{"name": "tableSlot", "type": "bytes32", "indexed": true},
+		{"name": "keys", "type": "bytes[]"},
+		{"name": "field", "type": "uint8"},
+		{"name": "data", "type": "bytes[]"}
+	]},
+	{"type": "event", "name": "Datamod_DeleteRecord", "inputs": [
+		{"name": "tableId", "type": "bytes32", "indexed": true},
+		{"name": "tableSlot", "type": "bytes32", "indexed": true},
+		{"name": "keys", "type": "bytes[]"}
+	]}
+]`
+
+var eventsABI = mustParseABI(EventsABI)
+
+func mustParseABI(content string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(content))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+var (
+	errUnknownEvent = errors.New("unknown event")
+	errUnknownTable = errors.New("unknown table")
+)
+
+// Change is a decoded table event.
+type Change struct {
+	Table     string
+	TableSlot common.Hash
+	Keys      [][]byte
+	// Field is the name of the changed field, with the names of struct
+	// fields separated by dots. It is empty when the row is deleted.
+	Field string
+	// Value holds the new value of value fields and Values the elements of
+	// array fields.
+	Value   []byte
+	Values  [][]byte
+	Deleted bool
+}
+
+// KeyHash identifies the row of a change within its table instance.
+func (c *Change) KeyHash() common.Hash {
+	return KeyHash(c.Keys)
+}
+
+// KeyHash returns the hash of the encoded keys of a row.
+func KeyHash(keys [][]byte) common.Hash {
+	var encoded []byte
+	for _, key := range keys {
+		encoded = binary.BigEndian.AppendUint64(encoded, uint64(len(key)))
+		encoded = append(encoded, key...)
+	}
+	return crypto.Keccak256Hash(encoded)
+}
+
+// tableInfo holds what is needed to decode the events of a table.
+type tableInfo struct {
+	schema datamod.TableSchema
+	fields map[int]string
+}
+
+func newTableInfos(schemas []datamod.TableSchema) map[common.Hash]*tableInfo {
+	tables := make(map[common.Hash]*tableInfo, len(schemas))
+	for _, schema := range schemas {
+		fields := make(map[int]string)
+		fieldNames(schema.Values, "", fields)
+		tables[common.BytesToHash(datamod.TableDefaultKey(schema.Name))] = &tableInfo{schema: schema, fields: fields}
+	}
+	return tables
+}
+
+// fieldNames maps the index of each field stored in a row to its name.
+// Table fields are skipped, as nested tables emit their own events.
+func fieldNames(values []datamod.FieldSchema, prefix string, names map[int]string) {
+	for _, value := range values {
+		switch value.Type.Type {
+		case datamod.StructType:
+			fieldNames(value.Fields, prefix+value.Name+".", names)
+		case datamod.TableType:
+		default:
+			names[value.Index] = prefix + value.Name
+		}
+	}
+}
+
+func decodeLog(tables map[common.Hash]*tableInfo, log *types.Log) (*Change, error) {
+	if len(log.Topics) != 3 {
+		return nil, errUnknownEvent
+	}
+	var name string
+	switch log.Topics[0] {
+	case lib.SetFieldEventID:
+		name = "Datamod_SetField"
+	case lib.SetArrayEventID:
+		name = "Datamod_SetArray"
+	case lib.DeleteRecordEventID:
+		name = "Datamod_DeleteRecord"
+	default:
+		return nil, errUnknownEvent
+	}
+	table, ok := tables[log.Topics[1]]
+	if !ok {
+		return nil, errUnknownTable
+	}
+	values, err := eventsABI.Unpack(name, log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s event: %w", name, err)
+	}
+
+	change := &Change{
+		Table:     table.schema.Name,
+		TableSlot: log.Topics[2],
+		Keys:      values[0].([][]byte),
+	}
+	if len(change.Keys) != len(table.schema.Keys) {
+		return nil, fmt.Errorf("invalid %s event: table '%s' has %d keys, got %d", name, table.schema.Name, len(table.schema.Keys), len(change.Keys))
+	}
+	if name == "Datamod_DeleteRecord" {
+		change.Deleted = true
+		return change, nil
+	}
+
+	index := int(values[1].(uint8))
+	field, ok := table.fields[index]
+	if !ok {
+		return nil, fmt.Errorf("invalid %s event: table '%s' has no field %d", name, table.schema.Name, index)
+	}
+	change.Field = field
+	if name == "Datamod_SetArray" {
+		// Values is never nil for array fields, even when they are empty
+		change.Values = append([][]byte{}, values[2].([][]byte)...)
+	} else {
+		change.Value = values[2].([]byte)
+	}
+	return change, nil
+}
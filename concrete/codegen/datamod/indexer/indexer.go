@@ -0,0 +1,139 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package indexer mirrors datamod tables from the change events they emit,
+// so their contents can be queried without reading precompile storage.
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrRemovedLog is returned when a log is removed by a reorg. Stores are not
+// able to revert changes, so the mirror must be synced again from a block
+// before the reorg.
+var ErrRemovedLog = errors.New("log removed by reorg")
+
+// Indexer applies the table events emitted by a precompile to a store. Logs
+// can be fetched from any ethereum.LogFilterer, e.g. an ethclient.Client,
+// which can also be attached to an in-process node.
+type Indexer struct {
+	address common.Address
+	tables  map[common.Hash]*tableInfo
+	store   Store
+
+	// Position of the last applied log, as logs from backfilling and from
+	// a subscription can overlap
+	applied   bool
+	lastBlock uint64
+	lastIndex uint
+}
+
+func New(schemas []datamod.TableSchema, address common.Address, store Store) *Indexer {
+	return &Indexer{
+		address: address,
+		tables:  newTableInfos(schemas),
+		store:   store,
+	}
+}
+
+// Query returns the filter matching the events of the precompile, between
+// the given blocks.
+func (i *Indexer) Query(fromBlock, toBlock *big.Int) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []common.Address{i.address},
+		Topics:    [][]common.Hash{{lib.SetFieldEventID, lib.SetArrayEventID, lib.DeleteRecordEventID}},
+	}
+}
+
+// ApplyLog applies a log to the store. Logs of other contracts, events and
+// tables are ignored, as are logs already applied.
+func (i *Indexer) ApplyLog(log types.Log) error {
+	if log.Removed {
+		return ErrRemovedLog
+	}
+	if log.Address != i.address {
+		return nil
+	}
+	if i.applied && (log.BlockNumber < i.lastBlock || log.BlockNumber == i.lastBlock && log.Index <= i.lastIndex) {
+		return nil
+	}
+	change, err := decodeLog(i.tables, &log)
+	if errors.Is(err, errUnknownEvent) || errors.Is(err, errUnknownTable) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("block %d, log %d: %w", log.BlockNumber, log.Index, err)
+	}
+	if err := i.store.Apply(change); err != nil {
+		return err
+	}
+	i.applied, i.lastBlock, i.lastIndex = true, log.BlockNumber, log.Index
+	return nil
+}
+
+// Sync applies the events emitted between the given blocks. A nil toBlock
+// syncs up to the latest block.
+func (i *Indexer) Sync(ctx context.Context, filterer ethereum.LogFilterer, fromBlock, toBlock *big.Int) error {
+	logs, err := filterer.FilterLogs(ctx, i.Query(fromBlock, toBlock))
+	if err != nil {
+		return err
+	}
+	for _, log := range logs {
+		if err := i.ApplyLog(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Watch syncs the events emitted since fromBlock and keeps applying new
+// events until the context is cancelled or an error occurs.
+func (i *Indexer) Watch(ctx context.Context, filterer ethereum.LogFilterer, fromBlock *big.Int) error {
+	// Subscribe before backfilling so no events are missed in between
+	logs := make(chan types.Log)
+	sub, err := filterer.SubscribeFilterLogs(ctx, i.Query(nil, nil), logs)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	if err := i.Sync(ctx, filterer, fromBlock, nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case log := <-logs:
+			if err := i.ApplyLog(log); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
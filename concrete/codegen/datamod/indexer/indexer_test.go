@@ -0,0 +1,275 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/testdata"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+type testFilterer struct {
+	logs []types.Log
+	sub  chan types.Log
+}
+
+func (f *testFilterer) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	for _, log := range f.logs {
+		if q.FromBlock != nil && log.BlockNumber < q.FromBlock.Uint64() {
+			continue
+		}
+		if q.ToBlock != nil && log.BlockNumber > q.ToBlock.Uint64() {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs, nil
+}
+
+func (f *testFilterer) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case log := <-f.sub:
+				ch <- log
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+type testChain struct {
+	statedb *state.StateDB
+	ds      lib.Datastore
+	logs    []types.Log
+	block   uint64
+}
+
+func newTestChain(addr common.Address) *testChain {
+	statedb := mock.NewMockStateDB().(*state.StateDB)
+	env := api.NewEnvironment(addr, api.EnvConfig{}, statedb, api.NewMockBlockContext(), api.NewMockCallContext(), api.NewMockCaller(), false, 0)
+	return &testChain{statedb: statedb, ds: lib.NewDatastore(env)}
+}
+
+// commit collects the logs emitted since the last commit into a new block.
+func (c *testChain) commit() []types.Log {
+	c.block++
+	var logs []types.Log
+	for ii, log := range c.statedb.Logs()[len(c.logs):] {
+		log.BlockNumber = c.block
+		log.Index = uint(ii)
+		logs = append(logs, *log)
+	}
+	c.logs = append(c.logs, logs...)
+	return logs
+}
+
+func loadSchemas(t *testing.T) []datamod.TableSchema {
+	content, err := os.ReadFile("../testdata/good-datamod.json")
+	require.NoError(t, err)
+	schemas, err := datamod.ParseTableSchemas(content)
+	require.NoError(t, err)
+	return schemas
+}
+
+func TestIndexer(t *testing.T) {
+	var (
+		r         = require.New(t)
+		addr      = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		other     = common.HexToAddress("0x0987654321098765432109876543210987654321")
+		alice     = common.Address{0x0a}
+		bob       = common.Address{0x0b}
+		chain     = newTestChain(addr)
+		ledger    = testdata.NewLedger(chain.ds)
+		inventory = testdata.NewInventory(chain.ds)
+		store     = NewMemoryStore()
+		indexer   = New(loadSchemas(t), addr, store)
+		filterer  = &testFilterer{sub: make(chan types.Log)}
+	)
+
+	ledger.Get(alice).Set(big.NewInt(10), "first", testdata.Point{X: 1, Y: -1}, []uint64{1, 2})
+	ledger.Get(bob).SetMemo("bob")
+	inventory.Get(alice).GetItems().Get(1, "sword").Set(3, "weapon")
+	inventory.Get(bob).GetItems().Get(1, "sword").SetCount(1)
+	chain.commit()
+	ledger.Get(alice).SetBalance(big.NewInt(20))
+	ledger.Get(alice).SetHistory(nil)
+	ledger.Get(bob).Delete()
+	chain.commit()
+
+	ledgerSlot := chain.ds.Get(testdata.LedgerDefaultKey()).Slot()
+
+	// Logs of other contracts are ignored
+	filterer.logs = append(chain.logs, types.Log{Address: other, Topics: chain.logs[0].Topics, Data: chain.logs[0].Data, BlockNumber: 2, Index: 100})
+	r.NoError(indexer.Sync(context.Background(), filterer, big.NewInt(1), nil))
+
+	record := store.Record("Ledger", ledgerSlot, codec.EncodeAddress(20, alice))
+	r.NotNil(record)
+	r.Equal([][]byte{codec.EncodeAddress(20, alice)}, record.Keys)
+	r.Equal(big.NewInt(20), codec.DecodeUint256(32, record.Fields["balance"]))
+	r.Equal("first", codec.DecodeString(32, record.Fields["memo"]))
+	r.Equal(int32(1), codec.DecodeSmallInt32(4, record.Fields["position.x"]))
+	r.Equal(int32(-1), codec.DecodeSmallInt32(4, record.Fields["position.y"]))
+	r.Equal([][]byte{}, record.Arrays["history"])
+	r.Nil(store.Record("Ledger", ledgerSlot, codec.EncodeAddress(20, bob)))
+	r.Len(store.Records("Ledger"), 1)
+
+	// Nested tables are identified by their slot
+	items := store.Records("Item")
+	r.Len(items, 2)
+	r.NotEqual(items[0].TableSlot, items[1].TableSlot)
+	record = store.Record("Item", lib.NestedTableSlot(inventory.Get(alice).GetField_slot(1)).Slot(), codec.EncodeSmallUint64(8, 1), codec.EncodeString(32, "sword"))
+	r.NotNil(record)
+	r.Equal(uint32(3), codec.DecodeSmallUint32(4, record.Fields["count"]))
+	r.Equal("weapon", codec.DecodeString(32, record.Fields["label"]))
+	r.Nil(store.Records("Inventory"))
+
+	// Watching backfills and skips logs that were already applied
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- indexer.Watch(ctx, filterer, big.NewInt(2)) }()
+	ledger.Get(bob).SetMemo("back")
+	for _, log := range append(chain.logs[len(chain.logs)-1:], chain.commit()...) {
+		filterer.sub <- log
+	}
+	ledger.Get(bob).SetMemo("removed")
+	log := chain.commit()[0]
+	log.Removed = true
+	filterer.sub <- log
+	r.ErrorIs(<-done, ErrRemovedLog)
+	cancel()
+
+	record = store.Record("Ledger", ledgerSlot, codec.EncodeAddress(20, bob))
+	r.NotNil(record)
+	r.Equal("back", codec.DecodeString(32, record.Fields["memo"]))
+	r.Equal([]common.Hash{lib.SetFieldEventID, common.BytesToHash(testdata.LedgerDefaultKey()), ledgerSlot}, chain.logs[0].Topics)
+}
+
+func TestSQLStatements(t *testing.T) {
+	var (
+		r       = require.New(t)
+		schemas = loadSchemas(t)
+		store   = &SQLStore{tables: make(map[string]datamod.TableSchema)}
+		slot    = common.Hash{0x01}
+		keys    = [][]byte{{0x02}}
+	)
+	for _, schema := range schemas {
+		store.tables[schema.Name] = schema
+	}
+
+	r.Equal(
+		`CREATE TABLE IF NOT EXISTS "Ledger" ("table_slot" BLOB NOT NULL, "key_hash" BLOB NOT NULL, "key_account" BLOB, "balance" BLOB, "memo" BLOB, "position.x" BLOB, "position.y" BLOB, "history" BLOB, PRIMARY KEY ("table_slot", "key_hash"))`,
+		createTableStmt(store.tables["Ledger"]),
+	)
+
+	stmt, args, err := store.applyStmt(&Change{Table: "Ledger", TableSlot: slot, Keys: keys, Field: "position.x", Value: []byte{0x03}})
+	r.NoError(err)
+	r.Equal(`INSERT INTO "Ledger" ("table_slot", "key_hash", "key_account", "position.x") VALUES (?, ?, ?, ?) ON CONFLICT ("table_slot", "key_hash") DO UPDATE SET "position.x" = excluded."position.x"`, stmt)
+	r.Equal([]interface{}{slot.Bytes(), KeyHash(keys).Bytes(), keys[0], []byte{0x03}}, args)
+
+	_, args, err = store.applyStmt(&Change{Table: "Ledger", TableSlot: slot, Keys: keys, Field: "history", Values: [][]byte{{0x04}, {}}})
+	r.NoError(err)
+	r.Equal(`["0x04","0x"]`, args[3])
+
+	stmt, args, err = store.applyStmt(&Change{Table: "Ledger", TableSlot: slot, Keys: keys, Deleted: true})
+	r.NoError(err)
+	r.Equal(`DELETE FROM "Ledger" WHERE "table_slot" = ? AND "key_hash" = ?`, stmt)
+	r.Equal([]interface{}{slot.Bytes(), KeyHash(keys).Bytes()}, args)
+
+	_, _, err = store.applyStmt(&Change{Table: "Missing"})
+	r.ErrorContains(err, "table 'Missing' is not mirrored")
+
+	_, _, err = store.applyStmt(&Change{Table: "Ledger", TableSlot: slot, Field: "memo", Value: []byte{0x05}})
+	r.ErrorContains(err, "table 'Ledger' has 1 keys, change has 0")
+	_, _, err = store.applyStmt(&Change{Table: "Ledger", TableSlot: slot, Keys: [][]byte{{0x02}, {0x03}}, Deleted: true})
+	r.ErrorContains(err, "table 'Ledger' has 1 keys, change has 2")
+}
+
+func TestSQLStore(t *testing.T) {
+	r := require.New(t)
+	db, err := sql.Open("sqlite3", ":memory:")
+	r.NoError(err)
+	defer db.Close()
+	// Every connection to :memory: opens a separate database
+	db.SetMaxOpenConns(1)
+
+	var (
+		addr    = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		alice   = common.Address{0x0a}
+		bob     = common.Address{0x0b}
+		chain   = newTestChain(addr)
+		ledger  = testdata.NewLedger(chain.ds)
+		schemas = loadSchemas(t)
+	)
+	store, err := NewSQLStore(db, schemas)
+	r.NoError(err)
+	// Creating the tables again is a no-op
+	_, err = NewSQLStore(db, schemas)
+	r.NoError(err)
+	indexer := New(schemas, addr, store)
+
+	ledger.Get(alice).Set(big.NewInt(10), "first", testdata.Point{X: 1, Y: -1}, []uint64{1, 2})
+	ledger.Get(bob).SetMemo("bob")
+	chain.commit()
+	ledger.Get(alice).SetBalance(big.NewInt(20))
+	ledger.Get(bob).Delete()
+	chain.commit()
+	r.NoError(indexer.Sync(context.Background(), &testFilterer{logs: chain.logs}, big.NewInt(1), nil))
+
+	ledgerSlot := chain.ds.Get(testdata.LedgerDefaultKey()).Slot()
+	aliceKey := codec.EncodeAddress(20, alice)
+
+	var count int
+	r.NoError(db.QueryRow(`SELECT COUNT(*) FROM "Ledger"`).Scan(&count))
+	r.Equal(1, count)
+
+	var (
+		tableSlot, keyHash, account []byte
+		balance, memo, x, y         []byte
+		history                     string
+	)
+	r.NoError(db.QueryRow(
+		`SELECT "table_slot", "key_hash", "key_account", "balance", "memo", "position.x", "position.y", "history" FROM "Ledger"`,
+	).Scan(&tableSlot, &keyHash, &account, &balance, &memo, &x, &y, &history))
+	r.Equal(ledgerSlot.Bytes(), tableSlot)
+	r.Equal(KeyHash([][]byte{aliceKey}).Bytes(), keyHash)
+	r.Equal(aliceKey, account)
+	r.Equal(big.NewInt(20), codec.DecodeUint256(32, balance))
+	r.Equal("first", codec.DecodeString(32, memo))
+	r.Equal(int32(1), codec.DecodeSmallInt32(4, x))
+	r.Equal(int32(-1), codec.DecodeSmallInt32(4, y))
+	r.Equal(`["0x0000000000000001","0x0000000000000002"]`, history)
+
+	r.Error(store.Apply(&Change{Table: "Ledger", TableSlot: ledgerSlot, Field: "memo", Value: []byte{0x01}}))
+}
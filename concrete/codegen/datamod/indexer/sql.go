@@ -0,0 +1,140 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod"
+)
+
+// SQLStore mirrors tables into a SQL database, using the SQLite dialect. The
+// driver is left to the caller. Each datamod table is mirrored into a SQL
+// table of the same name with the columns:
+//
+//	table_slot, key_hash  identify the row, see Change
+//	key_<name>            the encoded keys
+//	<field>               the encoded value of each field, with the names
+//	                      of struct fields separated by dots
+//
+// Array fields hold a JSON list of hex encoded elements.
+type SQLStore struct {
+	db     *sql.DB
+	tables map[string]datamod.TableSchema
+}
+
+// NewSQLStore creates the SQL tables mirroring the given schemas if they do
+// not exist yet.
+func NewSQLStore(db *sql.DB, schemas []datamod.TableSchema) (*SQLStore, error) {
+	store := &SQLStore{db: db, tables: make(map[string]datamod.TableSchema, len(schemas))}
+	for _, schema := range schemas {
+		if _, err := db.Exec(createTableStmt(schema)); err != nil {
+			return nil, fmt.Errorf("failed to create table '%s': %w", schema.Name, err)
+		}
+		store.tables[schema.Name] = schema
+	}
+	return store, nil
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func keyColumn(name string) string {
+	return quoteIdent("key_" + name)
+}
+
+func createTableStmt(schema datamod.TableSchema) string {
+	columns := []string{`"table_slot" BLOB NOT NULL`, `"key_hash" BLOB NOT NULL`}
+	for _, key := range schema.Keys {
+		columns = append(columns, keyColumn(key.Name)+" BLOB")
+	}
+	fields := make(map[int]string)
+	fieldNames(schema.Values, "", fields)
+	indexes := make([]int, 0, len(fields))
+	for index := range fields {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		columns = append(columns, quoteIdent(fields[index])+" BLOB")
+	}
+	columns = append(columns, `PRIMARY KEY ("table_slot", "key_hash")`)
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", quoteIdent(schema.Name), strings.Join(columns, ", "))
+}
+
+func (s *SQLStore) Apply(change *Change) error {
+	stmt, args, err := s.applyStmt(change)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(stmt, args...)
+	return err
+}
+
+func (s *SQLStore) applyStmt(change *Change) (string, []interface{}, error) {
+	schema, ok := s.tables[change.Table]
+	if !ok {
+		return "", nil, fmt.Errorf("table '%s' is not mirrored", change.Table)
+	}
+	if len(change.Keys) != len(schema.Keys) {
+		return "", nil, fmt.Errorf("table '%s' has %d keys, change has %d", schema.Name, len(schema.Keys), len(change.Keys))
+	}
+	table := quoteIdent(schema.Name)
+	keyHash := change.KeyHash()
+
+	if change.Deleted {
+		stmt := fmt.Sprintf(`DELETE FROM %s WHERE "table_slot" = ? AND "key_hash" = ?`, table)
+		return stmt, []interface{}{change.TableSlot.Bytes(), keyHash.Bytes()}, nil
+	}
+
+	var value interface{} = change.Value
+	if change.Values != nil {
+		elems := make([]string, len(change.Values))
+		for ii, elem := range change.Values {
+			elems[ii] = hexutil.Encode(elem)
+		}
+		encoded, err := json.Marshal(elems)
+		if err != nil {
+			return "", nil, err
+		}
+		value = string(encoded)
+	}
+
+	columns := []string{`"table_slot"`, `"key_hash"`}
+	args := []interface{}{change.TableSlot.Bytes(), keyHash.Bytes()}
+	for ii, key := range schema.Keys {
+		columns = append(columns, keyColumn(key.Name))
+		args = append(args, change.Keys[ii])
+	}
+	field := quoteIdent(change.Field)
+	columns = append(columns, field)
+	args = append(args, value)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt := fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES (%s) ON CONFLICT ("table_slot", "key_hash") DO UPDATE SET %s = excluded.%s`,
+		table, strings.Join(columns, ", "), placeholders, field, field,
+	)
+	return stmt, args, nil
+}
+
+var _ Store = (*SQLStore)(nil)
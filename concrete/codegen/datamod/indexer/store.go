@@ -0,0 +1,153 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package indexer
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Store mirrors datamod tables from their change events.
+type Store interface {
+	Apply(change *Change) error
+}
+
+// Record is a row mirrored by a MemoryStore.
+type Record struct {
+	Table     string
+	TableSlot common.Hash
+	Keys      [][]byte
+	// Fields holds the encoded values of value fields and Arrays the encoded
+	// elements of array fields. Fields that were never set are missing.
+	Fields map[string][]byte
+	Arrays map[string][][]byte
+}
+
+func (r *Record) copy() *Record {
+	cpy := &Record{
+		Table:     r.Table,
+		TableSlot: r.TableSlot,
+		Keys:      r.Keys,
+		Fields:    make(map[string][]byte, len(r.Fields)),
+		Arrays:    make(map[string][][]byte, len(r.Arrays)),
+	}
+	for name, value := range r.Fields {
+		cpy.Fields[name] = value
+	}
+	for name, values := range r.Arrays {
+		cpy.Arrays[name] = values
+	}
+	return cpy
+}
+
+type recordID struct {
+	tableSlot common.Hash
+	keyHash   common.Hash
+}
+
+type memoryTable struct {
+	records map[recordID]*Record
+	order   []recordID
+}
+
+// MemoryStore mirrors tables in memory. It is safe for concurrent use, so
+// it can be queried while an Indexer is watching.
+type MemoryStore struct {
+	lock   sync.RWMutex
+	tables map[string]*memoryTable
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tables: make(map[string]*memoryTable)}
+}
+
+func (s *MemoryStore) Apply(change *Change) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	table, ok := s.tables[change.Table]
+	if !ok {
+		table = &memoryTable{records: make(map[recordID]*Record)}
+		s.tables[change.Table] = table
+	}
+	id := recordID{change.TableSlot, change.KeyHash()}
+	record, ok := table.records[id]
+
+	if change.Deleted {
+		if ok {
+			delete(table.records, id)
+			for ii, other := range table.order {
+				if other == id {
+					table.order = append(table.order[:ii], table.order[ii+1:]...)
+					break
+				}
+			}
+		}
+		return nil
+	}
+
+	if !ok {
+		record = &Record{
+			Table:     change.Table,
+			TableSlot: change.TableSlot,
+			Keys:      change.Keys,
+			Fields:    make(map[string][]byte),
+			Arrays:    make(map[string][][]byte),
+		}
+		table.records[id] = record
+		table.order = append(table.order, id)
+	}
+	if change.Values != nil {
+		record.Arrays[change.Field] = change.Values
+	} else {
+		record.Fields[change.Field] = change.Value
+	}
+	return nil
+}
+
+// Record returns a copy of a row of the table instance at tableSlot, or nil
+// if it has no fields set.
+func (s *MemoryStore) Record(table string, tableSlot common.Hash, keys ...[]byte) *Record {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if t, ok := s.tables[table]; ok {
+		if record, ok := t.records[recordID{tableSlot, KeyHash(keys)}]; ok {
+			return record.copy()
+		}
+	}
+	return nil
+}
+
+// Records returns copies of the rows of all instances of a table, in the
+// order they were first set.
+func (s *MemoryStore) Records(table string) []*Record {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	t, ok := s.tables[table]
+	if !ok {
+		return nil
+	}
+	records := make([]*Record, len(t.order))
+	for ii, id := range t.order {
+		records[ii] = t.records[id].copy()
+	}
+	return records
+}
+
+var _ Store = (*MemoryStore)(nil)
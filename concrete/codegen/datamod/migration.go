@@ -0,0 +1,388 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package datamod
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// The storage of a table depends on its name, the types of its keys, the
+// position of its fields in the row and the sets it keeps for indexes and
+// enumeration. Changes to any of these are checked against a previous schema
+// before generating code, and moved fields are migrated when possible.
+
+// NestedTableLayout is the version of the storage layout of nested tables.
+// Version 1 rooted nested tables at the slot of their field, version 2 at
+// keccak256 of it. The rows of nested tables cannot be found to be migrated,
+// so data models with nested tables generated with version 1 are
+// incompatible with the current version.
+const NestedTableLayout = 2
+
+func canonicalType(fieldType *FieldType) string {
+	switch {
+	case fieldType.Type == TableType:
+		return "table " + fieldType.Name
+	case fieldType.Type == StructType:
+		return "struct " + fieldType.Name
+	case fieldType.Enum != nil:
+		return "enum " + fieldType.Name
+	case fieldType.Type == ArrayType && fieldType.Length > 0:
+		return fmt.Sprintf("%s[%d]", canonicalType(fieldType.Elem), fieldType.Length)
+	case fieldType.Type == ArrayType:
+		return canonicalType(fieldType.Elem) + "[]"
+	case fieldType.Name == "uint" || fieldType.Name == "int":
+		return fieldType.Name + "256"
+	}
+	return fieldType.Name
+}
+
+type leafLayout struct {
+	FieldSchema
+	Slot   int
+	Offset int
+}
+
+func (l leafLayout) position() string {
+	return fmt.Sprintf("slot %d offset %d", l.Slot, l.Offset)
+}
+
+func tableLeaves(schema TableSchema) map[string]leafLayout {
+	slots, offsets := rowLayout(schema.Sizes())
+	leaves := make(map[string]leafLayout)
+	for _, field := range schema.Leaves() {
+		leaves[field.Path] = leafLayout{field, slots[field.Index], offsets[field.Index]}
+	}
+	return leaves
+}
+
+func keyTypes(schema TableSchema) string {
+	types := make([]string, len(schema.Keys))
+	for ii, key := range schema.Keys {
+		types[ii] = canonicalType(&key.Type)
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+func indexFields(index IndexSchema) string {
+	names := make([]string, len(index.Fields))
+	for ii, field := range index.Fields {
+		names[ii] = lowerFirstLetter(field.Path)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (m *dataModel) hasNestedTables() bool {
+	for _, schema := range m.Tables {
+		for _, field := range schema.Leaves() {
+			if field.Type.Type == TableType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fingerprint hashes everything that determines where the data model is
+// stored. It does not depend on the order of tables and types in the schema.
+// The nested table layout is only included from version 2 on, so that the
+// fingerprints of version 1 and of data models without nested tables are
+// unchanged.
+func (m *dataModel) fingerprint() common.Hash {
+	var lines []string
+	if m.NestedLayout > 1 && m.hasNestedTables() {
+		lines = append(lines, fmt.Sprintf("layout nested %d", m.NestedLayout))
+	}
+	for _, schema := range m.Tables {
+		lines = append(lines, fmt.Sprintf("table %s %s", schema.Name, keyTypes(schema)))
+		slots, offsets := rowLayout(schema.Sizes())
+		for _, field := range schema.Leaves() {
+			lines = append(lines, fmt.Sprintf("field %s.%s %s %d %d", schema.Name, field.Path, canonicalType(&field.Type), slots[field.Index], offsets[field.Index]))
+		}
+		if schema.Enumerable {
+			lines = append(lines, fmt.Sprintf("enumerable %s", schema.Name))
+		}
+		for _, index := range schema.Indexes {
+			lines = append(lines, fmt.Sprintf("index %s.%s (%s)", schema.Name, index.Name, indexFields(index)))
+		}
+	}
+	for _, enum := range m.Enums {
+		lines = append(lines, fmt.Sprintf("enum %s (%s)", enum.Name, strings.Join(enum.Values, ", ")))
+	}
+	sort.Strings(lines)
+	return crypto.Keccak256Hash([]byte(strings.Join(lines, "\n")))
+}
+
+type SchemaChange struct {
+	Table string
+	// Op is '+' for additions, '-' for removals and '~' for other changes.
+	Op     byte
+	Detail string
+	// Reason explains why the change is incompatible, and is empty otherwise.
+	Reason string
+}
+
+func (c SchemaChange) String() string {
+	str := fmt.Sprintf("%c %s", c.Op, c.Detail)
+	if c.Reason != "" {
+		str += " (incompatible: " + c.Reason + ")"
+	}
+	return str
+}
+
+type fieldMigration struct {
+	From         int
+	To           int
+	Kind         string
+	Length       int
+	DynamicElems bool
+}
+
+type tableMigration struct {
+	Table     string
+	PrevSizes string
+	Sizes     string
+	Keyless   bool
+	Moves     []fieldMigration
+}
+
+// SchemaDiff lists the changes from a previous schema, grouped by table.
+type SchemaDiff struct {
+	Changes             []SchemaChange
+	Fingerprint         common.Hash
+	PreviousFingerprint common.Hash
+	migrations          []tableMigration
+}
+
+func (d *SchemaDiff) Compatible() bool {
+	for _, change := range d.Changes {
+		if change.Reason != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *SchemaDiff) String() string {
+	var lines []string
+	table := ""
+	for ii, change := range d.Changes {
+		if ii == 0 || change.Table != table {
+			table = change.Table
+			lines = append(lines, table+":")
+		}
+		lines = append(lines, "  "+change.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d *SchemaDiff) add(table string, op byte, reason string, format string, args ...interface{}) {
+	d.Changes = append(d.Changes, SchemaChange{Table: table, Op: op, Detail: fmt.Sprintf(format, args...), Reason: reason})
+}
+
+func sizesString(sizes []int) string {
+	strs := make([]string, len(sizes))
+	for ii, size := range sizes {
+		strs[ii] = fmt.Sprint(size)
+	}
+	return fmt.Sprintf("[]int{%s}", strings.Join(strs, ", "))
+}
+
+func fieldMoveKind(fieldType *FieldType) string {
+	switch {
+	case fieldType.Type == BytesType:
+		return "FieldBytes"
+	case fieldType.Type == ArrayType && fieldType.Length > 0:
+		return "FieldFixedArray"
+	case fieldType.Type == ArrayType:
+		return "FieldArray"
+	}
+	return "FieldValue"
+}
+
+func diffDataModels(prev *dataModel, cur *dataModel) *SchemaDiff {
+	diff := &SchemaDiff{
+		Fingerprint:         cur.fingerprint(),
+		PreviousFingerprint: prev.fingerprint(),
+	}
+
+	prevEnums := make(map[string]*EnumSchema)
+	for _, enum := range prev.Enums {
+		prevEnums[enum.Name] = enum
+	}
+	for _, enum := range cur.Enums {
+		prevEnum, ok := prevEnums[enum.Name]
+		if !ok {
+			continue
+		}
+		isPrefix := len(prevEnum.Values) <= len(enum.Values)
+		for ii := 0; isPrefix && ii < len(prevEnum.Values); ii++ {
+			isPrefix = prevEnum.Values[ii] == enum.Values[ii]
+		}
+		if !isPrefix {
+			diff.add("enum "+enum.Name, '~', "enum values are stored by position and can only be appended",
+				"values (%s) -> (%s)", strings.Join(prevEnum.Values, ", "), strings.Join(enum.Values, ", "))
+		} else if len(enum.Values) > len(prevEnum.Values) {
+			diff.add("enum "+enum.Name, '+', "", "values %s", strings.Join(enum.Values[len(prevEnum.Values):], ", "))
+		}
+	}
+
+	nested := make(map[string]bool)
+	prevTables := make(map[string]TableSchema)
+	for _, schema := range prev.Tables {
+		prevTables[schema.Name] = schema
+		for _, field := range schema.Leaves() {
+			if field.Type.Type == TableType {
+				nested[field.Type.Name] = true
+			}
+		}
+	}
+	curTables := make(map[string]bool)
+	for _, schema := range cur.Tables {
+		curTables[schema.Name] = true
+		prevSchema, ok := prevTables[schema.Name]
+		if !ok {
+			diff.add(schema.Name, '+', "", "table")
+			continue
+		}
+		diff.diffTable(prevSchema, schema, nested[schema.Name], prev.NestedLayout, cur.NestedLayout)
+	}
+	for _, schema := range prev.Tables {
+		if !curTables[schema.Name] {
+			diff.add(schema.Name, '-', "the rows of the table would be left in storage", "table")
+		}
+	}
+	return diff
+}
+
+func (d *SchemaDiff) diffTable(prev TableSchema, cur TableSchema, nested bool, prevLayout int, layout int) {
+	name := cur.Name
+	if prevKeys, keys := keyTypes(prev), keyTypes(cur); prevKeys != keys {
+		d.add(name, '~', "rows are stored at slots derived from their keys", "keys %s -> %s", prevKeys, keys)
+	}
+	if !prev.Enumerable && cur.Enumerable {
+		d.add(name, '+', "existing rows would be missing from the row set", "enumerable")
+	} else if prev.Enumerable && !cur.Enumerable {
+		d.add(name, '-', "", "enumerable")
+	}
+
+	prevIndexes := make(map[string]string)
+	for _, index := range prev.Indexes {
+		prevIndexes[index.Name] = indexFields(index)
+	}
+	curIndexes := make(map[string]bool)
+	for _, index := range cur.Indexes {
+		curIndexes[index.Name] = true
+		prevFields, ok := prevIndexes[index.Name]
+		if !ok {
+			d.add(name, '+', "existing rows would be missing from the index", "index %s (%s)", index.Name, indexFields(index))
+		} else if fields := indexFields(index); fields != prevFields {
+			d.add(name, '~', "existing rows would be missing from the index", "index %s (%s) -> (%s)", index.Name, prevFields, fields)
+		}
+	}
+	for _, index := range prev.Indexes {
+		if !curIndexes[index.Name] {
+			d.add(name, '-', "", "index %s (%s)", index.Name, prevIndexes[index.Name])
+		}
+	}
+
+	// Moved fields can only be migrated if all rows of the table can be
+	// found, i.e. for singletons and enumerable tables.
+	var moveReason string
+	switch {
+	case nested:
+		moveReason = "the rows of tables nested in other tables cannot be migrated"
+	case len(cur.Keys) > 0 && !(prev.Enumerable && cur.Enumerable):
+		moveReason = "only the rows of enumerable tables can be migrated"
+	}
+
+	prevLeaves := tableLeaves(prev)
+	leaves := tableLeaves(cur)
+	var moves []fieldMigration
+	for _, field := range cur.Leaves() {
+		leaf := leaves[field.Path]
+		fieldName := lowerFirstLetter(field.Path)
+		fieldType := canonicalType(&field.Type)
+		prevLeaf, ok := prevLeaves[field.Path]
+		switch {
+		case !ok:
+			d.add(name, '+', "", "%s %s", fieldName, fieldType)
+		case canonicalType(&prevLeaf.Type) != fieldType:
+			d.add(name, '~', "the type of a field cannot change", "%s %s -> %s", fieldName, canonicalType(&prevLeaf.Type), fieldType)
+		case field.Type.Type == TableType && prevLayout != layout:
+			d.add(name, '~', "the rows of nested tables cannot be migrated", "%s %s moved from nested table layout %d to %d", fieldName, fieldType, prevLayout, layout)
+		case prevLeaf.position() != leaf.position():
+			reason := moveReason
+			if field.Type.Type == TableType {
+				reason = "table fields cannot be moved"
+			}
+			d.add(name, '~', reason, "%s %s moved from %s to %s", fieldName, fieldType, prevLeaf.position(), leaf.position())
+			moves = append(moves, fieldMigration{
+				From:         prevLeaf.Index,
+				To:           leaf.Index,
+				Kind:         fieldMoveKind(&field.Type),
+				Length:       field.Type.Length,
+				DynamicElems: field.Type.Type == ArrayType && field.Type.Elem.Type == BytesType,
+			})
+		}
+	}
+	for _, field := range prev.Leaves() {
+		if _, ok := leaves[field.Path]; !ok {
+			d.add(name, '-', "removed fields would be left in the row", "%s %s", lowerFirstLetter(field.Path), canonicalType(&field.Type))
+		}
+	}
+
+	if len(moves) > 0 {
+		d.migrations = append(d.migrations, tableMigration{
+			Table:     formatTableName(name),
+			PrevSizes: sizesString(prev.Sizes()),
+			Sizes:     sizesString(cur.Sizes()),
+			Keyless:   len(cur.Keys) == 0,
+			Moves:     moves,
+		})
+	}
+}
+
+func readDataModel(jsonPath string) (*dataModel, error) {
+	jsonContent, err := ReadSchema(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalDataModel(jsonContent)
+}
+
+// DiffSchemas compares the schema at jsonPath with a previous version. Both
+// can be JSON files or Go packages, see ReadSchema. prevLayout is the nested
+// table layout the previous version was generated with, NestedTableLayout if
+// zero.
+func DiffSchemas(prevJSONPath string, jsonPath string, prevLayout int) (*SchemaDiff, error) {
+	prev, err := readDataModel(prevJSONPath)
+	if err != nil {
+		return nil, fmt.Errorf("previous schema: %w", err)
+	}
+	if prevLayout != 0 {
+		prev.NestedLayout = prevLayout
+	}
+	cur, err := readDataModel(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+	return diffDataModels(prev, cur), nil
+}
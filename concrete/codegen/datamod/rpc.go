@@ -0,0 +1,123 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package datamod
+
+import (
+	_ "embed"
+	"fmt"
+	"text/template"
+)
+
+//go:embed rpc.tpl
+var rpcTpl string
+
+type rpcField struct {
+	Name  string
+	Title string
+	Type  string
+	// Value is the expression converting the field of a row to Type.
+	Value string
+}
+
+type rpcTable struct {
+	TableStructName string
+	RowStructName   string
+	Keyless         bool
+	// KeyParams declares the keys with their RPC types, and KeyArgs converts
+	// them back to the types of the table.
+	KeyParams string
+	KeyArgs   string
+	Fields    []rpcField
+}
+
+// rpcType returns the type used to send values of the given type over
+// JSON-RPC, and the expressions converting to and from it. Unsigned big and 64
+// bit integers and byte slices are hex encoded, signed big integers are
+// decimal strings.
+func rpcType(fieldType FieldType) (string, string, string) {
+	switch {
+	case fieldType.Type == ArrayType:
+		elemType, elemToRPC, _ := rpcType(*fieldType.Elem)
+		if fieldType.Elem.GoType == "uint8" {
+			// Byte slices would be hex encoded.
+			elemType, elemToRPC = "uint", "uint(%s)"
+		}
+		if elemToRPC == "%s" {
+			return fieldType.GoType, "%s", ""
+		}
+		toRPC := fmt.Sprintf("service.Array(%%s, func(v %s) %s { return %s })", fieldType.Elem.GoType, elemType, fmt.Sprintf(elemToRPC, "v"))
+		return "[]" + elemType, toRPC, ""
+	case fieldType.Type == StructType:
+		return "interface{}", "service.Value(%s)", ""
+	case fieldType.Codec == "Uint256":
+		return "*hexutil.Big", "(*hexutil.Big)(%s)", "(*big.Int)(%s)"
+	case fieldType.Codec == "Int256":
+		return "*math.Decimal256", "(*math.Decimal256)(%s)", "(*big.Int)(%s)"
+	case fieldType.GoType == "[]byte":
+		return "hexutil.Bytes", "hexutil.Bytes(%s)", "[]byte(%s)"
+	case fieldType.GoType == "uint64":
+		return "hexutil.Uint64", "hexutil.Uint64(%s)", "uint64(%s)"
+	}
+	return fieldType.GoType, "%s", "%s"
+}
+
+func newRPCTable(schema TableSchema) rpcTable {
+	table := rpcTable{
+		TableStructName: formatTableName(schema.Name),
+		RowStructName:   formatRowName(schema.Name),
+		Keyless:         len(schema.Keys) == 0,
+	}
+	for _, key := range schema.Keys {
+		goType, _, fromRPC := rpcType(key.Type)
+		table.KeyParams += fmt.Sprintf("%s %s, ", key.Name, goType)
+		if table.KeyArgs != "" {
+			table.KeyArgs += ", "
+		}
+		table.KeyArgs += fmt.Sprintf(fromRPC, key.Name)
+	}
+	for _, value := range schema.Values {
+		field := rpcField{Name: value.Name, Title: value.Title}
+		if value.Type.Type == TableType {
+			field.Type = "common.Hash"
+			field.Value = fmt.Sprintf("lib.NestedTableSlot(row.GetField_slot(%d)).Slot()", value.Index)
+		} else {
+			goType, toRPC, _ := rpcType(value.Type)
+			field.Type = goType
+			field.Value = fmt.Sprintf(toRPC, "row.Get"+value.Path+"()")
+		}
+		table.Fields = append(table.Fields, field)
+	}
+	return table
+}
+
+// generateRPC writes an RPC service with read methods for every table, which
+// is registered under the given namespace.
+func generateRPC(model *dataModel, config Config) error {
+	tpl, err := template.New("rpc").Parse(rpcTpl)
+	if err != nil {
+		return err
+	}
+	var tables []rpcTable
+	for _, schema := range model.Tables {
+		tables = append(tables, newRPCTable(schema))
+	}
+	data := map[string]interface{}{
+		"Package":   config.Package,
+		"Namespace": config.RPCNamespace,
+		"Tables":    tables,
+	}
+	return writeTemplate(tpl, data, config.Out, "datamodRpc")
+}
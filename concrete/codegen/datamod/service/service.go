@@ -0,0 +1,185 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var errHeaderNotFound = errors.New("header not found")
+
+// Backend gives access to committed headers and state. It is implemented by
+// *core.BlockChain.
+type Backend interface {
+	CurrentBlock() *types.Header
+	CurrentSafeBlock() *types.Header
+	CurrentFinalBlock() *types.Header
+	GetHeaderByNumber(number uint64) *types.Header
+	GetHeaderByHash(hash common.Hash) *types.Header
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
+// Service reads the datastore of a precompile at any committed block. It
+// backs the RPC services generated by datamod.
+type Service struct {
+	backend Backend
+	address common.Address
+}
+
+func NewService(backend Backend, address common.Address) *Service {
+	return &Service{backend: backend, address: address}
+}
+
+// APIRegistrar is implemented by *node.Node.
+type APIRegistrar interface {
+	RegisterAPIs(apis []rpc.API)
+}
+
+// Register registers an RPC service with the node under the given namespace.
+func Register(stack APIRegistrar, namespace string, service interface{}) {
+	stack.RegisterAPIs([]rpc.API{{
+		Namespace: namespace,
+		Service:   service,
+	}})
+}
+
+func (s *Service) header(blockNrOrHash *rpc.BlockNumberOrHash) (*types.Header, error) {
+	if blockNrOrHash == nil {
+		return s.backend.CurrentBlock(), nil
+	}
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		header := s.backend.GetHeaderByHash(hash)
+		if header == nil {
+			return nil, errHeaderNotFound
+		}
+		if blockNrOrHash.RequireCanonical {
+			canonical := s.backend.GetHeaderByNumber(header.Number.Uint64())
+			if canonical == nil || canonical.Hash() != hash {
+				return nil, errors.New("hash is not currently canonical")
+			}
+		}
+		return header, nil
+	}
+	number, _ := blockNrOrHash.Number()
+	var header *types.Header
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		header = s.backend.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		header = s.backend.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		header = s.backend.CurrentFinalBlock()
+	case rpc.EarliestBlockNumber:
+		header = s.backend.GetHeaderByNumber(0)
+	default:
+		header = s.backend.GetHeaderByNumber(uint64(number))
+	}
+	if header == nil {
+		return nil, errHeaderNotFound
+	}
+	return header, nil
+}
+
+// Datastore returns a read-only view of the datastore of the precompile at
+// the given block, or at the latest block if blockNrOrHash is nil.
+func (s *Service) Datastore(blockNrOrHash *rpc.BlockNumberOrHash) (lib.Datastore, error) {
+	header, err := s.header(blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := s.backend.StateAt(header.Root)
+	if err != nil {
+		return nil, err
+	}
+	return lib.NewKVDatastore(&stateKV{statedb: statedb, address: s.address}), nil
+}
+
+type stateKV struct {
+	statedb *state.StateDB
+	address common.Address
+}
+
+func (kv *stateKV) Get(key common.Hash) common.Hash {
+	return kv.statedb.GetState(kv.address, key)
+}
+
+func (kv *stateKV) Set(key common.Hash, value common.Hash) {
+	panic("rpc datastore is read-only")
+}
+
+var _ lib.KeyValueStore = (*stateKV)(nil)
+
+var (
+	bigType   = reflect.TypeOf((*big.Int)(nil))
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// Array converts the elements of an array field with convert.
+func Array[T any, R any](values []T, convert func(T) R) []R {
+	converted := make([]R, len(values))
+	for ii, value := range values {
+		converted[ii] = convert(value)
+	}
+	return converted
+}
+
+// Value converts a decoded struct value to its JSON-RPC representation: big
+// and 64 bit unsigned integers and byte slices are hex encoded, and structs
+// become objects keyed by their datamod field names. Arrays of uint8 cannot be
+// told apart from bytes, and are hex encoded as well.
+func Value(value interface{}) interface{} {
+	return jsonValue(reflect.ValueOf(value))
+}
+
+func jsonValue(v reflect.Value) interface{} {
+	switch {
+	case v.Type() == bigType:
+		return (*hexutil.Big)(v.Interface().(*big.Int))
+	case v.Type() == bytesType:
+		return hexutil.Bytes(v.Bytes())
+	}
+	switch v.Kind() {
+	case reflect.Uint64:
+		return hexutil.Uint64(v.Uint())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Array {
+			return v.Interface()
+		}
+		values := make([]interface{}, v.Len())
+		for ii := range values {
+			values[ii] = jsonValue(v.Index(ii))
+		}
+		return values
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for ii := 0; ii < v.NumField(); ii++ {
+			name := v.Type().Field(ii).Name
+			fields[strings.ToLower(name[:1])+name[1:]] = jsonValue(v.Field(ii))
+		}
+		return fields
+	}
+	return v.Interface()
+}
@@ -0,0 +1,354 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package datamod
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed table.sol.tpl
+var tableSolTpl string
+
+//go:embed storage.sol.tpl
+var storageSolTpl string
+
+//go:embed types.sol.tpl
+var typesSolTpl string
+
+// solField holds what the Solidity templates need to read and write a field.
+type solField struct {
+	FieldSchema
+	Kind string
+	// Type is the Solidity type of the field and Param the same type with
+	// its data location, as used for parameters and return values.
+	Type  string
+	Param string
+	// ABIType is the canonical type used in method signatures.
+	ABIType string
+	// Slot is the index of the slot of the field in the row, and Offset the
+	// offset of the field within the slot.
+	Slot   int
+	Offset int
+	// Decode reads the field from the loaded storage word or slot.
+	Decode string
+	// Setter is the signature of the precompile method setting the field.
+	Setter string
+	// Length is the length of fixed arrays, zero for dynamic ones.
+	Length int
+	Elem   *solField
+	Fields []*solField
+}
+
+type solKey struct {
+	Name  string
+	Param string
+	// Pack is the expression encoding the key as done by the datamod codecs.
+	Pack string
+}
+
+type solTable struct {
+	Name      string
+	Library   string
+	Address   string
+	HasTypes  bool
+	Keys      []solKey
+	KeyParams string
+	KeyNames  string
+	// Fields are all the fields of a row, depth-first, and Values the top
+	// level ones except tables.
+	Fields     []*solField
+	Values     []*solField
+	Enumerable bool
+	// SetSignature and DeleteSignature are the signatures of the precompile
+	// methods setting and deleting whole rows.
+	SetSignature    string
+	DeleteSignature string
+}
+
+// rowLayout returns the slot and offset of each field of a row, following
+// lib.DatastoreStruct.
+func rowLayout(sizes []int) ([]int, []int) {
+	slots := make([]int, len(sizes))
+	offsets := make([]int, len(sizes))
+	offset := 0
+	for ii, size := range sizes {
+		if offset/32 != (offset+size-1)/32 {
+			offset = (offset/32 + 1) * 32
+		}
+		slots[ii], offsets[ii] = offset/32, offset%32
+		offset += size
+	}
+	return slots, offsets
+}
+
+func solidityType(fieldType *FieldType) string {
+	switch {
+	case fieldType.Enum != nil, fieldType.Type == StructType:
+		return fieldType.GoType
+	case fieldType.Type == ArrayType:
+		if fieldType.Length > 0 {
+			return fmt.Sprintf("%s[%d]", solidityType(fieldType.Elem), fieldType.Length)
+		}
+		return solidityType(fieldType.Elem) + "[]"
+	case fieldType.Name == "uint":
+		return "uint256"
+	case fieldType.Name == "int":
+		return "int256"
+	}
+	return fieldType.Name
+}
+
+func solidityParam(fieldType *FieldType) string {
+	typeStr := solidityType(fieldType)
+	switch fieldType.Type {
+	case BytesType, ArrayType, StructType:
+		return typeStr + " memory"
+	}
+	return typeStr
+}
+
+func solidityABIType(fieldType *FieldType, structs map[string][]FieldSchema) string {
+	switch {
+	case fieldType.Enum != nil:
+		return "uint8"
+	case fieldType.Type == StructType:
+		types := make([]string, 0, len(structs[fieldType.GoType]))
+		for _, field := range structs[fieldType.GoType] {
+			types = append(types, solidityABIType(&field.Type, structs))
+		}
+		return "(" + strings.Join(types, ",") + ")"
+	case fieldType.Type == ArrayType:
+		if fieldType.Length > 0 {
+			return fmt.Sprintf("%s[%d]", solidityABIType(fieldType.Elem, structs), fieldType.Length)
+		}
+		return solidityABIType(fieldType.Elem, structs) + "[]"
+	}
+	return solidityType(fieldType)
+}
+
+// solidityDecode returns the expression decoding a value type from a storage
+// word, where the value is left aligned like in lib.DatastoreStruct.
+func solidityDecode(fieldType *FieldType, word string) string {
+	if fieldType.Enum != nil {
+		return fmt.Sprintf("%s(uint8(bytes1(%s)))", fieldType.GoType, word)
+	}
+	switch name := fieldType.Name; {
+	case name == "address":
+		return fmt.Sprintf("address(bytes20(%s))", word)
+	case name == "bool":
+		return fmt.Sprintf("(uint8(bytes1(%s)) & 1) == 1", word)
+	case name == "bytes32":
+		return word
+	case strings.HasPrefix(name, "bytes"):
+		return fmt.Sprintf("%s(%s)", name, word)
+	case fieldType.Size == 32 && strings.HasPrefix(name, "uint"):
+		return fmt.Sprintf("uint256(%s)", word)
+	case fieldType.Size == 32:
+		return fmt.Sprintf("int256(uint256(%s))", word)
+	case strings.HasPrefix(name, "uint"):
+		return fmt.Sprintf("%s(bytes%d(%s))", name, fieldType.Size, word)
+	default:
+		return fmt.Sprintf("%s(uint%d(bytes%d(%s)))", name, fieldType.Size*8, fieldType.Size, word)
+	}
+}
+
+// solidityDecodeBytes returns the expression loading a bytes or string
+// value stored at the given slot.
+func solidityDecodeBytes(fieldType *FieldType, slot string) string {
+	load := fmt.Sprintf("DatamodStorage.loadBytes(precompileAddress, %s)", slot)
+	if fieldType.Name == "string" {
+		return "string(" + load + ")"
+	}
+	return load
+}
+
+func newSolField(field FieldSchema, slots []int, offsets []int, structs map[string][]FieldSchema) *solField {
+	f := &solField{
+		FieldSchema: field,
+		Type:        solidityType(&field.Type),
+		Param:       solidityParam(&field.Type),
+		ABIType:     solidityABIType(&field.Type, structs),
+	}
+	if field.Type.Type != StructType {
+		f.Slot, f.Offset = slots[field.Index], offsets[field.Index]
+	}
+	switch field.Type.Type {
+	case ValueType:
+		f.Kind = "value"
+		word := "word"
+		if f.Offset > 0 {
+			word = fmt.Sprintf("DatamodStorage.field(word, %d)", f.Offset)
+		}
+		f.Decode = solidityDecode(&field.Type, word)
+	case BytesType:
+		f.Kind = "bytes"
+		f.Decode = solidityDecodeBytes(&field.Type, "slot")
+	case ArrayType:
+		f.Kind = "array"
+		f.Length = field.Type.Length
+		elem := &solField{
+			Type:  solidityType(field.Type.Elem),
+			Param: solidityParam(field.Type.Elem),
+		}
+		elemSlot := "DatamodStorage.arrayElementSlot(slot, ii)"
+		if field.Type.Length > 0 {
+			elemSlot = "DatamodStorage.fixedArrayElementSlot(slot, ii)"
+		}
+		if field.Type.Elem.Type == BytesType {
+			elem.Kind = "bytes"
+			elem.Decode = solidityDecodeBytes(field.Type.Elem, elemSlot)
+		} else {
+			elem.Kind = "value"
+			elem.Decode = solidityDecode(field.Type.Elem, "words[ii]")
+		}
+		f.Elem = elem
+	case StructType:
+		f.Kind = "struct"
+		for _, child := range field.Fields {
+			f.Fields = append(f.Fields, newSolField(child, slots, offsets, structs))
+		}
+	case TableType:
+		f.Kind = "table"
+	}
+	return f
+}
+
+func newSolTable(schema TableSchema, address string, hasTypes bool, structs map[string][]FieldSchema) *solTable {
+	table := &solTable{
+		Name:       lowerFirstLetter(schema.Name),
+		Library:    formatTableName(schema.Name),
+		Address:    address,
+		HasTypes:   hasTypes,
+		Enumerable: schema.Enumerable,
+	}
+
+	var params, names, keyTypes []string
+	for _, key := range schema.Keys {
+		pack := key.Name
+		if key.Type.Enum != nil {
+			pack = "uint8(" + key.Name + ")"
+		}
+		table.Keys = append(table.Keys, solKey{
+			Name:  key.Name,
+			Param: solidityParam(&key.Type),
+			Pack:  pack,
+		})
+		params = append(params, solidityParam(&key.Type)+" "+key.Name)
+		names = append(names, key.Name)
+		keyTypes = append(keyTypes, solidityABIType(&key.Type, structs))
+	}
+	table.KeyParams = strings.Join(params, ", ")
+	table.KeyNames = strings.Join(names, ", ")
+
+	slots, offsets := rowLayout(schema.Sizes())
+	var build func(fields []FieldSchema) []*solField
+	build = func(fields []FieldSchema) []*solField {
+		var all []*solField
+		for _, field := range fields {
+			f := newSolField(field, slots, offsets, structs)
+			all = append(all, f)
+			if field.Type.Type == StructType {
+				all = append(all, build(field.Fields)...)
+			}
+		}
+		return all
+	}
+	table.Fields = build(schema.Values)
+	valueTypes := keyTypes
+	for _, value := range schema.Values {
+		if value.Type.Type == TableType {
+			continue
+		}
+		field := newSolField(value, slots, offsets, structs)
+		args := append(keyTypes[:len(keyTypes):len(keyTypes)], field.ABIType)
+		field.Setter = fmt.Sprintf("%s_set%s(%s)", table.Name, field.Path, strings.Join(args, ","))
+		table.Values = append(table.Values, field)
+		valueTypes = append(valueTypes[:len(valueTypes):len(valueTypes)], field.ABIType)
+	}
+	table.SetSignature = fmt.Sprintf("%s_set(%s)", table.Name, strings.Join(valueTypes, ","))
+	table.DeleteSignature = fmt.Sprintf("%s_delete(%s)", table.Name, strings.Join(keyTypes, ","))
+	return table
+}
+
+// GenerateSolidity writes Solidity libraries reading and writing the tables
+// of a data model, for a precompile at the given address.
+func GenerateSolidity(config Config) error {
+	jsonContent, err := ReadSchema(config.JSON)
+	if err != nil {
+		return err
+	}
+	model, err := unmarshalDataModel(jsonContent)
+	if err != nil {
+		return err
+	}
+
+	structs := make(map[string][]FieldSchema, len(model.Structs))
+	for _, schema := range model.Structs {
+		structs[schema.Name] = schema.Fields
+	}
+	hasTypes := len(model.Enums) > 0 || len(model.Structs) > 0
+
+	files := map[string]string{"DatamodStorage.sol": storageSolTpl}
+	if hasTypes {
+		var types []map[string]interface{}
+		for _, schema := range model.Structs {
+			var fields []string
+			for _, field := range schema.Fields {
+				fields = append(fields, solidityType(&field.Type)+" "+field.Name)
+			}
+			types = append(types, map[string]interface{}{"Name": schema.Name, "Fields": fields})
+		}
+		data := map[string]interface{}{"Enums": model.Enums, "Structs": types}
+		content, err := executeTemplate(typesSolTpl, data)
+		if err != nil {
+			return err
+		}
+		files["DatamodTypes.sol"] = content
+	}
+	for _, schema := range model.Tables {
+		table := newSolTable(schema, config.Address.Hex(), hasTypes, structs)
+		content, err := executeTemplate(tableSolTpl, table)
+		if err != nil {
+			return err
+		}
+		files[table.Library+".sol"] = content
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(config.Out, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func executeTemplate(content string, data interface{}) (string, error) {
+	tpl, err := template.New("").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -26,6 +26,71 @@ func {{.TableStructName}}DefaultKey() []byte {
 	return crypto.Keccak256([]byte("datamod.v1.{{.TableStructName}}"))
 }
 
+{{ if .Schema.Tracked -}}
+type {{.RowStructName}} struct {
+	lib.DatastoreStruct
+	dsSlot lib.DatastoreSlot
+	index  *lib.IndexedRow
+{{- if .Schema.Events }}
+	events *lib.TableEvents
+{{- end }}
+}
+
+func new{{.RowStructName}}(tableSlot lib.DatastoreSlot, dsSlot lib.DatastoreSlot, keys [][]byte) *{{.RowStructName}} {
+	sizes := {{.SizesStr}}
+	index := lib.NewIndexedRow(dsSlot, keys, new{{.TableStructName}}Keys(tableSlot), {{if .Schema.Enumerable}}lib.NewTableRows(tableSlot){{else}}nil{{end}},
+	{{- range .Schema.Indexes }}
+		lib.NewTableIndex(tableSlot, "{{.Name}}"),
+	{{- end }}
+	)
+{{- if .Schema.Events }}
+	events := lib.NewTableEvents({{.TableStructName}}DefaultKey(), tableSlot, keys)
+	return &{{.RowStructName}}{*lib.NewDatastoreStruct(dsSlot, sizes), tableSlot, index, events}
+{{- else }}
+	return &{{.RowStructName}}{*lib.NewDatastoreStruct(dsSlot, sizes), tableSlot, index}
+{{- end }}
+}
+
+func new{{.TableStructName}}Keys(tableSlot lib.DatastoreSlot) *lib.TableKeys {
+	sizes := {{.KeySizesStr}}
+	dynamic := {{.KeyDynamicStr}}
+	return lib.NewTableKeys(tableSlot, sizes, dynamic)
+}
+
+func (v *{{.RowStructName}}) indexGroups() []*lib.HashSet {
+	return v.index.Groups(
+	{{- range .Schema.Indexes }}
+		[][]byte{
+		{{- range .Fields }}
+			{{if eq .Type.Type 0}}v.GetField{{else}}v.GetField_bytes{{end}}({{.Index}}),
+		{{- end }}
+		},
+	{{- end }}
+	)
+}
+
+// Delete clears the row and removes it from all indexes.
+{{- if .Schema.Enumerable }} Rows of nested
+// tables are deleted as well.
+{{- end }}
+func (v *{{.RowStructName}}) Delete() {
+	v.index.Delete(v.indexGroups())
+{{- if .Schema.Events }}
+	v.events.DeleteRecord()
+{{- end }}
+{{- range .Schema.Leaves }}
+{{- if eq .Type.Type 0 }}
+	v.SetField({{.Index}}, make([]byte, {{.Type.Size}}))
+{{- else if eq .Type.Type 1 }}
+	v.SetField_bytes({{.Index}}, nil)
+{{- else if eq .Type.Type 3 }}
+	v.Get{{.Path}}Array().Clear()
+{{- else if and (eq .Type.Type 2) $.Schema.Enumerable }}
+	v.Get{{.Path}}().Clear()
+{{- end }}
+{{- end }}
+}
+{{- else -}}
 type {{.RowStructName}} struct {
 	lib.DatastoreStruct
 }
@@ -34,51 +99,111 @@ func New{{.RowStructName}}(dsSlot lib.DatastoreSlot) *{{.RowStructName}} {
 	sizes := {{.SizesStr}}
 	return &{{.RowStructName}}{*lib.NewDatastoreStruct(dsSlot, sizes)}
 }
+{{- end }}
 
 func (v *{{$.RowStructName}}) Get() (
 {{- range .Schema.Values }}
 	{{if eq .Type.Type 2}}*{{end}}{{.Type.GoType}},
 {{- end }}
 ) {
-	return {{ range .Schema.Values }}
+	return {{ range $i, $field := .Schema.Values }}
 		{{- if lt .Type.Type 2 -}}
-		codec.{{.Type.DecodeFunc}}({{.Type.Size}}, {{if eq .Type.Type 0}}v.GetField{{else}}v.GetField_bytes{{end}}({{.Index}}))
+		{{.Type.DecodeFuncRef}}({{.Type.Size}}, {{if eq .Type.Type 0}}v.GetField{{else}}v.GetField_bytes{{end}}({{.Index}}))
 		{{- else -}}
-		New{{.Type.GoType}}FromSlot(v.GetField_slot({{.Index}}))
+		v.Get{{.Path}}()
 		{{- end }}
-		{{- if ne .Index (sub (len $.Schema.Values) 1) }},
+		{{- if ne $i (sub (len $.Schema.Values) 1) }},
 		{{end}}
 	{{- end }}
 }
 
 func (v *{{$.RowStructName}}) Set(
 {{- range .Schema.Values }}
-{{- if lt .Type.Type 2 }}
+{{- if ne .Type.Type 2 }}
 	{{.Name}} {{.Type.GoType}},
 {{- end }}
 {{- end }}
 ) {
+{{- if .Schema.Indexes }}
+	groups := v.indexGroups()
+{{- end }}
 {{- range .Schema.Values }}
 {{- if lt .Type.Type 2 }}
 	{{if eq .Type.Type 0}}v.SetField{{else if eq .Type.Type 1}}v.SetField_bytes{{end -}}
-	({{ .Index }}, codec.{{.Type.EncodeFunc}}({{.Type.Size}}, {{.Name}}))
+	({{ .Index }}, {{.Type.EncodeFuncRef}}({{.Type.Size}}, {{.Name}}))
+{{- if $.Schema.Events }}
+	v.events.SetField({{ .Index }}, {{.Type.EncodeFuncRef}}({{.Type.Size}}, {{.Name}}))
+{{- end }}
+{{- else if ne .Type.Type 2 }}
+	v.Set{{.Path}}({{.Name}})
 {{- end }}
 {{- end }}
+{{- if .Schema.Indexes }}
+	v.index.Update(groups, v.indexGroups())
+{{- end }}
+{{- if .Schema.Enumerable }}
+	v.index.Insert()
+{{- end }}
 }
-{{range .Schema.Values}}
+{{range .Schema.AllValues}}
 {{- if lt .Type.Type 2 }}
-func (v *{{$.RowStructName}}) Get{{.Title}}() {{.Type.GoType}} {
+func (v *{{$.RowStructName}}) Get{{.Path}}() {{.Type.GoType}} {
 	data := {{if eq .Type.Type 0}}v.GetField{{else}}v.GetField_bytes{{end}}({{.Index}})
-	return codec.{{.Type.DecodeFunc}}({{.Type.Size}}, data)
+	return {{.Type.DecodeFuncRef}}({{.Type.Size}}, data)
 }
 
-func (v *{{$.RowStructName}}) Set{{.Title}}(value {{.Type.GoType}}) {
-	data := codec.{{.Type.EncodeFunc}}({{.Type.Size}}, value)
+func (v *{{$.RowStructName}}) Set{{.Path}}(value {{.Type.GoType}}) {
+	{{- if .Indexed }}
+	groups := v.indexGroups()
+	{{- end }}
+	data := {{.Type.EncodeFuncRef}}({{.Type.Size}}, value)
 	{{if eq .Type.Type 0}}v.SetField{{else}}v.SetField_bytes{{end}}({{.Index}}, data)
+	{{- if $.Schema.Events }}
+	v.events.SetField({{.Index}}, data)
+	{{- end }}
+	{{- if .Indexed }}
+	v.index.Update(groups, v.indexGroups())
+	{{- end }}
+	{{- if $.Schema.Enumerable }}
+	v.index.Insert()
+	{{- end }}
 }
-{{ else }}
-func (v *{{$.RowStructName}}) Get{{.Title}}() *{{.Type.GoType}} {
+{{ else if eq .Type.Type 3 }}
+func (v *{{$.RowStructName}}) Get{{.Path}}Array() *lib.{{if .Type.Length}}Fixed{{end}}Array[{{.Type.Elem.GoType}}] {
 	dsSlot := v.GetField_slot({{.Index}})
+	return lib.New{{if .Type.Length}}FixedArray(dsSlot, {{.Type.Length}}, {{else}}Array(dsSlot, {{end}}{{.Type.Elem.CodecRef}})
+}
+
+func (v *{{$.RowStructName}}) Get{{.Path}}() {{.Type.GoType}} {
+	return v.Get{{.Path}}Array().Values()
+}
+
+func (v *{{$.RowStructName}}) Set{{.Path}}(value {{.Type.GoType}}) {
+	v.Get{{.Path}}Array().SetValues(value)
+	{{- if $.Schema.Events }}
+	v.events.SetArray({{.Index}}, {{.Type.Elem.CodecRef}}.EncodeAll(value))
+	{{- end }}
+	{{- if $.Schema.Enumerable }}
+	v.index.Insert()
+	{{- end }}
+}
+{{ else if eq .Type.Type 4 }}
+func (v *{{$.RowStructName}}) Get{{.Path}}() {{.Type.GoType}} {
+	return {{.Type.GoType}}{
+	{{- range .Fields }}
+		{{.Title}}: v.Get{{.Path}}(),
+	{{- end }}
+	}
+}
+
+func (v *{{$.RowStructName}}) Set{{.Path}}(value {{.Type.GoType}}) {
+	{{- range .Fields }}
+	v.Set{{.Path}}(value.{{.Title}})
+	{{- end }}
+}
+{{ else }}
+func (v *{{$.RowStructName}}) Get{{.Path}}() *{{.Type.GoType}} {
+	dsSlot := lib.NestedTableSlot(v.GetField_slot({{.Index}}))
 	return New{{.Type.GoType}}FromSlot(dsSlot)
 }
 {{ end}}
@@ -102,22 +227,137 @@ func (m *{{.TableStructName}}) Get(
 	{{.Name}} {{.Type.GoType}},
 {{- end }}
 ) *{{.RowStructName}} {
+{{- if .Schema.Tracked }}
+	encodedKeys := [][]byte{
+		{{- range .Schema.Keys }}
+		{{.Type.EncodeFuncRef}}({{.Type.Size}}, {{.Name}}),
+		{{- end }}
+	}
+	dsSlot := m.dsSlot.Mapping().GetNested(encodedKeys...)
+	return new{{.RowStructName}}(m.dsSlot, dsSlot, encodedKeys)
+{{- else }}
 	dsSlot := m.dsSlot.Mapping().GetNested(
 		{{- range .Schema.Keys }}
-		codec.{{.Type.EncodeFunc}}({{.Type.Size}}, {{.Name}}),
+		{{.Type.EncodeFuncRef}}({{.Type.Size}}, {{.Name}}),
 		{{- end }}
 	)
 	return New{{.RowStructName}}(dsSlot)
+{{- end }}
 }
 {{- else }}
 type {{.TableStructName}} = {{.RowStructName}}
 
 func New{{.TableStructName}}(ds lib.Datastore) *{{.RowStructName}} {
 	dsSlot := ds.Get({{.TableStructName}}DefaultKey())
+{{- if .Schema.Tracked }}
+	return new{{.RowStructName}}(dsSlot, dsSlot, nil)
+{{- else }}
 	return New{{.RowStructName}}(dsSlot)
+{{- end }}
 }
 
 func New{{.TableStructName}}FromSlot(dsSlot lib.DatastoreSlot) *{{.RowStructName}} {
+{{- if .Schema.Tracked }}
+	return new{{.RowStructName}}(dsSlot, dsSlot, nil)
+{{- else }}
 	return New{{.RowStructName}}(dsSlot)
+{{- end }}
+}
+{{- end }}
+{{- if .Schema.Enumerable }}
+
+// Has reports whether the row exists. Rows are added when one of their fields
+// is set, and removed when they are deleted.
+func (m *{{.TableStructName}}) Has(
+{{- range .Schema.Keys }}
+	{{.Name}} {{.Type.GoType}},
+{{- end }}
+) bool {
+	return m.Get({{range $i, $k := .Schema.Keys}}{{if $i}}, {{end}}{{.Name}}{{end}}).index.Exists()
+}
+
+func (m *{{.TableStructName}}) Delete(
+{{- range .Schema.Keys }}
+	{{.Name}} {{.Type.GoType}},
+{{- end }}
+) {
+	m.Get({{range $i, $k := .Schema.Keys}}{{if $i}}, {{end}}{{.Name}}{{end}}).Delete()
+}
+
+func (m *{{.TableStructName}}) Len() uint64 {
+	return lib.NewTableRows(m.dsSlot).Length()
+}
+
+// Keys returns the keys of up to limit rows, starting at offset. Deleting
+// rows changes the order of the results.
+func (m *{{.TableStructName}}) Keys(offset uint64, limit uint64) []{{.TableStructName}}Key {
+	ids := lib.NewTableRows(m.dsSlot).Values(offset, limit)
+	return decode{{.TableStructName}}Keys(m.dsSlot, ids)
+}
+
+// Clear deletes all rows.
+func (m *{{.TableStructName}}) Clear() {
+	for _, key := range m.Keys(0, m.Len()) {
+		m.Delete({{range $i, $k := .Schema.Keys}}{{if $i}}, {{end}}key.{{.Title}}{{end}})
+	}
 }
 {{- end }}
+{{- if or .Schema.Enumerable .Schema.Indexes }}
+
+type {{.TableStructName}}Key struct {
+{{- range .Schema.Keys }}
+	{{.Title}} {{.Type.GoType}}
+{{- end }}
+}
+
+func decode{{.TableStructName}}Keys(tableSlot lib.DatastoreSlot, ids []common.Hash) []{{.TableStructName}}Key {
+	keys := make([]{{.TableStructName}}Key, len(ids))
+{{- if .Schema.Keys }}
+	records := new{{.TableStructName}}Keys(tableSlot)
+	for ii, id := range ids {
+		record := records.Get(id)
+		keys[ii] = {{.TableStructName}}Key{
+		{{- range $i, $key := .Schema.Keys }}
+			{{.Title}}: {{.Type.DecodeFuncRef}}({{.Type.Size}}, record[{{$i}}]),
+		{{- end }}
+		}
+	}
+{{- end }}
+	return keys
+}
+{{- range .Schema.Indexes }}
+
+func (m *{{$.TableStructName}}) {{.Name}}Group(
+{{- range .Fields }}
+	{{.Name}} {{.Type.GoType}},
+{{- end }}
+) *lib.HashSet {
+	return lib.NewTableIndex(m.dsSlot, "{{.Name}}").Group(
+	{{- range .Fields }}
+		{{.Type.EncodeFuncRef}}({{.Type.Size}}, {{.Name}}),
+	{{- end }}
+	)
+}
+
+// Find{{.Title}} returns the keys of up to limit rows matching the index
+// values, starting at offset. Removing rows changes the order of the results.
+func (m *{{$.TableStructName}}) Find{{.Title}}(
+{{- range .Fields }}
+	{{.Name}} {{.Type.GoType}},
+{{- end }}
+	offset uint64,
+	limit uint64,
+) []{{$.TableStructName}}Key {
+	ids := m.{{.Name}}Group({{range $i, $f := .Fields}}{{if $i}}, {{end}}{{.Name}}{{end}}).Values(offset, limit)
+	return decode{{$.TableStructName}}Keys(m.dsSlot, ids)
+}
+
+func (m *{{$.TableStructName}}) Count{{.Title}}(
+{{- range .Fields }}
+	{{.Name}} {{.Type.GoType}},
+{{- end }}
+) uint64 {
+	return m.{{.Name}}Group({{range $i, $f := .Fields}}{{if $i}}, {{end}}{{.Name}}{{end}}).Length()
+}
+{{- end }}
+{{- end }}
@@ -0,0 +1,91 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package model holds Go domain types with datamod annotations, equivalent to
+// a subset of good-datamod.json.
+package model
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type Color uint8
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	ColorBlue
+)
+
+type Point struct {
+	X int32
+	Y int32
+}
+
+type Shape struct {
+	Color   Color
+	Origin  Point
+	Name    string
+	Palette []Color
+}
+
+type ShapeTable struct {
+	ID      uint64 `datamod:"key"`
+	Color   Color  `datamod:"key"`
+	Shape   Shape
+	Visible bool
+	Center  Point
+}
+
+type OwnedTable struct {
+	ID     uint64         `datamod:"key"`
+	Name   string         `datamod:"key"`
+	Owner  common.Address `datamod:"index:byOwner,index:byOwnerAndKind"`
+	Kind   Color          `datamod:"index:byOwnerAndKind"`
+	Label  string         `datamod:"index:byLabel"`
+	Amount *big.Int
+	Tags   []uint8
+}
+
+//datamod:table enumerable
+type Inventory struct {
+	Owner common.Address `datamod:"key"`
+	Total *big.Int
+	Items []Item `datamod:"table item"`
+}
+
+//datamod:table enumerable events
+type Item struct {
+	ID    uint64 `datamod:"key"`
+	Name  string `datamod:"key"`
+	Count uint32
+	Label string `datamod:"index:byLabel"`
+	// Cached values are not stored.
+	cached string
+	Notes  string `datamod:"-"`
+}
+
+// Ledger is the balance sheet of an account.
+//
+//datamod:table events
+type Ledger struct {
+	Account  common.Address `datamod:"key"`
+	Balance  *big.Int
+	Memo     string
+	Position Point
+	History  []uint64
+}
@@ -18,9 +18,13 @@ var (
 	_ = codec.EncodeAddress
 )
 
-var (
-	KeyedTableDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeyedTable"))
-)
+// var (
+//	KeyedTableDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeyedTable"))
+// )
+
+func KeyedTableDefaultKey() []byte {
+	return crypto.Keccak256([]byte("datamod.v1.KeyedTable"))
+}
 
 type KeyedTableRow struct {
 	lib.DatastoreStruct
@@ -142,7 +146,7 @@ type KeyedTable struct {
 }
 
 func NewKeyedTable(ds lib.Datastore) *KeyedTable {
-	dsSlot := ds.Get(KeyedTableDefaultKey)
+	dsSlot := ds.Get(KeyedTableDefaultKey())
 	return &KeyedTable{dsSlot}
 }
 
@@ -18,9 +18,13 @@ var (
 	_ = codec.EncodeAddress
 )
 
-var (
-	KeyedWithKeyedTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeyedWithKeyedTableValue"))
-)
+// var (
+//	KeyedWithKeyedTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeyedWithKeyedTableValue"))
+// )
+
+func KeyedWithKeyedTableValueDefaultKey() []byte {
+	return crypto.Keccak256([]byte("datamod.v1.KeyedWithKeyedTableValue"))
+}
 
 type KeyedWithKeyedTableValueRow struct {
 	lib.DatastoreStruct
@@ -34,7 +38,7 @@ func NewKeyedWithKeyedTableValueRow(dsSlot lib.DatastoreSlot) *KeyedWithKeyedTab
 func (v *KeyedWithKeyedTableValueRow) Get() (
 	*KeyedTable,
 ) {
-	return NewKeyedTableFromSlot(v.GetField_slot(0))
+	return v.GetValueTable()
 }
 
 func (v *KeyedWithKeyedTableValueRow) Set(
@@ -42,7 +46,7 @@ func (v *KeyedWithKeyedTableValueRow) Set(
 }
 
 func (v *KeyedWithKeyedTableValueRow) GetValueTable() *KeyedTable {
-	dsSlot := v.GetField_slot(0)
+	dsSlot := lib.NestedTableSlot(v.GetField_slot(0))
 	return NewKeyedTableFromSlot(dsSlot)
 }
 
@@ -51,7 +55,7 @@ type KeyedWithKeyedTableValue struct {
 }
 
 func NewKeyedWithKeyedTableValue(ds lib.Datastore) *KeyedWithKeyedTableValue {
-	dsSlot := ds.Get(KeyedWithKeyedTableValueDefaultKey)
+	dsSlot := ds.Get(KeyedWithKeyedTableValueDefaultKey())
 	return &KeyedWithKeyedTableValue{dsSlot}
 }
 
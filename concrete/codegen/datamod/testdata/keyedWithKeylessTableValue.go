@@ -18,9 +18,13 @@ var (
 	_ = codec.EncodeAddress
 )
 
-var (
-	KeyedWithKeylessTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeyedWithKeylessTableValue"))
-)
+// var (
+//	KeyedWithKeylessTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeyedWithKeylessTableValue"))
+// )
+
+func KeyedWithKeylessTableValueDefaultKey() []byte {
+	return crypto.Keccak256([]byte("datamod.v1.KeyedWithKeylessTableValue"))
+}
 
 type KeyedWithKeylessTableValueRow struct {
 	lib.DatastoreStruct
@@ -34,7 +38,7 @@ func NewKeyedWithKeylessTableValueRow(dsSlot lib.DatastoreSlot) *KeyedWithKeyles
 func (v *KeyedWithKeylessTableValueRow) Get() (
 	*KeylessTable,
 ) {
-	return NewKeylessTableFromSlot(v.GetField_slot(0))
+	return v.GetValueTable()
 }
 
 func (v *KeyedWithKeylessTableValueRow) Set(
@@ -42,7 +46,7 @@ func (v *KeyedWithKeylessTableValueRow) Set(
 }
 
 func (v *KeyedWithKeylessTableValueRow) GetValueTable() *KeylessTable {
-	dsSlot := v.GetField_slot(0)
+	dsSlot := lib.NestedTableSlot(v.GetField_slot(0))
 	return NewKeylessTableFromSlot(dsSlot)
 }
 
@@ -51,7 +55,7 @@ type KeyedWithKeylessTableValue struct {
 }
 
 func NewKeyedWithKeylessTableValue(ds lib.Datastore) *KeyedWithKeylessTableValue {
-	dsSlot := ds.Get(KeyedWithKeylessTableValueDefaultKey)
+	dsSlot := ds.Get(KeyedWithKeylessTableValueDefaultKey())
 	return &KeyedWithKeylessTableValue{dsSlot}
 }
 
@@ -18,9 +18,13 @@ var (
 	_ = codec.EncodeAddress
 )
 
-var (
-	KeylessTableDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeylessTable"))
-)
+// var (
+//	KeylessTableDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeylessTable"))
+// )
+
+func KeylessTableDefaultKey() []byte {
+	return crypto.Keccak256([]byte("datamod.v1.KeylessTable"))
+}
 
 type KeylessTableRow struct {
 	lib.DatastoreStruct
@@ -140,7 +144,7 @@ func (v *KeylessTableRow) SetValueBytes16(value []byte) {
 type KeylessTable = KeylessTableRow
 
 func NewKeylessTable(ds lib.Datastore) *KeylessTableRow {
-	dsSlot := ds.Get(KeylessTableDefaultKey)
+	dsSlot := ds.Get(KeylessTableDefaultKey())
 	return NewKeylessTableRow(dsSlot)
 }
 
@@ -18,9 +18,13 @@ var (
 	_ = codec.EncodeAddress
 )
 
-var (
-	KeylessWithKeyedTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeylessWithKeyedTableValue"))
-)
+// var (
+//	KeylessWithKeyedTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeylessWithKeyedTableValue"))
+// )
+
+func KeylessWithKeyedTableValueDefaultKey() []byte {
+	return crypto.Keccak256([]byte("datamod.v1.KeylessWithKeyedTableValue"))
+}
 
 type KeylessWithKeyedTableValueRow struct {
 	lib.DatastoreStruct
@@ -34,7 +38,7 @@ func NewKeylessWithKeyedTableValueRow(dsSlot lib.DatastoreSlot) *KeylessWithKeye
 func (v *KeylessWithKeyedTableValueRow) Get() (
 	*KeyedTable,
 ) {
-	return NewKeyedTableFromSlot(v.GetField_slot(0))
+	return v.GetValueTable()
 }
 
 func (v *KeylessWithKeyedTableValueRow) Set(
@@ -42,14 +46,14 @@ func (v *KeylessWithKeyedTableValueRow) Set(
 }
 
 func (v *KeylessWithKeyedTableValueRow) GetValueTable() *KeyedTable {
-	dsSlot := v.GetField_slot(0)
+	dsSlot := lib.NestedTableSlot(v.GetField_slot(0))
 	return NewKeyedTableFromSlot(dsSlot)
 }
 
 type KeylessWithKeyedTableValue = KeylessWithKeyedTableValueRow
 
 func NewKeylessWithKeyedTableValue(ds lib.Datastore) *KeylessWithKeyedTableValueRow {
-	dsSlot := ds.Get(KeylessWithKeyedTableValueDefaultKey)
+	dsSlot := ds.Get(KeylessWithKeyedTableValueDefaultKey())
 	return NewKeylessWithKeyedTableValueRow(dsSlot)
 }
 
@@ -18,9 +18,13 @@ var (
 	_ = codec.EncodeAddress
 )
 
-var (
-	KeylessWithKeylessTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeylessWithKeylessTableValue"))
-)
+// var (
+//	KeylessWithKeylessTableValueDefaultKey = crypto.Keccak256([]byte("datamod.v1.KeylessWithKeylessTableValue"))
+// )
+
+func KeylessWithKeylessTableValueDefaultKey() []byte {
+	return crypto.Keccak256([]byte("datamod.v1.KeylessWithKeylessTableValue"))
+}
 
 type KeylessWithKeylessTableValueRow struct {
 	lib.DatastoreStruct
@@ -34,7 +38,7 @@ func NewKeylessWithKeylessTableValueRow(dsSlot lib.DatastoreSlot) *KeylessWithKe
 func (v *KeylessWithKeylessTableValueRow) Get() (
 	*KeylessTable,
 ) {
-	return NewKeylessTableFromSlot(v.GetField_slot(0))
+	return v.GetValueTable()
 }
 
 func (v *KeylessWithKeylessTableValueRow) Set(
@@ -42,14 +46,14 @@ func (v *KeylessWithKeylessTableValueRow) Set(
 }
 
 func (v *KeylessWithKeylessTableValueRow) GetValueTable() *KeylessTable {
-	dsSlot := v.GetField_slot(0)
+	dsSlot := lib.NestedTableSlot(v.GetField_slot(0))
 	return NewKeylessTableFromSlot(dsSlot)
 }
 
 type KeylessWithKeylessTableValue = KeylessWithKeylessTableValueRow
 
 func NewKeylessWithKeylessTableValue(ds lib.Datastore) *KeylessWithKeylessTableValueRow {
-	dsSlot := ds.Get(KeylessWithKeylessTableValueDefaultKey)
+	dsSlot := ds.Get(KeylessWithKeylessTableValueDefaultKey())
 	return NewKeylessWithKeylessTableValueRow(dsSlot)
 }
 
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: MIT
+pragma solidity >=0.8.0;
+
+/* Autogenerated file. Do not edit manually. */
+
+import "./DatamodStorage.sol";
+import "./DatamodTypes.sol";
+
+// Reads are served from the storage of the precompile. Writes call methods of
+// the precompile, which it must implement: keyedTable_set<Field> for each field
+// and keyedTable_set for whole rows.
+library KeyedTable {
+    address constant precompileAddress = address(0x0000000000000000000000000000000000000080);
+    bytes32 constant defaultSlot = keccak256("datamod.v1.KeyedTable");
+
+    function rowSlotAt(bytes32 tableSlot, uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal pure returns (bytes32 slot) {
+        slot = tableSlot;
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyUint));
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyInt));
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyString));
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyBytes));
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyBool));
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyAddress));
+        slot = DatamodStorage.mappingSlot(slot, abi.encodePacked(keyBytes16));
+    }
+
+    function rowSlot(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal pure returns (bytes32) {
+        return rowSlotAt(defaultSlot, keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16);
+    }
+
+    function getValueUintAt(bytes32 row) internal view returns (uint256) {
+        bytes32 word = DatamodStorage.load(precompileAddress, DatamodStorage.offsetSlot(row, 0));
+        return uint256(word);
+    }
+
+    function getValueUint(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (uint256) {
+        return getValueUintAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getValueIntAt(bytes32 row) internal view returns (int256) {
+        bytes32 word = DatamodStorage.load(precompileAddress, DatamodStorage.offsetSlot(row, 1));
+        return int256(uint256(word));
+    }
+
+    function getValueInt(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (int256) {
+        return getValueIntAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getValueStringAt(bytes32 row) internal view returns (string memory) {
+        bytes32 slot = DatamodStorage.offsetSlot(row, 2);
+        return string(DatamodStorage.loadBytes(precompileAddress, slot));
+    }
+
+    function getValueString(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (string memory) {
+        return getValueStringAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getValueBytesAt(bytes32 row) internal view returns (bytes memory) {
+        bytes32 slot = DatamodStorage.offsetSlot(row, 3);
+        return DatamodStorage.loadBytes(precompileAddress, slot);
+    }
+
+    function getValueBytes(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (bytes memory) {
+        return getValueBytesAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getValueBoolAt(bytes32 row) internal view returns (bool) {
+        bytes32 word = DatamodStorage.load(precompileAddress, DatamodStorage.offsetSlot(row, 4));
+        return (uint8(bytes1(word)) & 1) == 1;
+    }
+
+    function getValueBool(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (bool) {
+        return getValueBoolAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getValueAddressAt(bytes32 row) internal view returns (address) {
+        bytes32 word = DatamodStorage.load(precompileAddress, DatamodStorage.offsetSlot(row, 4));
+        return address(bytes20(DatamodStorage.field(word, 1)));
+    }
+
+    function getValueAddress(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (address) {
+        return getValueAddressAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getValueBytes16At(bytes32 row) internal view returns (bytes16) {
+        bytes32 word = DatamodStorage.load(precompileAddress, DatamodStorage.offsetSlot(row, 5));
+        return bytes16(word);
+    }
+
+    function getValueBytes16(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (bytes16) {
+        return getValueBytes16At(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function getAt(bytes32 row) internal view returns (uint256 valueUint, int256 valueInt, string memory valueString, bytes memory valueBytes, bool valueBool, address valueAddress, bytes16 valueBytes16) {
+        valueUint = getValueUintAt(row);
+        valueInt = getValueIntAt(row);
+        valueString = getValueStringAt(row);
+        valueBytes = getValueBytesAt(row);
+        valueBool = getValueBoolAt(row);
+        valueAddress = getValueAddressAt(row);
+        valueBytes16 = getValueBytes16At(row);
+    }
+
+    function get(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16) internal view returns (uint256, int256, string memory, bytes memory, bool, address, bytes16) {
+        return getAt(rowSlot(keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16));
+    }
+
+    function setValueUint(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, uint256 value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueUint(uint256,int256,string,bytes,bool,address,bytes16,uint256)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function setValueInt(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, int256 value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueInt(uint256,int256,string,bytes,bool,address,bytes16,int256)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function setValueString(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, string memory value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueString(uint256,int256,string,bytes,bool,address,bytes16,string)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function setValueBytes(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, bytes memory value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueBytes(uint256,int256,string,bytes,bool,address,bytes16,bytes)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function setValueBool(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, bool value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueBool(uint256,int256,string,bytes,bool,address,bytes16,bool)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function setValueAddress(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, address value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueAddress(uint256,int256,string,bytes,bool,address,bytes16,address)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function setValueBytes16(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, bytes16 value) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_setValueBytes16(uint256,int256,string,bytes,bool,address,bytes16,bytes16)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, value));
+    }
+
+    function set(uint256 keyUint, int256 keyInt, string memory keyString, bytes memory keyBytes, bool keyBool, address keyAddress, bytes16 keyBytes16, uint256 valueUint, int256 valueInt, string memory valueString, bytes memory valueBytes, bool valueBool, address valueAddress, bytes16 valueBytes16) internal {
+        callPrecompile(abi.encodeWithSignature("keyedTable_set(uint256,int256,string,bytes,bool,address,bytes16,uint256,int256,string,bytes,bool,address,bytes16)", keyUint, keyInt, keyString, keyBytes, keyBool, keyAddress, keyBytes16, valueUint, valueInt, valueString, valueBytes, valueBool, valueAddress, valueBytes16));
+    }
+
+    function callPrecompile(bytes memory data) private {
+        (bool success, ) = precompileAddress.call(data);
+        require(success);
+    }
+}
@@ -0,0 +1,213 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package datamod
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iancoleman/orderedmap"
+)
+
+const maxEnumValues = 256
+
+type EnumSchema struct {
+	Name   string
+	Values []string
+}
+
+type StructSchema struct {
+	Name   string
+	Fields []FieldSchema
+}
+
+// typeDefs holds the enum and struct definitions of a schema, which table,
+// struct and array fields refer to by name.
+type typeDefs struct {
+	enums      map[string]*EnumSchema
+	structs    map[string]orderedmap.OrderedMap
+	enumList   []*EnumSchema
+	structList []string
+}
+
+func isTypeDef(jsonSchema orderedmap.OrderedMap) bool {
+	_, isEnum := jsonSchema.Get("enum")
+	_, isStruct := jsonSchema.Get("struct")
+	return isEnum || isStruct
+}
+
+func unmarshalTypeDefs(jsonSchemas *orderedmap.OrderedMap) (*typeDefs, error) {
+	defs := &typeDefs{
+		enums:   make(map[string]*EnumSchema),
+		structs: make(map[string]orderedmap.OrderedMap),
+	}
+	for _, name := range jsonSchemas.Keys() {
+		_jsonSchema, _ := jsonSchemas.Get(name)
+		jsonSchema, ok := _jsonSchema.(orderedmap.OrderedMap)
+		if !ok || !isTypeDef(jsonSchema) {
+			continue
+		}
+		if !isValidName(name) {
+			return nil, fmt.Errorf("invalid type name '%s'", name)
+		}
+		if len(jsonSchema.Keys()) != 1 {
+			return nil, fmt.Errorf("invalid definition for type '%s'", name)
+		}
+		typeName := upperFirstLetter(name)
+
+		if _jsonValues, ok := jsonSchema.Get("enum"); ok {
+			jsonValues, ok := _jsonValues.([]interface{})
+			if !ok || len(jsonValues) == 0 {
+				return nil, fmt.Errorf("invalid values for enum '%s'", name)
+			}
+			if len(jsonValues) > maxEnumValues {
+				return nil, fmt.Errorf("enum '%s' has more than %d values", name, maxEnumValues)
+			}
+			enum := &EnumSchema{Name: typeName}
+			seen := make(map[string]bool)
+			for _, _value := range jsonValues {
+				value, ok := _value.(string)
+				if !ok || !isValidName(value) {
+					return nil, fmt.Errorf("invalid value '%v' for enum '%s'", _value, name)
+				}
+				value = upperFirstLetter(value)
+				if seen[value] {
+					return nil, fmt.Errorf("duplicate value '%s' for enum '%s'", value, name)
+				}
+				seen[value] = true
+				enum.Values = append(enum.Values, value)
+			}
+			defs.enums[name] = enum
+			defs.enumList = append(defs.enumList, enum)
+			continue
+		}
+
+		_jsonFields, _ := jsonSchema.Get("struct")
+		jsonFields, ok := _jsonFields.(orderedmap.OrderedMap)
+		if !ok || len(jsonFields.Keys()) == 0 {
+			return nil, fmt.Errorf("invalid fields for struct '%s'", name)
+		}
+		defs.structs[name] = jsonFields
+		defs.structList = append(defs.structList, name)
+	}
+	return defs, nil
+}
+
+// resolveField fills in the enum and struct details of a field. Struct
+// fields get a fresh copy of their child fields, so each use of a struct can
+// be laid out in its parent row independently.
+func (d *typeDefs) resolveField(field *FieldSchema, stack []string) error {
+	fieldType := &field.Type
+	if fieldType.Type == ArrayType {
+		elem := *fieldType.Elem
+		fieldType.Elem = &elem
+		fieldType = fieldType.Elem
+	}
+	if fieldType.Enum != nil {
+		enum, ok := d.enums[fieldType.Name]
+		if !ok {
+			return fmt.Errorf("enum '%s' is not defined", fieldType.Name)
+		}
+		fieldType.Enum = enum
+		return nil
+	}
+	if fieldType.Type == StructType {
+		fields, err := d.resolveStruct(fieldType.Name, stack)
+		if err != nil {
+			return err
+		}
+		field.Fields = fields
+	}
+	return nil
+}
+
+func (d *typeDefs) resolveStruct(name string, stack []string) ([]FieldSchema, error) {
+	jsonFields, ok := d.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("struct '%s' is not defined", name)
+	}
+	for ii, parent := range stack {
+		if parent == name {
+			cycle := append(stack[ii:len(stack):len(stack)], name)
+			return nil, fmt.Errorf("struct '%s' is recursive: %s", name, strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack, name)
+
+	var fields []FieldSchema
+	for _, fieldName := range jsonFields.Keys() {
+		_fieldType, _ := jsonFields.Get(fieldName)
+		fieldType, ok := _fieldType.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid schema for field '%s' in struct '%s'", fieldName, name)
+		}
+		field, err := newFieldSchema(fieldName, 0, fieldType)
+		if err != nil {
+			return nil, err
+		}
+		if field.Type.Type == TableType {
+			return nil, fmt.Errorf("struct '%s' cannot have table fields", name)
+		}
+		if err := d.resolveField(&field, stack); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// structSchemas resolves every struct definition, which also reports
+// recursive definitions that are not used by any table.
+func (d *typeDefs) structSchemas() ([]StructSchema, error) {
+	var schemas []StructSchema
+	for _, name := range d.structList {
+		fields, err := d.resolveStruct(name, nil)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, StructSchema{Name: upperFirstLetter(name), Fields: fields})
+	}
+	return schemas, nil
+}
+
+// layoutFields assigns row indices to the fields of a table. Struct fields
+// are flattened, so their leaf fields are packed into the parent row.
+func layoutFields(fields []FieldSchema, pathPrefix string, nextIndex *int) {
+	for ii := range fields {
+		field := &fields[ii]
+		field.Path = pathPrefix + field.Title
+		field.Index = *nextIndex
+		if field.Type.Type == StructType {
+			layoutFields(field.Fields, field.Path, nextIndex)
+		} else {
+			*nextIndex++
+		}
+	}
+}
+
+func appendFields(fields []FieldSchema, all []FieldSchema, leavesOnly bool) []FieldSchema {
+	for _, field := range fields {
+		if field.Type.Type == StructType {
+			if !leavesOnly {
+				all = append(all, field)
+			}
+			all = appendFields(field.Fields, all, leavesOnly)
+		} else {
+			all = append(all, field)
+		}
+	}
+	return all
+}
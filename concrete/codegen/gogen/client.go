@@ -0,0 +1,136 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package gogen
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/concrete/codegen/solgen"
+)
+
+//go:embed client.tpl
+var clientTpl string
+
+type clientField struct {
+	Name string
+	Type string
+}
+
+type clientError struct {
+	Name    string
+	RawName string
+	Sig     string
+	Fields  []clientField
+}
+
+// readABIJSON returns the ABI of a file holding either the ABI or a build
+// artifact.
+func readABIJSON(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var artifact struct {
+		ABI json.RawMessage `json:"abi"`
+	}
+	if err := json.Unmarshal(content, &artifact); err == nil && len(artifact.ABI) > 0 {
+		return string(artifact.ABI), nil
+	}
+	return string(content), nil
+}
+
+func generateClient(ABI abi.ABI, abiJSON string, config Config) (string, error) {
+	if !isValidName(config.Name) {
+		return "", fmt.Errorf("invalid name: '%s'", config.Name)
+	}
+	if !isValidName(config.Package) {
+		return "", fmt.Errorf("invalid package name: '%s'", config.Package)
+	}
+
+	// The contract binding is generated as done by abigen, without bytecode
+	// as precompiles are not deployed.
+	code, err := bind.Bind([]string{config.Name}, []string{abiJSON}, []string{""}, nil, config.Package, bind.LangGo, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(ABI.Errors))
+	for name := range ABI.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := make([]clientError, 0, len(names))
+	for _, name := range names {
+		abiErr := ABI.Errors[name]
+		clientErr := clientError{Name: abi.ToCamelCase(abiErr.Name), RawName: abiErr.Name, Sig: abiErr.Sig}
+		for _, input := range abiErr.Inputs {
+			clientErr.Fields = append(clientErr.Fields, clientField{
+				Name: abi.ToCamelCase(input.Name),
+				Type: input.Type.GetType().String(),
+			})
+		}
+		errs = append(errs, clientErr)
+	}
+
+	tpl, err := template.New("client").Parse(clientTpl)
+	if err != nil {
+		return "", err
+	}
+	data := map[string]interface{}{
+		"Type":    config.Name,
+		"Backend": strings.ToLower(config.Name[:1]) + config.Name[1:] + "Backend",
+		"Address": config.Address.Hex(),
+		"Errors":  errs,
+	}
+	var buf bytes.Buffer
+	buf.WriteString(strings.Replace(code, "import (\n", "import (\n\t\"context\"\n", 1))
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// GenerateClient writes Go bindings calling the precompile at the configured
+// address, as generated by abigen, along with its custom errors.
+func GenerateClient(config Config) error {
+	ABI, _, err := solgen.GetABI(config.ABI)
+	if err != nil {
+		return err
+	}
+	abiJSON, err := readABIJSON(config.ABI)
+	if err != nil {
+		return err
+	}
+	code, err := generateClient(ABI, abiJSON, config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.Out, []byte(code), 0644)
+}
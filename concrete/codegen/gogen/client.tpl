@@ -0,0 +1,88 @@
+
+// {{.Type}}Address is the address of the {{.Type}} precompile.
+var {{.Type}}Address = common.HexToAddress("{{.Address}}")
+
+// New{{.Type}}Precompile creates a new instance of {{.Type}}, bound to the
+// precompile address.
+func New{{.Type}}Precompile(backend bind.ContractBackend) (*{{.Type}}, error) {
+	return New{{.Type}}({{.Type}}Address, &{{.Backend}}{backend})
+}
+
+// {{.Backend}} reports code at the precompile address, as bound contracts
+// refuse to estimate gas for and decode empty results from addresses without
+// code.
+type {{.Backend}} struct {
+	bind.ContractBackend
+}
+
+func (b *{{.Backend}}) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if account == {{.Type}}Address {
+		return []byte{0x00}, nil
+	}
+	return b.ContractBackend.CodeAt(ctx, account, blockNumber)
+}
+
+func (b *{{.Backend}}) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	if account == {{.Type}}Address {
+		return []byte{0x00}, nil
+	}
+	return b.ContractBackend.PendingCodeAt(ctx, account)
+}
+{{range .Errors}}
+// {{$.Type}}{{.Name}}Error is the {{.Sig}} custom error of the precompile.
+{{- if .Fields}}
+type {{$.Type}}{{.Name}}Error struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{- else}}
+type {{$.Type}}{{.Name}}Error struct{}
+{{- end}}
+
+func (e *{{$.Type}}{{.Name}}Error) Error() string {
+	return "execution reverted: {{.Name}}"
+}
+{{end}}
+// Unpack{{.Type}}Error returns the custom error the precompile reverted with, if
+// err holds revert data matching one. Other errors are returned as is.
+func Unpack{{.Type}}Error(err error) error {
+{{- if .Errors}}
+	var dataErr interface{ ErrorData() interface{} }
+	if !errors.As(err, &dataErr) {
+		return err
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data := common.FromHex(hexData)
+	if len(data) < 4 {
+		return err
+	}
+	parsed, parseErr := {{.Type}}MetaData.GetAbi()
+	if parseErr != nil {
+		return err
+	}
+	var id [4]byte
+	copy(id[:], data)
+	abiErr, idErr := parsed.ErrorByID(id)
+	if idErr != nil {
+		return err
+	}
+	switch abiErr.Name {
+{{- range .Errors}}
+	case "{{.RawName}}":
+		decoded := new({{$.Type}}{{.Name}}Error)
+		{{- if .Fields}}
+		values, unpackErr := abiErr.Inputs.Unpack(data[4:])
+		if unpackErr != nil || abiErr.Inputs.Copy(decoded, values) != nil {
+			return err
+		}
+		{{- end}}
+		return decoded
+{{- end}}
+	}
+{{- end}}
+	return err
+}
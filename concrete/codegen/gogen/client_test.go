@@ -0,0 +1,195 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package gogen
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/codegen/gogen/testdata"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/core"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateClient(t *testing.T) {
+	r := require.New(t)
+	out := filepath.Join(t.TempDir(), "token_client.go")
+	err := GenerateClient(Config{
+		Name:    "TokenClient",
+		Package: "testdata",
+		ABI:     "testdata/token.json",
+		Out:     out,
+		Address: common.HexToAddress("0x80"),
+	})
+	r.NoError(err)
+
+	code, err := os.ReadFile(out)
+	r.NoError(err)
+	expected, err := os.ReadFile("testdata/token_client.go")
+	r.NoError(err)
+	r.Equal(string(expected), string(code), "testdata/token_client.go is outdated")
+}
+
+var (
+	transferEventID            = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	insufficientBalanceErrorID = crypto.Keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4]
+	unauthorizedErrorID        = crypto.Keccak256([]byte("Unauthorized()"))[:4]
+	errTestUnauthorized        = errors.New("unauthorized")
+)
+
+type testToken struct {
+	owner common.Address
+}
+
+func (t *testToken) balance(env api.Environment, owner common.Address) lib.DatastoreSlot {
+	return lib.NewDatastore(env).Get(owner.Bytes())
+}
+
+func (t *testToken) transfer(env api.Environment, from common.Address, to common.Address, amount *big.Int) {
+	t.balance(env, from).SetBigUint(new(big.Int).Sub(t.balance(env, from).BigUint(), amount))
+	t.balance(env, to).SetBigUint(new(big.Int).Add(t.balance(env, to).BigUint(), amount))
+	env.Log([]common.Hash{transferEventID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())}, common.BigToHash(amount).Bytes())
+}
+
+func (t *testToken) BalanceOf(env api.Environment, owner common.Address) (*big.Int, error) {
+	return t.balance(env, owner).BigUint(), nil
+}
+
+func (t *testToken) Mint(env api.Environment, to common.Address, amount *big.Int) error {
+	if env.GetCaller() != t.owner {
+		return errTestUnauthorized
+	}
+	t.balance(env, common.Address{}).SetBigUint(amount)
+	t.transfer(env, common.Address{}, to, amount)
+	return nil
+}
+
+func (t *testToken) Transfer(env api.Environment, to common.Address, amount *big.Int) (bool, error) {
+	from := env.GetCaller()
+	if balance := t.balance(env, from).BigUint(); balance.Cmp(amount) < 0 {
+		enc := lib.NewABIEncoder()
+		enc.BigUint(balance)
+		enc.BigUint(amount)
+		return false, &revertError{data: append(common.CopyBytes(insufficientBalanceErrorID), enc.Encode()...)}
+	}
+	t.transfer(env, from, to, amount)
+	return true, nil
+}
+
+type revertError struct {
+	data []byte
+}
+
+func (e *revertError) Error() string {
+	return "reverted"
+}
+
+// tokenPrecompile reverts with the custom errors of the token, as the adapter
+// does not return data on errors.
+type tokenPrecompile struct {
+	*testdata.TokenPrecompile
+}
+
+func (p *tokenPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+	output, err := p.TokenPrecompile.Run(env, input)
+	var revertErr *revertError
+	if errors.As(err, &revertErr) {
+		return revertErr.data, err
+	}
+	if errors.Is(err, errTestUnauthorized) {
+		return unauthorizedErrorID, err
+	}
+	return output, err
+}
+
+func TestClient(t *testing.T) {
+	var (
+		r          = require.New(t)
+		key, _     = ethcrypto.GenerateKey()
+		other, _   = ethcrypto.GenerateKey()
+		owner      = ethcrypto.PubkeyToAddress(key.PublicKey)
+		otherAddr  = ethcrypto.PubkeyToAddress(other.PublicKey)
+		registry   = concrete.NewRegistry()
+		balance, _ = new(big.Int).SetString("1000000000000000000000", 10)
+	)
+	registry.AddPrecompile(0, testdata.TokenClientAddress, &tokenPrecompile{testdata.NewTokenPrecompile(&testToken{owner: owner})})
+	backend := backends.NewSimulatedBackendWithOpts(
+		backends.WithAlloc(core.GenesisAlloc{owner: {Balance: balance}, otherAddr: {Balance: balance}}),
+		backends.WithConcrete(registry),
+	)
+	defer backend.Close()
+
+	client, err := testdata.NewTokenClientPrecompile(backend)
+	r.NoError(err)
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	r.NoError(err)
+	otherOpts, err := bind.NewKeyedTransactorWithChainID(other, big.NewInt(1337))
+	r.NoError(err)
+
+	_, err = client.Mint(opts, owner, big.NewInt(100))
+	r.NoError(err)
+	backend.Commit()
+	tx, err := client.Transfer(opts, otherAddr, big.NewInt(30))
+	r.NoError(err)
+	backend.Commit()
+
+	receipt, err := backend.TransactionReceipt(nil, tx.Hash())
+	r.NoError(err)
+	r.Equal(uint64(1), receipt.Status)
+	ownerBalance, err := client.BalanceOf(nil, owner)
+	r.NoError(err)
+	r.Equal(big.NewInt(70), ownerBalance)
+	otherBalance, err := client.BalanceOf(nil, otherAddr)
+	r.NoError(err)
+	r.Equal(big.NewInt(30), otherBalance)
+
+	// Events are filtered as for contracts.
+	it, err := client.FilterTransfer(nil, []common.Address{owner}, nil)
+	r.NoError(err)
+	r.True(it.Next())
+	r.Equal(owner, it.Event.From)
+	r.Equal(otherAddr, it.Event.To)
+	r.Equal(big.NewInt(30), it.Event.Amount)
+	r.False(it.Next())
+	r.NoError(it.Close())
+
+	// Custom errors are decoded from the revert data.
+	_, err = client.Transfer(opts, otherAddr, big.NewInt(1000))
+	r.Error(err)
+	var balanceErr *testdata.TokenClientInsufficientBalanceError
+	r.ErrorAs(testdata.UnpackTokenClientError(err), &balanceErr)
+	r.Equal(big.NewInt(70), balanceErr.Available)
+	r.Equal(big.NewInt(1000), balanceErr.Required)
+
+	_, err = client.Mint(otherOpts, otherAddr, big.NewInt(1))
+	r.Error(err)
+	var unauthorizedErr *testdata.TokenClientUnauthorizedError
+	r.ErrorAs(testdata.UnpackTokenClientError(err), &unauthorizedErr)
+
+	otherErr := errors.New("other")
+	r.Equal(otherErr, testdata.UnpackTokenClientError(otherErr))
+}
@@ -0,0 +1,340 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package gogen
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/codegen/solgen"
+)
+
+//go:embed gogen.tpl
+var gogenTpl string
+
+type Config struct {
+	Name    string
+	Package string
+	ABI     string
+	Out     string
+	// Address is the address of the precompile, used by client bindings.
+	Address common.Address
+}
+
+type goMethod struct {
+	Name      string
+	Signature string
+	ID        string
+	IsStatic  bool
+	// Params and Results are the parameters and results of the interface
+	// method, excluding the environment and the error.
+	Params  string
+	Results string
+	Args    string
+	Outs    string
+	// Decode and Encode are the statements decoding the arguments into
+	// arg<i> and encoding the results from out<i>.
+	Decode string
+	Encode string
+}
+
+func isValidName(name string) bool {
+	return token.IsIdentifier(name) && !token.IsKeyword(name) && name != "_"
+}
+
+// goType returns the Go type of an ABI type. Standard sized integers are
+// mapped to native types and others to *big.Int, as done by accounts/abi.
+func goType(t abi.Type) (string, error) {
+	switch t.T {
+	case abi.UintTy, abi.IntTy:
+		prefix := "int"
+		if t.T == abi.UintTy {
+			prefix = "uint"
+		}
+		switch t.Size {
+		case 8, 16, 32, 64:
+			return fmt.Sprintf("%s%d", prefix, t.Size), nil
+		}
+		return "*big.Int", nil
+	case abi.BoolTy:
+		return "bool", nil
+	case abi.StringTy:
+		return "string", nil
+	case abi.BytesTy:
+		return "[]byte", nil
+	case abi.AddressTy:
+		return "common.Address", nil
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case abi.SliceTy, abi.ArrayTy:
+		elem, err := goType(*t.Elem)
+		if err != nil {
+			return "", err
+		}
+		if t.T == abi.SliceTy {
+			return "[]" + elem, nil
+		}
+		return fmt.Sprintf("[%d]%s", t.Size, elem), nil
+	}
+	return "", fmt.Errorf("unsupported abi type: %s", t.String())
+}
+
+func isDynamic(t abi.Type) bool {
+	switch t.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy:
+		return true
+	case abi.ArrayTy:
+		return isDynamic(*t.Elem)
+	}
+	return false
+}
+
+// codeWriter builds the decoding and encoding statements of nested values,
+// using depth to name the loop variables and nested decoders and encoders.
+type codeWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *codeWriter) line(format string, args ...interface{}) {
+	fmt.Fprintf(&w.buf, format+"\n", args...)
+}
+
+func (w *codeWriter) decode(t abi.Type, dec string, target string, assign string, depth int) {
+	switch t.T {
+	case abi.UintTy, abi.IntTy:
+		native, _ := goType(t)
+		switch {
+		case native == "*big.Int" && t.T == abi.UintTy:
+			w.line("%s %s %s.BigUint(%d)", target, assign, dec, t.Size)
+		case native == "*big.Int":
+			w.line("%s %s %s.BigInt(%d)", target, assign, dec, t.Size)
+		case native == "uint64":
+			w.line("%s %s %s.Uint(64)", target, assign, dec)
+		case native == "int64":
+			w.line("%s %s %s.Int(64)", target, assign, dec)
+		case t.T == abi.UintTy:
+			w.line("%s %s %s(%s.Uint(%d))", target, assign, native, dec, t.Size)
+		default:
+			w.line("%s %s %s(%s.Int(%d))", target, assign, native, dec, t.Size)
+		}
+	case abi.BoolTy:
+		w.line("%s %s %s.Bool()", target, assign, dec)
+	case abi.StringTy:
+		w.line("%s %s %s.String()", target, assign, dec)
+	case abi.BytesTy:
+		w.line("%s %s %s.Bytes()", target, assign, dec)
+	case abi.AddressTy:
+		w.line("%s %s %s.Address()", target, assign, dec)
+	case abi.FixedBytesTy:
+		w.line("copy(%s[:], %s.FixedBytes(%d))", target, dec, t.Size)
+	case abi.SliceTy:
+		elemType, _ := goType(*t.Elem)
+		length, elems, index := fmt.Sprintf("n%d", depth), fmt.Sprintf("dec%d", depth), fmt.Sprintf("i%d", depth)
+		w.line("{")
+		w.line("%s, %s := %s.DynamicArray()", length, elems, dec)
+		w.line("%s = make([]%s, %s)", target, elemType, length)
+		w.line("for %s := range %s {", index, target)
+		w.decode(*t.Elem, elems, fmt.Sprintf("%s[%s]", target, index), "=", depth+1)
+		w.line("}")
+		w.line("}")
+	case abi.ArrayTy:
+		index := fmt.Sprintf("i%d", depth)
+		if !isDynamic(*t.Elem) {
+			w.line("for %s := range %s {", index, target)
+			w.decode(*t.Elem, dec, fmt.Sprintf("%s[%s]", target, index), "=", depth+1)
+			w.line("}")
+			return
+		}
+		elems := fmt.Sprintf("dec%d", depth)
+		w.line("{")
+		w.line("%s := %s.Tuple()", elems, dec)
+		w.line("for %s := range %s {", index, target)
+		w.decode(*t.Elem, elems, fmt.Sprintf("%s[%s]", target, index), "=", depth+1)
+		w.line("}")
+		w.line("}")
+	}
+}
+
+func (w *codeWriter) encode(t abi.Type, enc string, value string, depth int) {
+	switch t.T {
+	case abi.UintTy, abi.IntTy:
+		native, _ := goType(t)
+		switch {
+		case native == "*big.Int" && t.T == abi.UintTy:
+			w.line("%s.BigUint(%s)", enc, value)
+		case native == "*big.Int":
+			w.line("%s.BigInt(%s)", enc, value)
+		case t.T == abi.UintTy:
+			w.line("%s.Uint(uint64(%s))", enc, value)
+		default:
+			w.line("%s.Int(int64(%s))", enc, value)
+		}
+	case abi.BoolTy:
+		w.line("%s.Bool(%s)", enc, value)
+	case abi.StringTy:
+		w.line("%s.String(%s)", enc, value)
+	case abi.BytesTy:
+		w.line("%s.Bytes(%s)", enc, value)
+	case abi.AddressTy:
+		w.line("%s.Address(%s)", enc, value)
+	case abi.FixedBytesTy:
+		w.line("%s.FixedBytes(%s[:])", enc, value)
+	case abi.SliceTy, abi.ArrayTy:
+		elem := fmt.Sprintf("v%d", depth)
+		if t.T == abi.ArrayTy && !isDynamic(*t.Elem) {
+			w.line("for _, %s := range %s {", elem, value)
+			w.encode(*t.Elem, enc, elem, depth+1)
+			w.line("}")
+			return
+		}
+		elems := fmt.Sprintf("enc%d", depth)
+		w.line("{")
+		w.line("%s := lib.NewABIEncoder()", elems)
+		w.line("for _, %s := range %s {", elem, value)
+		w.encode(*t.Elem, elems, elem, depth+1)
+		w.line("}")
+		if t.T == abi.SliceTy {
+			w.line("%s.DynamicArray(len(%s), %s)", enc, value, elems)
+		} else {
+			w.line("%s.Tuple(%s)", enc, elems)
+		}
+		w.line("}")
+	}
+}
+
+func newGoMethod(key string, method abi.Method) (*goMethod, error) {
+	m := &goMethod{
+		Name:      abi.ToCamelCase(key),
+		Signature: method.Sig,
+		IsStatic:  method.IsConstant(),
+	}
+	var ids []string
+	for _, b := range method.ID {
+		ids = append(ids, fmt.Sprintf("0x%02x", b))
+	}
+	m.ID = strings.Join(ids, ", ")
+
+	var params, args []string
+	names := make(map[string]bool)
+	decode := &codeWriter{}
+	for ii, input := range method.Inputs {
+		typ, err := goType(input.Type)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", method.Sig, err)
+		}
+		name := input.Name
+		if !isValidName(name) || name == "env" || names[name] {
+			name = fmt.Sprintf("arg%d", ii)
+		}
+		names[name] = true
+		arg := fmt.Sprintf("arg%d", ii)
+		params = append(params, name+" "+typ)
+		args = append(args, arg)
+		switch input.Type.T {
+		case abi.SliceTy, abi.ArrayTy, abi.FixedBytesTy:
+			decode.line("var %s %s", arg, typ)
+			decode.decode(input.Type, "dec", arg, "=", 0)
+		default:
+			decode.decode(input.Type, "dec", arg, ":=", 0)
+		}
+	}
+
+	var results, outs []string
+	encode := &codeWriter{}
+	for ii, output := range method.Outputs {
+		typ, err := goType(output.Type)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", method.Sig, err)
+		}
+		out := fmt.Sprintf("out%d", ii)
+		results = append(results, typ)
+		outs = append(outs, out)
+		encode.encode(output.Type, "enc", out, 0)
+	}
+
+	m.Params = strings.Join(params, ", ")
+	m.Results = strings.Join(results, ", ")
+	m.Args = strings.Join(args, ", ")
+	m.Outs = strings.Join(outs, ", ")
+	m.Decode = decode.buf.String()
+	m.Encode = encode.buf.String()
+	return m, nil
+}
+
+func generateGoStub(ABI abi.ABI, config Config) (string, error) {
+	if !isValidName(config.Name) {
+		return "", fmt.Errorf("invalid name: '%s'", config.Name)
+	}
+	if !isValidName(config.Package) {
+		return "", fmt.Errorf("invalid package name: '%s'", config.Package)
+	}
+
+	keys := make([]string, 0, len(ABI.Methods))
+	for key := range ABI.Methods {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	methods := make([]*goMethod, 0, len(keys))
+	for _, key := range keys {
+		method, err := newGoMethod(key, ABI.Methods[key])
+		if err != nil {
+			return "", err
+		}
+		methods = append(methods, method)
+	}
+
+	tpl, err := template.New("gogen").Parse(gogenTpl)
+	if err != nil {
+		return "", err
+	}
+	data := map[string]interface{}{
+		"Name":    config.Name,
+		"Package": config.Package,
+		"Methods": methods,
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(code), nil
+}
+
+// GenerateGoStub writes a Go interface for the methods of an ABI, and an
+// adapter serving it as a concrete precompile.
+func GenerateGoStub(config Config) error {
+	ABI, _, err := solgen.GetABI(config.ABI)
+	if err != nil {
+		return err
+	}
+	code, err := generateGoStub(ABI, config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.Out, []byte(code), 0644)
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package gogen
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/codegen/gogen/testdata"
+	"github.com/ethereum/go-ethereum/concrete/codegen/solgen"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGoStub(t *testing.T) {
+	r := require.New(t)
+	out := filepath.Join(t.TempDir(), "example.go")
+	err := GenerateGoStub(Config{Name: "Example", Package: "testdata", ABI: "testdata/example.json", Out: out})
+	r.NoError(err)
+
+	code, err := os.ReadFile(out)
+	r.NoError(err)
+	expected, err := os.ReadFile("testdata/example.go")
+	r.NoError(err)
+	r.Equal(string(expected), string(code), "testdata/example.go is outdated")
+}
+
+func TestGenerateGoStubErrors(t *testing.T) {
+	r := require.New(t)
+	ABI, _, err := solgen.GetABI("testdata/example.json")
+	r.NoError(err)
+	_, err = generateGoStub(ABI, Config{Name: "0", Package: "testdata"})
+	r.Error(err)
+	_, err = generateGoStub(ABI, Config{Name: "Example", Package: "type"})
+	r.Error(err)
+
+	ABI, err = abi.JSON(strings.NewReader(`[{"type":"function","name":"f","inputs":[{"name":"t","type":"tuple","components":[{"name":"a","type":"uint256"}]}],"outputs":[]}]`))
+	r.NoError(err)
+	_, err = generateGoStub(ABI, Config{Name: "Example", Package: "testdata"})
+	r.ErrorContains(err, "unsupported abi type")
+}
+
+type example struct {
+	store     map[[32]byte][]byte
+	finalised bool
+}
+
+func (e *example) Add(env api.Environment, x *big.Int, y *big.Int) (*big.Int, error) {
+	return new(big.Int).Add(x, y), nil
+}
+
+func (e *example) Concat(env api.Environment, parts []string, weights [3]uint8) (string, []uint16, error) {
+	lengths := make([]uint16, len(parts))
+	for ii, part := range parts {
+		lengths[ii] = uint16(len(part)) * uint16(weights[ii%3])
+	}
+	return strings.Join(parts, ""), lengths, nil
+}
+
+func (e *example) Echo(env api.Environment, x *big.Int) (*big.Int, error) {
+	return x, nil
+}
+
+func (e *example) Echo0(env api.Environment, x [32]byte) ([32]byte, error) {
+	return x, nil
+}
+
+func (e *example) Get(env api.Environment, key [32]byte) ([]byte, error) {
+	return e.store[key], nil
+}
+
+func (e *example) Matrix(env api.Environment, rows [][2]*big.Int, pair [2][]byte) ([][2]*big.Int, [2][]byte, error) {
+	for ii := range rows {
+		rows[ii][0], rows[ii][1] = rows[ii][1], rows[ii][0]
+	}
+	return rows, [2][]byte{pair[1], pair[0]}, nil
+}
+
+func (e *example) Ping(env api.Environment) error {
+	return errors.New("pong")
+}
+
+func (e *example) Set(env api.Environment, key [32]byte, value []byte) error {
+	e.store[key] = value
+	return nil
+}
+
+func (e *example) Transfer(env api.Environment, to common.Address, amount uint64, arg2 int32) (bool, error) {
+	return amount > 0 && arg2 < 0, nil
+}
+
+func (e *example) Finalise(env api.Environment) error {
+	e.finalised = true
+	return nil
+}
+
+func TestExamplePrecompile(t *testing.T) {
+	r := require.New(t)
+	ABI, _, err := solgen.GetABI("testdata/example.json")
+	r.NoError(err)
+	impl := &example{store: make(map[[32]byte][]byte)}
+	pc := testdata.NewExamplePrecompile(impl)
+
+	call := func(name string, args ...interface{}) []interface{} {
+		input, err := ABI.Pack(name, args...)
+		r.NoError(err)
+		output, err := pc.Run(nil, input)
+		r.NoError(err)
+		values, err := ABI.Unpack(name, output)
+		r.NoError(err)
+		return values
+	}
+
+	r.Equal([]interface{}{big.NewInt(3)}, call("add", big.NewInt(1), big.NewInt(2)))
+	r.Equal([]interface{}{big.NewInt(5)}, call("echo", big.NewInt(5)))
+	r.Equal([]interface{}{[32]byte{1}}, call("echo0", [32]byte{1}))
+
+	r.Empty(call("set", [32]byte{1}, []byte{1, 2, 3}))
+	r.Equal([]interface{}{[]byte{1, 2, 3}}, call("get", [32]byte{1}))
+	r.Equal([]interface{}{true}, call("transfer", common.Address{1}, uint64(1), int32(-1)))
+	r.Equal(
+		[]interface{}{"abcd", []uint16{1, 6}},
+		call("concat", []string{"a", "bcd"}, [3]uint8{1, 2, 3}),
+	)
+	r.Equal(
+		[]interface{}{[][2]*big.Int{{big.NewInt(1), big.NewInt(-1)}}, [2][]byte{{2}, {1}}},
+		call("matrix", [][2]*big.Int{{big.NewInt(-1), big.NewInt(1)}}, [2][]byte{{1}, {2}}),
+	)
+
+	input, err := ABI.Pack("ping")
+	r.NoError(err)
+	_, err = pc.Run(nil, input)
+	r.EqualError(err, "pong")
+
+	r.True(pc.IsStatic(testdata.ExampleAddMethodID))
+	r.True(pc.IsStatic(testdata.ExampleGetMethodID))
+	r.False(pc.IsStatic(testdata.ExampleSetMethodID))
+	r.False(pc.IsStatic(testdata.ExamplePingMethodID))
+
+	_, err = pc.Run(nil, []byte{0, 0, 0, 0})
+	r.ErrorIs(err, lib.ErrMethodNotFound)
+	_, err = pc.Run(nil, append(testdata.ExampleEchoMethodID, common.LeftPadBytes([]byte{1, 0, 0, 0}, 32)...))
+	r.ErrorIs(err, lib.ErrInvalidABIInput)
+	_, err = pc.Run(nil, testdata.ExampleAddMethodID)
+	r.ErrorIs(err, lib.ErrInvalidABIInput)
+
+	r.NoError(pc.Finalise(nil))
+	r.True(impl.finalised)
+	r.NoError(pc.Commit(nil))
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package solgen
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"text/template"
+)
+
+//go:embed smt.tpl
+var smtTpl string
+
+type SMTConfig struct {
+	Name string
+	Out  string
+}
+
+func generateSparseMerkleVerifier(config SMTConfig) (string, error) {
+	if !isValidSolidityContractName(config.Name) {
+		return "", fmt.Errorf("invalid contract name: '%s'", config.Name)
+	}
+	tmpl, err := template.New("smt").Parse(smtTpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, config); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// GenerateSparseMerkleVerifier writes a solidity library that verifies proofs
+// generated by lib.SparseMerkleTree.
+func GenerateSparseMerkleVerifier(config SMTConfig) error {
+	code, err := generateSparseMerkleVerifier(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.Out, []byte(code), 0644)
+}
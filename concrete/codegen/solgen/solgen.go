@@ -84,6 +84,70 @@ func withLocation(typeStr string, arg abi.Argument) string {
 	return typeStr + " memory" + argName
 }
 
+// solArgs holds the declarations of a list of arguments. Struct types are
+// named after their internal type, so they must be declared in the imported
+// solidity file.
+type solArgs struct {
+	// Params are the arguments with their data location, as used by function
+	// parameters and local variables, and Fields the arguments without it, as
+	// used by events and errors.
+	Params string
+	Fields string
+	Types  string
+	Names  string
+}
+
+// reservedNames are the names of the local variables of the generated
+// functions, which arguments are renamed from.
+var reservedNames = map[string]bool{"success": true, "data": true, "selector": true}
+
+// newSolArgs takes the names of the arguments from the ABI file, as the
+// parsed ABI names unnamed arguments. Unnamed arguments are named after their
+// position if named is set, and other arguments are renamed if they collide
+// with local variables.
+func newSolArgs(args abi.Arguments, custom []abi.ArgumentMarshaling, named bool) solArgs {
+	var params, fields, types, names []string
+	for ii, arg := range args {
+		var internalType string
+		arg.Name = ""
+		if ii < len(custom) {
+			internalType = custom[ii].InternalType
+			arg.Name = custom[ii].Name
+		}
+		if len(arg.Name) == 0 && named {
+			arg.Name = fmt.Sprintf("arg%d", ii)
+		} else if reservedNames[arg.Name] {
+			arg.Name += "_"
+		}
+		typeStr := getTypeString(internalType, arg)
+		field := typeStr
+		if arg.Indexed {
+			field += " indexed"
+		}
+		if len(arg.Name) > 0 {
+			field += " " + arg.Name
+		}
+		params = append(params, withLocation(typeStr, arg))
+		fields = append(fields, field)
+		types = append(types, typeStr)
+		names = append(names, arg.Name)
+	}
+	return solArgs{
+		Params: strings.Join(params, ", "),
+		Fields: strings.Join(fields, ", "),
+		Types:  strings.Join(types, ", "),
+		Names:  strings.Join(names, ", "),
+	}
+}
+
+// declaration returns the left-hand side declaring the given variables.
+func declaration(params string, count int) string {
+	if count <= 1 {
+		return params
+	}
+	return "(" + params + ")"
+}
+
 func generateSolidityLibrary(ABI abi.ABI, cABI customABI, config Config) (string, error) {
 	config.Sol = filepath.ToSlash(config.Sol)
 
@@ -105,45 +169,86 @@ func generateSolidityLibrary(ABI abi.ABI, cABI customABI, config Config) (string
 		importPath = formatPath(importPath)
 	}
 
-	data := map[string]interface{}{
-		"Name":        config.Name,
-		"Address":     config.Address.Hex(),
-		"Methods":     []map[string]interface{}{},
-		"ImportPaths": []string{importPath},
+	// Overloaded methods and events are keyed by name with a numeric suffix in
+	// the parsed ABI, so they are matched to the JSON entries by signature.
+	methods := make(map[string]abi.Method, len(ABI.Methods))
+	for _, method := range ABI.Methods {
+		methods[method.Sig] = method
+	}
+	events := make(map[string]abi.Event, len(ABI.Events))
+	for _, event := range ABI.Events {
+		events[event.Sig] = event
+	}
+	abiErrors := make(map[string]abi.Error, len(ABI.Errors))
+	for _, abiErr := range ABI.Errors {
+		abiErrors[abiErr.Sig] = abiErr
 	}
 
-	for mIdx, method := range ABI.Methods {
-		inputSig := []string{}
-		inputTypes := []string{}
-		inputNames := []string{}
-		for inIdx, input := range method.Inputs {
-			internalType := cABI.MethodsByName[mIdx].Inputs[inIdx].InternalType
-			typeStr := getTypeString(internalType, input)
-			inputSig = append(inputSig, withLocation(typeStr, input))
-			inputTypes = append(inputTypes, typeStr)
-			inputNames = append(inputNames, input.Name)
-		}
-
-		outputSig := []string{}
-		outputTypes := []string{}
-		for outIdx, output := range method.Outputs {
-			internalType := cABI.MethodsByName[mIdx].Outputs[outIdx].InternalType
-			typeStr := getTypeString(internalType, output)
-			outputSig = append(outputSig, withLocation(typeStr, output))
-			outputTypes = append(outputTypes, typeStr)
+	var (
+		methodsData []map[string]interface{}
+		eventsData  []map[string]interface{}
+		errorsData  []map[string]interface{}
+		errorNames  = make(map[string]bool)
+	)
+	// Entries are generated in the order of the ABI file.
+	for _, entry := range cABI.Methods {
+		sig := entry.signature()
+		switch entry.Type {
+		case "function", "":
+			method, ok := methods[sig]
+			if !ok {
+				continue
+			}
+			inputs := newSolArgs(method.Inputs, entry.Inputs, true)
+			outputs := newSolArgs(method.Outputs, entry.Outputs, false)
+			methodsData = append(methodsData, map[string]interface{}{
+				"Name":        method.RawName,
+				"Signature":   method.Sig,
+				"IsStatic":    method.IsConstant(),
+				"Inputs":      inputs.Params,
+				"InputNames":  inputs.Names,
+				"Outputs":     outputs.Params,
+				"OutputTypes": outputs.Types,
+			})
+		case "event":
+			event, ok := events[sig]
+			if !ok {
+				continue
+			}
+			eventsData = append(eventsData, map[string]interface{}{
+				"Name":      event.RawName,
+				"Fields":    newSolArgs(event.Inputs, entry.Inputs, false).Fields,
+				"Anonymous": event.Anonymous,
+			})
+		case "error":
+			// Errors cannot be overloaded in solidity, and are keyed by name in
+			// the parsed ABI.
+			if errorNames[entry.Name] {
+				return "", fmt.Errorf("overloaded error: '%s'", entry.Name)
+			}
+			errorNames[entry.Name] = true
+			abiErr, ok := abiErrors[sig]
+			if !ok {
+				continue
+			}
+			args := newSolArgs(abiErr.Inputs, entry.Inputs, true)
+			errorsData = append(errorsData, map[string]interface{}{
+				"Name":   abiErr.Name,
+				"Fields": args.Fields,
+				"Decl":   declaration(args.Params, len(abiErr.Inputs)),
+				"Types":  args.Types,
+				"Names":  args.Names,
+			})
 		}
+	}
 
-		methodData := map[string]interface{}{
-			"Name":        method.Name,
-			"Signature":   method.Sig,
-			"IsStatic":    method.IsConstant(),
-			"Inputs":      strings.Join(inputSig, ", "),
-			"Outputs":     strings.Join(outputSig, ", "),
-			"InputTypes":  strings.Join(inputTypes, ", "),
-			"OutputTypes": strings.Join(outputTypes, ", "),
-			"InputNames":  strings.Join(inputNames, ", "),
-		}
-		data["Methods"] = append(data["Methods"].([]map[string]interface{}), methodData)
+	data := map[string]interface{}{
+		"Name":        config.Name,
+		"Address":     config.Address.Hex(),
+		"Methods":     methodsData,
+		"Events":      eventsData,
+		"Errors":      errorsData,
+		"ImportPaths": []string{importPath},
 	}
 
 	var buf bytes.Buffer
@@ -155,14 +260,37 @@ func generateSolidityLibrary(ABI abi.ABI, cABI customABI, config Config) (string
 }
 
 type customMethod struct {
+	Type    string                   `json:"type"`
 	Name    string                   `json:"name"`
 	Inputs  []abi.ArgumentMarshaling `json:"inputs"`
 	Outputs []abi.ArgumentMarshaling `json:"outputs"`
 }
 
+func canonicalType(arg abi.ArgumentMarshaling) string {
+	if !strings.HasPrefix(arg.Type, "tuple") {
+		return arg.Type
+	}
+	components := make([]string, 0, len(arg.Components))
+	for _, component := range arg.Components {
+		components = append(components, canonicalType(component))
+	}
+	return "(" + strings.Join(components, ",") + ")" + strings.TrimPrefix(arg.Type, "tuple")
+}
+
+// signature returns the canonical signature of the entry, as used to compute
+// selectors and topics.
+func (m *customMethod) signature() string {
+	types := make([]string, 0, len(m.Inputs))
+	for _, input := range m.Inputs {
+		types = append(types, canonicalType(input))
+	}
+	return m.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// customABI holds the entries of an ABI as they are in the JSON file, which
+// keeps the internal types of arguments.
 type customABI struct {
-	Methods       []customMethod           `json:"methods"`
-	MethodsByName map[string]*customMethod `json:"-"`
+	Methods []customMethod `json:"methods"`
 }
 
 func (c *customABI) UnmarshalJSON(data []byte) error {
@@ -172,10 +300,6 @@ func (c *customABI) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	c.Methods = a
-	c.MethodsByName = make(map[string]*customMethod)
-	for i := range c.Methods {
-		c.MethodsByName[c.Methods[i].Name] = &c.Methods[i]
-	}
 	return nil
 }
 
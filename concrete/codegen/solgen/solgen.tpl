@@ -1,5 +1,5 @@
 // SPDX-License-Identifier: MIT
-pragma solidity >=0.8.0;
+pragma solidity >={{if .Errors}}0.8.4{{else}}0.8.0{{end}};
 
 /* Autogenerated file. Do not edit manually. */
 
@@ -9,16 +9,63 @@ pragma solidity >=0.8.0;
 {{- end }}
 library {{.Name}} {
     address constant precompileAddress = address({{.Address}});
+    {{- if .Events }}
+{{ range .Events }}
+    event {{.Name}}({{.Fields}}){{if .Anonymous}} anonymous{{end}};
+    {{- end }}
+    {{- end }}
+    {{- if .Errors }}
+{{ range .Errors }}
+    error {{.Name}}({{.Fields}});
+    {{- end }}
+    {{- end }}
     {{- range .Methods }}
 
     function {{.Name}}({{.Inputs}}) internal{{if .IsStatic}} view{{end}}{{if .Outputs}} returns ({{.Outputs}}){{end}} {
-        (bool success, {{if .Outputs}}bytes memory data{{end}}) = precompileAddress.{{if .IsStatic}}staticcall{{else}}call{{end}}(
+        (bool success, bytes memory data) = precompileAddress.{{if .IsStatic}}staticcall{{else}}call{{end}}(
             abi.encodeWithSignature("{{.Signature}}"{{if .InputNames}}, {{.InputNames}}{{end}})
         );
-        require(success);
+        if (!success) {
+            _revert(data);
+        }
         {{- if .Outputs }}
         return abi.decode(data, ({{.OutputTypes}}));
         {{- end }}
     }
     {{- end }}
+
+    /// @dev Re-raises the custom errors of the precompile, and bubbles up any
+    /// other revert data as is.
+    function _revert(bytes memory data) private pure {
+        {{- if .Errors }}
+        if (data.length >= 4) {
+            bytes4 selector;
+            assembly {
+                selector := and(mload(add(data, 32)), 0xffffffff00000000000000000000000000000000000000000000000000000000)
+            }
+            {{- range .Errors }}
+            if (selector == {{.Name}}.selector) {
+                {{- if .Decl }}
+                {{.Decl}} = abi.decode(_errorArgs(data), ({{.Types}}));
+                {{- end }}
+                revert {{.Name}}({{.Names}});
+            }
+            {{- end }}
+        }
+        {{- end }}
+        assembly {
+            revert(add(data, 32), mload(data))
+        }
+    }
+    {{- if .Errors }}
+
+    /// @dev Returns the arguments of an error, overwriting the data before
+    /// them.
+    function _errorArgs(bytes memory data) private pure returns (bytes memory args) {
+        assembly {
+            args := add(data, 4)
+            mstore(args, sub(mload(data), 4))
+        }
+    }
+    {{- end }}
 }
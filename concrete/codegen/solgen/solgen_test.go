@@ -15,7 +15,25 @@
 
 package solgen
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/lib"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/ethereum/go-ethereum/core"
+)
 
 func TestValidContractName(t *testing.T) {
 	testCases := []struct {
@@ -37,3 +55,199 @@ func TestValidContractName(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateSparseMerkleVerifier(t *testing.T) {
+	code, err := generateSparseMerkleVerifier(SMTConfig{Name: "Verifier"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, "library Verifier {") {
+		t.Errorf("unexpected library name in generated code")
+	}
+	if _, err := generateSparseMerkleVerifier(SMTConfig{Name: "0"}); err == nil {
+		t.Errorf("expected error for invalid name")
+	}
+}
+
+const smtVerifierTestContract = `
+contract SparseMerkleVerifierTest {
+    function verify(
+        bytes32 root,
+        bytes memory key,
+        bytes memory value,
+        bytes32[] memory sideNodes,
+        bytes32 leafPath,
+        bytes32 leafValueHash
+    ) external pure returns (bool) {
+        return SparseMerkleVerifier.verify(root, key, value, sideNodes, leafPath, leafValueHash);
+    }
+}
+`
+
+const smtVerifierTestABI = `[{"type":"function","name":"verify","stateMutability":"pure","inputs":[
+	{"name":"root","type":"bytes32"},
+	{"name":"key","type":"bytes"},
+	{"name":"value","type":"bytes"},
+	{"name":"sideNodes","type":"bytes32[]"},
+	{"name":"leafPath","type":"bytes32"},
+	{"name":"leafValueHash","type":"bytes32"}
+],"outputs":[{"name":"","type":"bool"}]}]`
+
+// compileSolidity compiles a contract with solc and returns its runtime code.
+func compileSolidity(t *testing.T, source string, name string) []byte {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Skip("solc not found")
+	}
+	cmd := exec.Command(solc, "--combined-json", "bin-runtime", "-")
+	cmd.Stdin = strings.NewReader(source)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("solc: %v\n%s", err, stderr.String())
+	}
+	var output struct {
+		Contracts map[string]struct {
+			BinRuntime string `json:"bin-runtime"`
+		}
+	}
+	if err := json.Unmarshal(out, &output); err != nil {
+		t.Fatal(err)
+	}
+	for id, contract := range output.Contracts {
+		if strings.HasSuffix(id, ":"+name) {
+			return common.FromHex(contract.BinRuntime)
+		}
+	}
+	t.Fatalf("contract %s not found in solc output", name)
+	return nil
+}
+
+// TestSparseMerkleVerifierProofs checks the generated verifier against proofs
+// generated by lib.SparseMerkleTree, in the simulated backend.
+func TestSparseMerkleVerifierProofs(t *testing.T) {
+	library, err := generateSparseMerkleVerifier(SMTConfig{Name: "SparseMerkleVerifier"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := compileSolidity(t, library+smtVerifierTestContract, "SparseMerkleVerifierTest")
+
+	address := common.HexToAddress("0xcc")
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{address: {Code: code, Balance: common.Big0}}, 10_000_000)
+	defer backend.Close()
+	verifierABI, err := abi.JSON(strings.NewReader(smtVerifierTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := func(root common.Hash, key, value []byte, proof *lib.SparseMerkleProof) bool {
+		input, err := verifierABI.Pack("verify", root, key, value, hashesToArrays(proof.SideNodes), proof.LeafPath, proof.LeafValueHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := backend.CallContract(context.Background(), ethereum.CallMsg{To: &address, Data: input}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, err := verifierABI.Unpack("verify", output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret[0].(bool)
+	}
+
+	env := mock.NewMockEnvironment(common.Address{}, api.EnvConfig{}, false, 0)
+	tree := lib.NewSparseMerkleTree(lib.NewPersistentDatastore(env).Get([]byte("smt")))
+	var keys [][]byte
+	for ii := 0; ii < 32; ii++ {
+		keys = append(keys, []byte(fmt.Sprintf("key%d", ii)))
+	}
+	// Half of the keys are in the tree, the other half are proven absent,
+	// either through an empty subtree or a different leaf in their place
+	for ii := 0; ii < len(keys); ii += 2 {
+		tree.Set(keys[ii], []byte(fmt.Sprintf("value%d", ii)))
+	}
+	root := tree.Root()
+	for ii, key := range keys {
+		proof := tree.Prove(key)
+		value := tree.Get(key)
+		values := [][]byte{value, nil, []byte("wrong")}
+		for _, v := range values {
+			want := lib.VerifySparseMerkleProof(root, key, v, proof)
+			if got := verify(root, key, v, proof); got != want {
+				t.Fatalf("key %d, value %q: solidity verifier returned %v, want %v", ii, v, got, want)
+			}
+		}
+		if !verify(root, key, value, proof) {
+			t.Fatalf("key %d: valid proof rejected", ii)
+		}
+		if verify(common.Hash{0x01}, key, value, proof) {
+			t.Fatalf("key %d: proof accepted against a wrong root", ii)
+		}
+	}
+}
+
+func hashesToArrays(hashes []common.Hash) [][32]byte {
+	arrays := make([][32]byte, len(hashes))
+	for ii, hash := range hashes {
+		arrays[ii] = hash
+	}
+	return arrays
+}
+
+func TestGenerateSolidityLibrary(t *testing.T) {
+	ABI, cABI, err := GetABI("testdata/Token.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := Config{
+		Name:    "TokenPrecompile",
+		Address: common.HexToAddress("0x80"),
+		Sol:     "testdata/Types.sol",
+		Out:     "testdata/TokenPrecompile.sol",
+	}
+	code, err := generateSolidityLibrary(ABI, cABI, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile("testdata/TokenPrecompile.sol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != string(expected) {
+		t.Errorf("testdata/TokenPrecompile.sol is outdated")
+	}
+
+	// Overloads are generated with their own signature.
+	for _, sig := range []string{
+		`abi.encodeWithSignature("transfer(address,uint256)", to, amount)`,
+		`abi.encodeWithSignature("transfer(address,uint256,bytes)", to, amount, data_)`,
+		`abi.encodeWithSignature("move((int256,int256))", point)`,
+		"event Transfer(address indexed from, address indexed to, uint256 amount);",
+		"event Transfer(address indexed to, uint256 amount);",
+		"event Log(bytes32) anonymous;",
+		"error InvalidPoint(Point point);",
+	} {
+		if !strings.Contains(code, sig) {
+			t.Errorf("missing %q in generated code", sig)
+		}
+	}
+}
+
+func TestGenerateSolidityLibraryErrors(t *testing.T) {
+	content := `[
+		{"type":"error","name":"Failed","inputs":[]},
+		{"type":"error","name":"Failed","inputs":[{"name":"code","type":"uint256","internalType":"uint256"}]}
+	]`
+	ABI, err := abi.JSON(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cABI customABI
+	if err := cABI.UnmarshalJSON([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := generateSolidityLibrary(ABI, cABI, Config{Name: "Lib"}); err == nil {
+		t.Errorf("expected error for overloaded errors")
+	}
+}
@@ -0,0 +1,7 @@
+// SPDX-License-Identifier: MIT
+pragma solidity >=0.8.0;
+
+struct Point {
+    int256 x;
+    int256 y;
+}
@@ -22,7 +22,7 @@ var (
 //	KkvDefaultKey = crypto.Keccak256([]byte("datamod.v1.Kkv"))
 // )
 
-func defaultKey() []byte {
+func KkvDefaultKey() []byte {
 	return crypto.Keccak256([]byte("datamod.v1.Kkv"))
 }
 
@@ -62,7 +62,7 @@ type Kkv struct {
 }
 
 func NewKkv(ds lib.Datastore) *Kkv {
-	dsSlot := ds.Get(defaultKey())
+	dsSlot := ds.Get(KkvDefaultKey())
 	return &Kkv{dsSlot}
 }
 
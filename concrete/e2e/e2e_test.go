@@ -287,3 +287,115 @@ func TestE2EKkvPrecompile(t *testing.T) {
 		})
 	}
 }
+
+func getNamespacedKkvABI() abi.ABI {
+	namespacedKkvABI, err := abi.JSON(strings.NewReader(NamespacedKkvAbiString))
+	if err != nil {
+		panic(err)
+	}
+	return namespacedKkvABI
+}
+
+func TestNamespacedKkvPrecompileFixture(t *testing.T) {
+	var (
+		r       = require.New(t)
+		ABI     = getNamespacedKkvABI()
+		address = common.BytesToAddress([]byte{150})
+		env     = mock.NewMockEnvironment(address, api.EnvConfig{Trusted: true}, false, 0)
+		pc      = &NamespacedKkvPrecompile{}
+		ns1     = common.HexToHash("0x01")
+		ns2     = common.HexToHash("0x02")
+		k1      = common.HexToHash("0x03")
+		k2      = common.HexToHash("0x04")
+	)
+
+	call := func(method string, args ...interface{}) []interface{} {
+		input, err := ABI.Pack(method, args...)
+		r.NoError(err)
+		r.Equal(method != "set", pc.IsStatic(input))
+		output, _, err := concrete.RunPrecompile(pc, env, input, pc.IsStatic(input))
+		r.NoError(err)
+		values, err := ABI.Methods[method].Outputs.Unpack(output)
+		r.NoError(err)
+		return values
+	}
+
+	call("set", ns1, k1, k2, common.HexToHash("0x05"))
+	call("set", ns1, k2, k1, common.HexToHash("0x06"))
+	call("set", ns2, k1, k2, common.HexToHash("0x07"))
+
+	r.Equal([]interface{}{[32]byte(common.HexToHash("0x05"))}, call("get", ns1, k1, k2))
+	r.Equal([]interface{}{[32]byte(common.HexToHash("0x06"))}, call("get", ns1, k2, k1))
+	r.Equal([]interface{}{[32]byte(common.HexToHash("0x07"))}, call("get", ns2, k1, k2))
+	r.Equal([]interface{}{[32]byte(common.HexToHash("0x06")), uint64(2)}, call("latest", ns1))
+	r.Equal([]interface{}{[32]byte(common.HexToHash("0x07")), uint64(1)}, call("latest", ns2))
+
+	// Entries of different namespaces and nested tables do not overlap
+	namespace := fixture_datamod.NewDirectory(lib.NewDatastore(env)).GetNamespaces().Get(ns2)
+	r.Equal(common.Hash{}, namespace.GetEntries().Get(k2, k1).GetValue())
+	r.Equal(common.Hash{}, fixture_datamod.NewKkv(lib.NewDatastore(env)).Get(k1, k2).GetValue())
+}
+
+func TestE2ENamespacedKkvPrecompile(t *testing.T) {
+	var (
+		r             = require.New(t)
+		ABI           = getNamespacedKkvABI()
+		address       = common.BytesToAddress([]byte{150})
+		key, _        = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		senderAddress = crypto.PubkeyToAddress(key.PublicKey)
+		gspec         = &core.Genesis{
+			Config:   params.TestChainConfig,
+			GasLimit: 30_000_000,
+			Alloc: core.GenesisAlloc{
+				senderAddress: {Balance: math.MaxBig256},
+			},
+		}
+		signer     = types.LatestSigner(gspec.Config)
+		nBlocks    = 4
+		txGasLimit = uint64(2e5)
+	)
+
+	pcArgs := func(ii int) (ns, k1, k2, v common.Hash) {
+		ns = common.BigToHash(big.NewInt(int64(ii % 2)))
+		k1 = common.BigToHash(big.NewInt(int64(ii)))
+		k2 = common.BigToHash(big.NewInt(int64(ii + 1)))
+		v = common.BigToHash(big.NewInt(int64(ii + 2)))
+		return
+	}
+
+	concreteRegistry := concrete.NewRegistry()
+	concreteRegistry.AddPrecompile(0, address, &NamespacedKkvPrecompile{})
+
+	db, blocks, receipts := core.GenerateChainWithGenesisWithConcrete(gspec, ethash.NewFaker(), nBlocks, concreteRegistry, func(ii int, block *core.BlockGen) {
+		ns, k1, k2, v := pcArgs(ii)
+		input, err := ABI.Pack("set", ns, k1, k2, v)
+		r.NoError(err)
+		tx := types.NewTransaction(block.TxNonce(senderAddress), address, common.Big0, txGasLimit, block.BaseFee(), input)
+		signed, err := types.SignTx(tx, signer, key)
+		r.NoError(err)
+		block.AddTx(signed)
+	})
+
+	for _, blockReceipts := range receipts {
+		for _, receipt := range blockReceipts {
+			r.Equal(types.ReceiptStatusSuccessful, receipt.Status)
+		}
+	}
+
+	root := blocks[len(blocks)-1].Root()
+	statedb, err := state.New(root, state.NewDatabase(db), nil)
+	r.NoError(err)
+	env := api.NewNoCallEnvironment(address, api.EnvConfig{}, statedb, false, 0)
+	namespaces := fixture_datamod.NewDirectory(lib.NewDatastore(env)).GetNamespaces()
+
+	for ii := 0; ii < nBlocks; ii++ {
+		ns, k1, k2, v := pcArgs(ii)
+		r.Equal(v, namespaces.Get(ns).GetEntries().Get(k1, k2).GetValue())
+	}
+	for ii := nBlocks - 2; ii < nBlocks; ii++ {
+		ns, _, _, v := pcArgs(ii)
+		namespace := namespaces.Get(ns)
+		r.Equal(v, namespace.GetLatest().GetValue())
+		r.Equal(uint64(nBlocks/2), namespace.GetWrites())
+	}
+}
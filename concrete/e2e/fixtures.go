@@ -105,6 +105,64 @@ func (a *KeyKeyValuePrecompile) Run(env api.Environment, input []byte) ([]byte,
 
 var _ concrete.Precompile = &KeyKeyValuePrecompile{}
 
+const NamespacedKkvAbiString = "[{\"inputs\":[{\"name\":\"ns\",\"type\":\"bytes32\"},{\"name\":\"k1\",\"type\":\"bytes32\"},{\"name\":\"k2\",\"type\":\"bytes32\"},{\"name\":\"v\",\"type\":\"bytes32\"}],\"name\":\"set\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"name\":\"ns\",\"type\":\"bytes32\"},{\"name\":\"k1\",\"type\":\"bytes32\"},{\"name\":\"k2\",\"type\":\"bytes32\"}],\"name\":\"get\",\"outputs\":[{\"name\":\"v\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"name\":\"ns\",\"type\":\"bytes32\"}],\"name\":\"latest\",\"outputs\":[{\"name\":\"v\",\"type\":\"bytes32\"},{\"name\":\"writes\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]"
+
+var (
+	NamespacedKkvSetMethodID    = common.Hex2Bytes("fa49f025")
+	NamespacedKkvGetMethodID    = common.Hex2Bytes("4752ccf5")
+	NamespacedKkvLatestMethodID = common.Hex2Bytes("79feb107")
+)
+
+// NamespacedKkvPrecompile stores key-key-value entries in namespaces, using
+// nested datamod tables: directory -> namespace -> kkv and kv.
+type NamespacedKkvPrecompile struct {
+	lib.BlankPrecompile
+}
+
+func (a *NamespacedKkvPrecompile) IsStatic(input []byte) bool {
+	methodID, _ := utils.SplitInput(input)
+	return !bytes.Equal(methodID, NamespacedKkvSetMethodID)
+}
+
+func (a *NamespacedKkvPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+	methodID, data := utils.SplitInput(input)
+	if len(data) < 32 {
+		return nil, ErrInvalidInput
+	}
+	namespaces := fixture_datamod.NewDirectory(lib.NewDatastore(env)).GetNamespaces()
+	namespace := namespaces.Get(common.BytesToHash(data[:32]))
+	if bytes.Equal(methodID, NamespacedKkvGetMethodID) {
+		if len(data) != 96 {
+			return nil, ErrInvalidInput
+		}
+		k1 := common.BytesToHash(data[32:64])
+		k2 := common.BytesToHash(data[64:])
+		v := namespace.GetEntries().Get(k1, k2).GetValue()
+		return v.Bytes(), nil
+	} else if bytes.Equal(methodID, NamespacedKkvLatestMethodID) {
+		if len(data) != 32 {
+			return nil, ErrInvalidInput
+		}
+		v := namespace.GetLatest().GetValue()
+		writes := new(big.Int).SetUint64(namespace.GetWrites())
+		return append(v.Bytes(), common.BigToHash(writes).Bytes()...), nil
+	} else if bytes.Equal(methodID, NamespacedKkvSetMethodID) {
+		if len(data) != 128 {
+			return nil, ErrInvalidInput
+		}
+		k1 := common.BytesToHash(data[32:64])
+		k2 := common.BytesToHash(data[64:96])
+		v := common.BytesToHash(data[96:])
+		namespace.GetEntries().Get(k1, k2).SetValue(v)
+		namespace.GetLatest().SetValue(v)
+		namespace.SetWrites(namespace.GetWrites() + 1)
+		return nil, nil
+	}
+	return nil, ErrMethodNotFound
+}
+
+var _ concrete.Precompile = &NamespacedKkvPrecompile{}
+
 type GasPrecompile struct {
 	lib.BlankPrecompile
 }
@@ -0,0 +1,305 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ABIEncoder and ABIDecoder implement the subset of the ABI specification
+// used by generated precompile stubs. Like abiEncode, they do not depend on
+// accounts/abi so that stubs build with tinygo.
+
+var (
+	ErrMethodNotFound  = errors.New("method not found")
+	ErrInvalidABIInput = errors.New("invalid abi input")
+)
+
+var (
+	abiMaxUint256 = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	abiTwoTo256   = new(big.Int).Lsh(common.Big1, 256)
+)
+
+type abiPart struct {
+	data    []byte
+	dynamic bool
+}
+
+// ABIEncoder encodes a tuple. Static values are written to the head and
+// dynamic ones to the tail, referenced by their offset.
+type ABIEncoder struct {
+	parts []abiPart
+}
+
+func NewABIEncoder() *ABIEncoder {
+	return &ABIEncoder{}
+}
+
+func (e *ABIEncoder) static(data []byte) {
+	e.parts = append(e.parts, abiPart{data: data})
+}
+
+func (e *ABIEncoder) dynamic(data []byte) {
+	e.parts = append(e.parts, abiPart{data: data, dynamic: true})
+}
+
+func (e *ABIEncoder) Word(word []byte) {
+	e.static(common.LeftPadBytes(word, 32))
+}
+
+func (e *ABIEncoder) Uint(value uint64) {
+	e.static(abiWord(value))
+}
+
+func (e *ABIEncoder) Int(value int64) {
+	word := abiWord(uint64(value))
+	if value < 0 {
+		for ii := 0; ii < 24; ii++ {
+			word[ii] = 0xff
+		}
+	}
+	e.static(word)
+}
+
+func (e *ABIEncoder) BigUint(value *big.Int) {
+	if value == nil {
+		value = common.Big0
+	}
+	e.static(common.LeftPadBytes(new(big.Int).And(value, abiMaxUint256).Bytes(), 32))
+}
+
+func (e *ABIEncoder) BigInt(value *big.Int) {
+	if value == nil {
+		value = common.Big0
+	}
+	if value.Sign() < 0 {
+		value = new(big.Int).Add(value, abiTwoTo256)
+	}
+	e.BigUint(value)
+}
+
+func (e *ABIEncoder) Bool(value bool) {
+	if value {
+		e.Uint(1)
+	} else {
+		e.Uint(0)
+	}
+}
+
+func (e *ABIEncoder) Address(value common.Address) {
+	e.static(common.LeftPadBytes(value.Bytes(), 32))
+}
+
+// FixedBytes encodes a bytesN value, which is right padded.
+func (e *ABIEncoder) FixedBytes(value []byte) {
+	word := make([]byte, 32)
+	copy(word, value)
+	e.static(word)
+}
+
+func (e *ABIEncoder) Bytes(value []byte) {
+	e.dynamic(abiEncodeBytes(value))
+}
+
+func (e *ABIEncoder) String(value string) {
+	e.Bytes([]byte(value))
+}
+
+// DynamicArray encodes a T[] value given its length and the encoder its
+// elements were written to.
+func (e *ABIEncoder) DynamicArray(length int, elems *ABIEncoder) {
+	e.dynamic(append(abiWord(uint64(length)), elems.Encode()...))
+}
+
+// Tuple encodes a dynamic tuple, such as a T[k] value with dynamic elements,
+// given the encoder its elements were written to.
+func (e *ABIEncoder) Tuple(elems *ABIEncoder) {
+	e.dynamic(elems.Encode())
+}
+
+func (e *ABIEncoder) Encode() []byte {
+	headSize := 0
+	for _, part := range e.parts {
+		if part.dynamic {
+			headSize += 32
+		} else {
+			headSize += len(part.data)
+		}
+	}
+	head := make([]byte, 0, headSize)
+	var tail []byte
+	for _, part := range e.parts {
+		if part.dynamic {
+			head = append(head, abiWord(uint64(headSize+len(tail)))...)
+			tail = append(tail, part.data...)
+		} else {
+			head = append(head, part.data...)
+		}
+	}
+	return append(head, tail...)
+}
+
+// ABIDecoder decodes a tuple. Values are read from the head in order. The
+// first error is recorded, after which zero values are returned, so callers
+// only need to check Err once all values are read.
+type ABIDecoder struct {
+	data   []byte
+	offset int
+	err    *error
+}
+
+func NewABIDecoder(data []byte) *ABIDecoder {
+	return &ABIDecoder{data: data, err: new(error)}
+}
+
+func (d *ABIDecoder) Err() error {
+	return *d.err
+}
+
+func (d *ABIDecoder) fail() {
+	if *d.err == nil {
+		*d.err = ErrInvalidABIInput
+	}
+}
+
+func (d *ABIDecoder) sub(offset int) *ABIDecoder {
+	if offset > len(d.data) {
+		d.fail()
+		offset = len(d.data)
+	}
+	return &ABIDecoder{data: d.data[offset:], err: d.err}
+}
+
+func (d *ABIDecoder) Word() []byte {
+	if *d.err != nil || d.offset+32 > len(d.data) {
+		d.fail()
+		return make([]byte, 32)
+	}
+	word := d.data[d.offset : d.offset+32]
+	d.offset += 32
+	return word
+}
+
+func (d *ABIDecoder) Uint(bits int) uint64 {
+	word := d.Word()
+	value := new(big.Int).SetBytes(word)
+	if value.BitLen() > bits {
+		d.fail()
+		return 0
+	}
+	return value.Uint64()
+}
+
+func (d *ABIDecoder) Int(bits int) int64 {
+	value := d.BigInt(bits)
+	return value.Int64()
+}
+
+func (d *ABIDecoder) BigUint(bits int) *big.Int {
+	value := new(big.Int).SetBytes(d.Word())
+	if value.BitLen() > bits {
+		d.fail()
+		return new(big.Int)
+	}
+	return value
+}
+
+func (d *ABIDecoder) BigInt(bits int) *big.Int {
+	value := new(big.Int).SetBytes(d.Word())
+	if value.Bit(255) == 1 {
+		value.Sub(value, abiTwoTo256)
+	}
+	// Values must be in [-2^(bits-1), 2^(bits-1)).
+	if bound := new(big.Int).Lsh(common.Big1, uint(bits-1)); value.Cmp(bound) >= 0 || value.Cmp(new(big.Int).Neg(bound)) < 0 {
+		d.fail()
+		return new(big.Int)
+	}
+	return value
+}
+
+func (d *ABIDecoder) Bool() bool {
+	switch d.Uint(8) {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	d.fail()
+	return false
+}
+
+func (d *ABIDecoder) Address() common.Address {
+	word := d.Word()
+	for _, b := range word[:12] {
+		if b != 0 {
+			d.fail()
+			return common.Address{}
+		}
+	}
+	return common.BytesToAddress(word[12:])
+}
+
+// FixedBytes decodes a bytesN value of the given size.
+func (d *ABIDecoder) FixedBytes(size int) []byte {
+	return d.Word()[:size]
+}
+
+// length reads a length or offset, bounded by the size of the data.
+func (d *ABIDecoder) length() int {
+	value := d.Uint(64)
+	if value > uint64(len(d.data)) {
+		d.fail()
+		return 0
+	}
+	return int(value)
+}
+
+func (d *ABIDecoder) Bytes() []byte {
+	tail := d.sub(d.length())
+	size := tail.length()
+	if *d.err != nil || 32+size > len(tail.data) {
+		d.fail()
+		return []byte{}
+	}
+	return common.CopyBytes(tail.data[32 : 32+size])
+}
+
+func (d *ABIDecoder) String() string {
+	return string(d.Bytes())
+}
+
+// DynamicArray decodes the length of a T[] value and returns the decoder its
+// elements are read from.
+func (d *ABIDecoder) DynamicArray() (int, *ABIDecoder) {
+	tail := d.sub(d.length())
+	length := tail.length()
+	elems := tail.sub(32)
+	// Every element takes at least one word.
+	if length > len(elems.data)/32 {
+		d.fail()
+		return 0, elems
+	}
+	return length, elems
+}
+
+// Tuple returns the decoder the elements of a dynamic tuple, such as a T[k]
+// value with dynamic elements, are read from.
+func (d *ABIDecoder) Tuple() *ABIDecoder {
+	return d.sub(d.length())
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newABIArguments(t *testing.T, types ...string) abi.Arguments {
+	var args abi.Arguments
+	for _, typeStr := range types {
+		typ, err := abi.NewType(typeStr, "", nil)
+		require.NoError(t, err)
+		args = append(args, abi.Argument{Type: typ})
+	}
+	return args
+}
+
+func TestABICodec(t *testing.T) {
+	var (
+		r          = require.New(t)
+		args       = newABIArguments(t, "uint8", "int64", "uint256", "int256", "bool", "address", "bytes4", "bytes", "string", "uint16[]", "bytes[2]", "int32[2][]")
+		address    = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+		bigUint, _ = new(big.Int).SetString("fedcba9876543210fedcba9876543210", 16)
+		bigInt     = new(big.Int).Neg(bigUint)
+		data       = make([]byte, 33)
+	)
+	for ii := range data {
+		data[ii] = byte(ii + 1)
+	}
+
+	packed, err := args.Pack(
+		uint8(7), int64(-42), bigUint, bigInt, true, address, [4]byte{1, 2, 3, 4}, data, "hello",
+		[]uint16{1, 2, 3}, [2][]byte{{1}, data}, [][2]int32{{-1, 1}, {-2, 2}},
+	)
+	r.NoError(err)
+
+	enc := NewABIEncoder()
+	enc.Uint(7)
+	enc.Int(-42)
+	enc.BigUint(bigUint)
+	enc.BigInt(bigInt)
+	enc.Bool(true)
+	enc.Address(address)
+	enc.FixedBytes([]byte{1, 2, 3, 4})
+	enc.Bytes(data)
+	enc.String("hello")
+	elems := NewABIEncoder()
+	for _, v := range []uint64{1, 2, 3} {
+		elems.Uint(v)
+	}
+	enc.DynamicArray(3, elems)
+	elems = NewABIEncoder()
+	elems.Bytes([]byte{1})
+	elems.Bytes(data)
+	enc.Tuple(elems)
+	elems = NewABIEncoder()
+	for _, v := range []int64{-1, 1, -2, 2} {
+		elems.Int(v)
+	}
+	enc.DynamicArray(2, elems)
+	r.Equal(packed, enc.Encode())
+
+	dec := NewABIDecoder(packed)
+	r.Equal(uint64(7), dec.Uint(8))
+	r.Equal(int64(-42), dec.Int(64))
+	r.Equal(bigUint, dec.BigUint(256))
+	r.Equal(bigInt, dec.BigInt(256))
+	r.True(dec.Bool())
+	r.Equal(address, dec.Address())
+	r.Equal([]byte{1, 2, 3, 4}, dec.FixedBytes(4))
+	r.Equal(data, dec.Bytes())
+	r.Equal("hello", dec.String())
+	length, elemsDec := dec.DynamicArray()
+	r.Equal(3, length)
+	for _, v := range []uint64{1, 2, 3} {
+		r.Equal(v, elemsDec.Uint(16))
+	}
+	elemsDec = dec.Tuple()
+	r.Equal([]byte{1}, elemsDec.Bytes())
+	r.Equal(data, elemsDec.Bytes())
+	length, elemsDec = dec.DynamicArray()
+	r.Equal(2, length)
+	for _, v := range []int64{-1, 1, -2, 2} {
+		r.Equal(v, elemsDec.Int(32))
+	}
+	r.NoError(dec.Err())
+}
+
+func TestABIDecoderErrors(t *testing.T) {
+	r := require.New(t)
+	word := func(value uint64) []byte {
+		return abiWord(value)
+	}
+
+	// Values out of the range of their type.
+	dec := NewABIDecoder(word(256))
+	dec.Uint(8)
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+	dec = NewABIDecoder(word(128))
+	dec.Int(8)
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+	dec = NewABIDecoder(word(2))
+	dec.Bool()
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+	dec = NewABIDecoder(common.LeftPadBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 32))
+	dec.Address()
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+
+	// Truncated input.
+	dec = NewABIDecoder(make([]byte, 31))
+	r.Equal(uint64(0), dec.Uint(64))
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+
+	// Offsets and lengths beyond the input.
+	dec = NewABIDecoder(word(64))
+	r.Equal([]byte{}, dec.Bytes())
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+	dec = NewABIDecoder(append(word(32), word(1000)...))
+	r.Equal([]byte{}, dec.Bytes())
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+	dec = NewABIDecoder(append(word(32), word(1000)...))
+	length, _ := dec.DynamicArray()
+	r.Equal(0, length)
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+
+	// Errors of nested decoders are reported by the parent.
+	dec = NewABIDecoder(append(word(32), word(256)...))
+	dec.Tuple().Uint(8)
+	r.ErrorIs(dec.Err(), ErrInvalidABIInput)
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+const (
+	blobRefCountIndex = 0
+	blobSizeIndex     = 1
+	blobHeaderLength  = 2
+)
+
+// BlobStore stores arbitrary-length blobs by their keccak256 hash. Storing a
+// blob that is already present only increments its reference count, so only
+// new bytes are paid for. Blobs are deleted once all references are released.
+type BlobStore struct {
+	blobs Mapping
+}
+
+func NewBlobStore(dsSlot DatastoreSlot) *BlobStore {
+	return &BlobStore{blobs: dsSlot.Mapping()}
+}
+
+func (b *BlobStore) entry(hash common.Hash) DatastoreSlot {
+	return b.blobs.Get(hash.Bytes())
+}
+
+func (b *BlobStore) header(hash common.Hash) SlotArray {
+	return b.entry(hash).SlotArray([]int{blobHeaderLength})
+}
+
+// Chunks are laid out contiguously starting at keccak256(entrySlot), like
+// long byte arrays in DatastoreSlot.SetBytes.
+func (b *BlobStore) chunks(hash common.Hash, size uint64) SlotArray {
+	if size == 0 {
+		return nil
+	}
+	entry := b.entry(hash)
+	chunksSlot := entry.Datastore().Get(crypto.Keccak256(entry.Slot().Bytes()))
+	return chunksSlot.SlotArray([]int{int((size + 31) / 32)})
+}
+
+// Put stores the blob and returns its hash.
+func (b *BlobStore) Put(data []byte) common.Hash {
+	hash := crypto.Keccak256Hash(data)
+	header := b.header(hash)
+	refCount := header.Get(blobRefCountIndex)
+	if count := refCount.Uint64(); count > 0 {
+		refCount.SetUint64(count + 1)
+		return hash
+	}
+	size := uint64(len(data))
+	if size > 0 {
+		chunks := b.chunks(hash, size)
+		for ii := 0; ii < chunks.Length(); ii++ {
+			var chunk common.Hash
+			copy(chunk[:], data[ii*32:])
+			chunks.Get(ii).SetBytes32(chunk)
+		}
+	}
+	header.Get(blobSizeIndex).SetUint64(size)
+	refCount.SetUint64(1)
+	return hash
+}
+
+// Release drops a reference to the blob, deleting it if it was the last one.
+func (b *BlobStore) Release(hash common.Hash) {
+	header := b.header(hash)
+	refCount := header.Get(blobRefCountIndex)
+	count := refCount.Uint64()
+	if count == 0 {
+		return
+	}
+	if count > 1 {
+		refCount.SetUint64(count - 1)
+		return
+	}
+	sizeSlot := header.Get(blobSizeIndex)
+	if size := sizeSlot.Uint64(); size > 0 {
+		chunks := b.chunks(hash, size)
+		for ii := 0; ii < chunks.Length(); ii++ {
+			chunks.Get(ii).SetBytes32(common.Hash{})
+		}
+	}
+	sizeSlot.SetUint64(0)
+	refCount.SetUint64(0)
+}
+
+func (b *BlobStore) Has(hash common.Hash) bool {
+	return b.RefCount(hash) > 0
+}
+
+func (b *BlobStore) RefCount(hash common.Hash) uint64 {
+	return b.header(hash).Get(blobRefCountIndex).Uint64()
+}
+
+func (b *BlobStore) Size(hash common.Hash) uint64 {
+	return b.header(hash).Get(blobSizeIndex).Uint64()
+}
+
+// Get returns the whole blob, or nil if it is not in the store.
+func (b *BlobStore) Get(hash common.Hash) []byte {
+	if !b.Has(hash) {
+		return nil
+	}
+	return b.GetRange(hash, 0, b.Size(hash))
+}
+
+// GetRange returns up to length bytes of the blob starting at offset, reading
+// only the chunks that overlap the range.
+func (b *BlobStore) GetRange(hash common.Hash, offset uint64, length uint64) []byte {
+	if !b.Has(hash) {
+		return nil
+	}
+	size := b.Size(hash)
+	if offset >= size || length == 0 {
+		return []byte{}
+	}
+	if length > size-offset {
+		length = size - offset
+	}
+	var (
+		data   = make([]byte, length)
+		chunks = b.chunks(hash, size)
+		first  = offset / 32
+		last   = (offset + length - 1) / 32
+	)
+	for ii := first; ii <= last; ii++ {
+		chunk := chunks.Get(int(ii)).Bytes32()
+		if start := ii * 32; start < offset {
+			copy(data, chunk[offset-start:])
+		} else {
+			copy(data[start-offset:], chunk[:])
+		}
+	}
+	return data
+}
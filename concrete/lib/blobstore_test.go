@@ -0,0 +1,98 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobStore(t *testing.T) {
+	var (
+		r          = require.New(t)
+		slot, _, _ = newSlot("blobstore.test")
+		store      = NewBlobStore(slot)
+		data       = bytes.Repeat([]byte("0123456789"), 10)
+	)
+
+	hash := crypto.Keccak256Hash(data)
+	r.False(store.Has(hash))
+	r.Nil(store.Get(hash))
+
+	r.Equal(hash, store.Put(data))
+	r.Equal(hash, store.Put(data))
+	r.Equal(uint64(2), store.RefCount(hash))
+	r.Equal(uint64(len(data)), store.Size(hash))
+	r.Equal(data, store.Get(hash))
+
+	ranges := [][2]uint64{{0, 0}, {0, 1}, {0, 32}, {5, 40}, {31, 2}, {64, 36}, {90, 100}, {100, 1}}
+	for _, rng := range ranges {
+		offset, length := rng[0], rng[1]
+		end := offset + length
+		if end > uint64(len(data)) {
+			end = uint64(len(data))
+		}
+		r.Equal(data[offset:end], store.GetRange(hash, offset, length))
+	}
+
+	store.Release(hash)
+	r.True(store.Has(hash))
+	store.Release(hash)
+	r.False(store.Has(hash))
+	r.Equal(uint64(0), store.Size(hash))
+
+	// No chunks are left behind
+	chunks := slot.Datastore().Get(crypto.Keccak256(store.entry(hash).Slot().Bytes())).SlotArray([]int{4})
+	for ii := 0; ii < 4; ii++ {
+		r.Equal(common.Hash{}, chunks.Get(ii).Bytes32())
+	}
+
+	empty := store.Put(nil)
+	r.True(store.Has(empty))
+	r.Equal([]byte{}, store.Get(empty))
+}
+
+func TestBlobStoreGas(t *testing.T) {
+	var (
+		r       = require.New(t)
+		address = common.HexToAddress("0xc0ffee0001")
+		env     = mock.NewMockEnvironment(address, api.EnvConfig{}, true, 1e8)
+		store   = NewBlobStore(NewPersistentDatastore(env).Get([]byte("blobstore.gas")))
+		data    = bytes.Repeat([]byte{0xff}, 32*32)
+	)
+
+	gas := env.Gas()
+	store.Put(data)
+	firstPutGas := gas - env.Gas()
+
+	gas = env.Gas()
+	store.Put(data)
+	secondPutGas := gas - env.Gas()
+
+	r.NoError(env.Error())
+	r.Less(secondPutGas*10, firstPutGas)
+}
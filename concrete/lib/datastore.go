@@ -75,14 +75,28 @@ type Datastore interface {
 	Get(key []byte) DatastoreSlot
 }
 
+// EventLogger emits EVM logs, e.g. an api.Environment.
+type EventLogger interface {
+	Log(topics []common.Hash, data []byte)
+}
+
 type datastore struct {
-	kv KeyValueStore
+	kv     KeyValueStore
+	logger EventLogger
 }
 
 func newDatastore(kv KeyValueStore) *datastore {
 	return &datastore{kv: kv}
 }
 
+// Log emits a log through the environment of persistent datastores and is a
+// no-op otherwise.
+func (ds *datastore) Log(topics []common.Hash, data []byte) {
+	if ds.logger != nil {
+		ds.logger.Log(topics, data)
+	}
+}
+
 func (ds *datastore) value(key []byte) *dsSlot {
 	if len(key) > 32 {
 		key = crypto.Keccak256(key)
@@ -95,11 +109,16 @@ func (ds *datastore) Get(key []byte) DatastoreSlot {
 	return ds.value(key)
 }
 
-var _ Datastore = (*datastore)(nil)
+var (
+	_ Datastore   = (*datastore)(nil)
+	_ EventLogger = (*datastore)(nil)
+)
 
 func NewPersistentDatastore(env api.Environment) Datastore {
 	kv := newEnvPersistentKeyValueStore(env)
-	return newDatastore(kv)
+	ds := newDatastore(kv)
+	ds.logger = env
+	return ds
 }
 
 func NewEphemeralDatastore(env api.Environment) Datastore {
@@ -107,6 +126,12 @@ func NewEphemeralDatastore(env api.Environment) Datastore {
 	return newDatastore(kv)
 }
 
+// NewKVDatastore returns a datastore over an arbitrary key value store, e.g.
+// to read precompile storage outside of the EVM.
+func NewKVDatastore(kv KeyValueStore) Datastore {
+	return newDatastore(kv)
+}
+
 func NewDatastore(env api.Environment) Datastore {
 	return NewPersistentDatastore(env)
 }
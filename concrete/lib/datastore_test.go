@@ -188,3 +188,38 @@ func TestDynamicArray(t *testing.T) {
 		return array.GetNested(1, 0) // slot1_0
 	})
 }
+
+func TestDatastoreSnapshot(t *testing.T) {
+	var (
+		r        = require.New(t)
+		address  = common.HexToAddress("0xc0ffee0001")
+		config   = api.EnvConfig{}
+		meterGas = true
+		gas      = uint64(1e6)
+	)
+	env := mock.NewMockEnvironment(address, config, meterGas, gas)
+	ds := NewPersistentDatastore(env)
+	slot := ds.Get([]byte("snapshot.test"))
+
+	slot.SetUint64(1)
+	outer := env.Snapshot()
+	slot.SetUint64(2)
+	inner := env.Snapshot()
+	slot.SetUint64(3)
+	r.Equal(uint64(3), slot.Uint64())
+
+	env.RevertToSnapshot(inner)
+	r.Equal(uint64(2), slot.Uint64())
+	r.NoError(env.Error())
+
+	// Gas used by discarded writes is not returned
+	gasBefore := env.Gas()
+	env.RevertToSnapshot(outer)
+	r.Equal(gasBefore-api.GasFastStep, env.Gas())
+	r.Equal(uint64(1), slot.Uint64())
+	r.NoError(env.Error())
+
+	// Snapshots are invalidated once reverted to an earlier one
+	env.RevertToSnapshot(inner)
+	r.ErrorIs(env.Error(), api.ErrInvalidSnapshot)
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// HashSet is an enumerable set of hashes. Values are kept in a DynamicArray
+// and their positions in a mapping at keccak256(slot), so that adding,
+// removing and checking membership are constant-time. Removing a value moves
+// the last value into its position.
+type HashSet struct {
+	dsSlot    DatastoreSlot
+	values    DynamicArray
+	positions Mapping
+}
+
+func NewHashSet(dsSlot DatastoreSlot) *HashSet {
+	positionsSlot := dsSlot.Datastore().Get(crypto.Keccak256(dsSlot.Slot().Bytes()))
+	return &HashSet{
+		dsSlot:    dsSlot,
+		values:    dsSlot.DynamicArray(),
+		positions: positionsSlot.Mapping(),
+	}
+}
+
+func (s *HashSet) Slot() DatastoreSlot {
+	return s.dsSlot
+}
+
+// Positions are stored off by one so that zero means absent.
+func (s *HashSet) position(value common.Hash) DatastoreSlot {
+	return s.positions.Get(value.Bytes())
+}
+
+func (s *HashSet) Has(value common.Hash) bool {
+	return s.position(value).Uint64() > 0
+}
+
+// Add returns false if the value was already in the set.
+func (s *HashSet) Add(value common.Hash) bool {
+	position := s.position(value)
+	if position.Uint64() > 0 {
+		return false
+	}
+	s.values.Push().SetBytes32(value)
+	position.SetUint64(s.values.Length())
+	return true
+}
+
+// Remove returns false if the value was not in the set.
+func (s *HashSet) Remove(value common.Hash) bool {
+	position := s.position(value)
+	index := position.Uint64()
+	if index == 0 {
+		return false
+	}
+	lastIndex := s.values.Length()
+	if index != lastIndex {
+		last := s.values.Get(lastIndex - 1).Bytes32()
+		s.values.Get(index - 1).SetBytes32(last)
+		s.position(last).SetUint64(index)
+	}
+	s.values.Pop().SetBytes32(common.Hash{})
+	position.SetUint64(0)
+	return true
+}
+
+func (s *HashSet) Length() uint64 {
+	return s.values.Length()
+}
+
+// Get panics if the index is out of range.
+func (s *HashSet) Get(index uint64) common.Hash {
+	dsSlot := s.values.Get(index)
+	if dsSlot == nil {
+		panic("index out of range")
+	}
+	return dsSlot.Bytes32()
+}
+
+// Values returns up to limit values starting at offset.
+func (s *HashSet) Values(offset uint64, limit uint64) []common.Hash {
+	length := s.Length()
+	if offset >= length {
+		return []common.Hash{}
+	}
+	if limit > length-offset {
+		limit = length - offset
+	}
+	values := make([]common.Hash, limit)
+	for ii := range values {
+		values[ii] = s.values.Get(offset + uint64(ii)).Bytes32()
+	}
+	return values
+}
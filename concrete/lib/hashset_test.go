@@ -0,0 +1,62 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashSet(t *testing.T) {
+	var (
+		r          = require.New(t)
+		slot, _, _ = newSlot("hashset.test")
+		set        = NewHashSet(slot)
+		a, b, c    = common.Hash{0x0a}, common.Hash{0x0b}, common.Hash{0x0c}
+	)
+
+	r.Equal(uint64(0), set.Length())
+	r.False(set.Has(a))
+	r.False(set.Remove(a))
+	r.Equal([]common.Hash{}, set.Values(0, 10))
+
+	r.True(set.Add(a))
+	r.True(set.Add(b))
+	r.True(set.Add(c))
+	r.False(set.Add(b))
+	r.Equal(uint64(3), set.Length())
+	r.Equal([]common.Hash{a, b, c}, set.Values(0, 10))
+	r.Equal([]common.Hash{b}, set.Values(1, 1))
+	r.Equal([]common.Hash{}, set.Values(3, 1))
+
+	// The last value takes the place of the removed one
+	r.True(set.Remove(a))
+	r.False(set.Has(a))
+	r.Equal([]common.Hash{c, b}, set.Values(0, 10))
+	r.True(set.Remove(b))
+	r.Equal([]common.Hash{c}, set.Values(0, 10))
+	r.True(set.Add(a))
+	r.Equal([]common.Hash{c, a}, set.Values(0, 10))
+	r.Equal(a, set.Get(1))
+	r.Panics(func() { set.Get(2) })
+}
@@ -0,0 +1,368 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// The sparse merkle tree is a compact binary tree of depth 256 indexed by the
+// keccak256 hash of the key. Subtrees containing a single leaf are replaced by
+// the leaf itself and empty subtrees hash to zero, so only O(log n) nodes are
+// stored and hashed per update.
+//
+// Hashing:
+//   empty          = 0x00...00
+//   leaf(p, v)     = keccak256(0x00 || p || keccak256(v))
+//   node(l, r)     = keccak256(0x01 || l || r)
+
+const (
+	smtDepth = 256
+
+	smtLeafKind     = 1
+	smtInternalKind = 2
+)
+
+var (
+	smtLeafPrefix     = []byte{0x00}
+	smtInternalPrefix = []byte{0x01}
+
+	smtRootKey       = []byte("smt.root")
+	smtNodesKey      = []byte("smt.nodes")
+	smtValuesKey     = []byte("smt.values")
+	smtPendingKey    = []byte("smt.pending")
+	smtPendingIdxKey = []byte("smt.pendingIdx")
+	smtPendingValKey = []byte("smt.pendingVal")
+)
+
+type SparseMerkleProof struct {
+	// Siblings of the nodes in the path from the root to the leaf, ordered
+	// from the root down.
+	SideNodes []common.Hash
+	// For non-membership proofs, the leaf found in place of the key, if any.
+	LeafPath      common.Hash
+	LeafValueHash common.Hash
+}
+
+func (p *SparseMerkleProof) hasLeaf() bool {
+	return p.LeafPath != (common.Hash{}) || p.LeafValueHash != (common.Hash{})
+}
+
+type SparseMerkleTree struct {
+	root        DatastoreSlot
+	nodes       Mapping
+	values      Mapping
+	pendingSlot DatastoreSlot
+	pending     DynamicArray
+	pendingIdx  Mapping
+	pendingVal  Mapping
+	deferred    bool
+}
+
+func newSparseMerkleTree(dsSlot DatastoreSlot, deferred bool) *SparseMerkleTree {
+	var (
+		m           = dsSlot.Mapping()
+		pendingSlot = m.Get(smtPendingKey)
+	)
+	return &SparseMerkleTree{
+		root:        m.Get(smtRootKey),
+		nodes:       m.Get(smtNodesKey).Mapping(),
+		values:      m.Get(smtValuesKey).Mapping(),
+		pendingSlot: pendingSlot,
+		pending:     pendingSlot.DynamicArray(),
+		pendingIdx:  m.Get(smtPendingIdxKey).Mapping(),
+		pendingVal:  m.Get(smtPendingValKey).Mapping(),
+		deferred:    deferred,
+	}
+}
+
+// NewSparseMerkleTree returns a tree that updates its root on every Set.
+func NewSparseMerkleTree(dsSlot DatastoreSlot) *SparseMerkleTree {
+	return newSparseMerkleTree(dsSlot, false)
+}
+
+// NewDeferredSparseMerkleTree returns a tree that only updates its root when
+// Commit is called, e.g. from the precompile Finalise hook, or at the end of
+// Run. The precompile Commit hook is static and cannot update the root.
+// Root and Prove reflect the last committed state.
+func NewDeferredSparseMerkleTree(dsSlot DatastoreSlot) *SparseMerkleTree {
+	return newSparseMerkleTree(dsSlot, true)
+}
+
+func (t *SparseMerkleTree) Root() common.Hash {
+	return t.root.Bytes32()
+}
+
+func (t *SparseMerkleTree) Get(key []byte) []byte {
+	return t.values.Get(key).Bytes()
+}
+
+// Set stores the value under the given key. Setting an empty value removes
+// the key from the tree.
+func (t *SparseMerkleTree) Set(key []byte, value []byte) {
+	t.values.Get(key).SetBytes(value)
+	path, valueHash := smtPath(key), smtValueHash(value)
+	if t.deferred {
+		t.addPending(path, valueHash)
+		return
+	}
+	t.update(path, valueHash)
+}
+
+// Commit applies all pending updates to the tree. It is a no-op for trees that
+// are not deferred.
+func (t *SparseMerkleTree) Commit() {
+	length := t.pending.Length()
+	for ii := uint64(0); ii < length; ii++ {
+		slot := t.pending.Get(ii)
+		path := slot.Bytes32()
+		t.update(path, t.pendingVal.Get(path.Bytes()).Bytes32())
+		slot.SetBytes32(common.Hash{})
+		t.pendingIdx.Get(path.Bytes()).SetUint64(0)
+		t.pendingVal.Get(path.Bytes()).SetBytes32(common.Hash{})
+	}
+	if length > 0 {
+		// The length of a dynamic array is stored in its own slot
+		t.pendingSlot.SetUint64(0)
+	}
+}
+
+// addPending records an update to apply on Commit. Pending updates are kept
+// in fixed size slots, so no data is left behind once they are applied.
+func (t *SparseMerkleTree) addPending(path, valueHash common.Hash) {
+	t.pendingVal.Get(path.Bytes()).SetBytes32(valueHash)
+	idx := t.pendingIdx.Get(path.Bytes())
+	if idx.Uint64() != 0 {
+		return
+	}
+	t.pending.Push().SetBytes32(path)
+	idx.SetUint64(t.pending.Length())
+}
+
+func (t *SparseMerkleTree) update(path, valueHash common.Hash) {
+	oldRoot := t.Root()
+	newRoot := t.updateNode(oldRoot, 0, path, valueHash)
+	if newRoot != oldRoot {
+		t.root.SetBytes32(newRoot)
+	}
+}
+
+func (t *SparseMerkleTree) updateNode(node common.Hash, depth int, path common.Hash, valueHash common.Hash) common.Hash {
+	if node == (common.Hash{}) {
+		if valueHash == (common.Hash{}) {
+			return common.Hash{}
+		}
+		return t.putLeaf(path, valueHash)
+	}
+
+	kind, a, b := t.getNode(node)
+
+	if kind == smtLeafKind {
+		leafPath := a
+		if leafPath == path {
+			if valueHash == (common.Hash{}) {
+				t.deleteNode(node)
+				return common.Hash{}
+			}
+			newLeaf := t.putLeaf(path, valueHash)
+			if newLeaf != node {
+				t.deleteNode(node)
+			}
+			return newLeaf
+		}
+		if valueHash == (common.Hash{}) {
+			// Deleting a key that is not in the tree
+			return node
+		}
+		newLeaf := t.putLeaf(path, valueHash)
+		return t.join(depth, node, leafPath, newLeaf, path)
+	}
+
+	left, right := a, b
+	if smtBit(path, depth) == 0 {
+		left = t.updateNode(left, depth+1, path, valueHash)
+	} else {
+		right = t.updateNode(right, depth+1, path, valueHash)
+	}
+	if left == a && right == b {
+		return node
+	}
+	t.deleteNode(node)
+	return t.putInternalOrCollapse(left, right)
+}
+
+// join builds the internal nodes required to hold two leaves whose paths
+// share a prefix of at least depth bits.
+func (t *SparseMerkleTree) join(depth int, aHash, aPath, bHash, bPath common.Hash) common.Hash {
+	aBit, bBit := smtBit(aPath, depth), smtBit(bPath, depth)
+	if aBit != bBit {
+		if aBit == 0 {
+			return t.putInternal(aHash, bHash)
+		}
+		return t.putInternal(bHash, aHash)
+	}
+	child := t.join(depth+1, aHash, aPath, bHash, bPath)
+	if aBit == 0 {
+		return t.putInternal(child, common.Hash{})
+	}
+	return t.putInternal(common.Hash{}, child)
+}
+
+// putInternalOrCollapse stores an internal node unless it holds a single
+// leaf, in which case the leaf is promoted in its place.
+func (t *SparseMerkleTree) putInternalOrCollapse(left, right common.Hash) common.Hash {
+	if left == (common.Hash{}) && right == (common.Hash{}) {
+		return common.Hash{}
+	}
+	if left == (common.Hash{}) || right == (common.Hash{}) {
+		child := left
+		if child == (common.Hash{}) {
+			child = right
+		}
+		if kind, _, _ := t.getNode(child); kind == smtLeafKind {
+			return child
+		}
+	}
+	return t.putInternal(left, right)
+}
+
+func (t *SparseMerkleTree) nodeSlots(hash common.Hash) SlotArray {
+	return t.nodes.Get(hash.Bytes()).SlotArray([]int{3})
+}
+
+func (t *SparseMerkleTree) getNode(hash common.Hash) (int, common.Hash, common.Hash) {
+	arr := t.nodeSlots(hash)
+	return int(arr.Get(0).Uint64()), arr.Get(1).Bytes32(), arr.Get(2).Bytes32()
+}
+
+func (t *SparseMerkleTree) putNode(kind int, hash, a, b common.Hash) common.Hash {
+	arr := t.nodeSlots(hash)
+	arr.Get(0).SetUint64(uint64(kind))
+	arr.Get(1).SetBytes32(a)
+	arr.Get(2).SetBytes32(b)
+	return hash
+}
+
+func (t *SparseMerkleTree) putLeaf(path, valueHash common.Hash) common.Hash {
+	return t.putNode(smtLeafKind, smtLeafHash(path, valueHash), path, valueHash)
+}
+
+func (t *SparseMerkleTree) putInternal(left, right common.Hash) common.Hash {
+	return t.putNode(smtInternalKind, smtInternalHash(left, right), left, right)
+}
+
+func (t *SparseMerkleTree) deleteNode(hash common.Hash) {
+	arr := t.nodeSlots(hash)
+	for ii := 0; ii < 3; ii++ {
+		arr.Get(ii).SetBytes32(common.Hash{})
+	}
+}
+
+// Prove returns a proof of membership or non-membership of the key against
+// the current root.
+func (t *SparseMerkleTree) Prove(key []byte) *SparseMerkleProof {
+	var (
+		path  = crypto.Keccak256Hash(key)
+		node  = t.Root()
+		proof = &SparseMerkleProof{}
+	)
+	for depth := 0; depth < smtDepth; depth++ {
+		if node == (common.Hash{}) {
+			return proof
+		}
+		kind, a, b := t.getNode(node)
+		if kind == smtLeafKind {
+			if a != path {
+				proof.LeafPath = a
+				proof.LeafValueHash = b
+			}
+			return proof
+		}
+		if smtBit(path, depth) == 0 {
+			proof.SideNodes = append(proof.SideNodes, b)
+			node = a
+		} else {
+			proof.SideNodes = append(proof.SideNodes, a)
+			node = b
+		}
+	}
+	return proof
+}
+
+// VerifySparseMerkleProof checks a proof returned by Prove. An empty value
+// verifies non-membership.
+func VerifySparseMerkleProof(root common.Hash, key []byte, value []byte, proof *SparseMerkleProof) bool {
+	if proof == nil || len(proof.SideNodes) > smtDepth {
+		return false
+	}
+	var (
+		path    = crypto.Keccak256Hash(key)
+		current common.Hash
+	)
+	if len(value) == 0 {
+		if proof.hasLeaf() {
+			if proof.LeafPath == path {
+				return false
+			}
+			current = smtLeafHash(proof.LeafPath, proof.LeafValueHash)
+		}
+	} else {
+		if proof.hasLeaf() {
+			return false
+		}
+		current = smtLeafHash(path, crypto.Keccak256Hash(value))
+	}
+	for depth := len(proof.SideNodes) - 1; depth >= 0; depth-- {
+		if smtBit(path, depth) == 0 {
+			current = smtHashChildren(current, proof.SideNodes[depth])
+		} else {
+			current = smtHashChildren(proof.SideNodes[depth], current)
+		}
+	}
+	return current == root
+}
+
+func smtPath(key []byte) common.Hash {
+	return crypto.Keccak256Hash(key)
+}
+
+// smtValueHash returns the hash of a value, or zero for the empty value.
+func smtValueHash(value []byte) common.Hash {
+	if len(value) == 0 {
+		return common.Hash{}
+	}
+	return crypto.Keccak256Hash(value)
+}
+
+func smtHashChildren(left, right common.Hash) common.Hash {
+	if left == (common.Hash{}) && right == (common.Hash{}) {
+		return common.Hash{}
+	}
+	return smtInternalHash(left, right)
+}
+
+func smtLeafHash(path, valueHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash(smtLeafPrefix, path.Bytes(), valueHash.Bytes())
+}
+
+func smtInternalHash(left, right common.Hash) common.Hash {
+	return crypto.Keccak256Hash(smtInternalPrefix, left.Bytes(), right.Bytes())
+}
+
+func smtBit(path common.Hash, depth int) int {
+	return int(path[depth/8]>>(7-uint(depth%8))) & 1
+}
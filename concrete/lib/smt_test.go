@@ -0,0 +1,163 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func smtTestKV(n int) ([][]byte, [][]byte) {
+	keys := make([][]byte, n)
+	values := make([][]byte, n)
+	for ii := 0; ii < n; ii++ {
+		keys[ii] = []byte(fmt.Sprintf("key%d", ii))
+		values[ii] = []byte(fmt.Sprintf("value%d", ii))
+	}
+	return keys, values
+}
+
+func TestSparseMerkleTree(t *testing.T) {
+	var (
+		r            = require.New(t)
+		slot, _, _   = newSlot("smt.test")
+		tree         = NewSparseMerkleTree(slot)
+		keys, values = smtTestKV(16)
+	)
+
+	r.Equal(common.Hash{}, tree.Root())
+	r.True(VerifySparseMerkleProof(tree.Root(), keys[0], nil, tree.Prove(keys[0])))
+
+	roots := []common.Hash{tree.Root()}
+	for ii := range keys {
+		tree.Set(keys[ii], values[ii])
+		r.NotEqual(roots[len(roots)-1], tree.Root())
+		roots = append(roots, tree.Root())
+	}
+
+	for ii := range keys {
+		r.Equal(values[ii], tree.Get(keys[ii]))
+		proof := tree.Prove(keys[ii])
+		r.True(VerifySparseMerkleProof(tree.Root(), keys[ii], values[ii], proof))
+		r.False(VerifySparseMerkleProof(tree.Root(), keys[ii], []byte("wrong"), proof))
+		r.False(VerifySparseMerkleProof(tree.Root(), keys[ii], nil, proof))
+	}
+
+	missing := []byte("missing")
+	proof := tree.Prove(missing)
+	r.True(VerifySparseMerkleProof(tree.Root(), missing, nil, proof))
+	r.False(VerifySparseMerkleProof(tree.Root(), missing, []byte("value"), proof))
+
+	// Removing keys in reverse order must go back through the same roots
+	for ii := len(keys) - 1; ii >= 0; ii-- {
+		tree.Set(keys[ii], nil)
+		r.Equal(roots[ii], tree.Root())
+		r.True(VerifySparseMerkleProof(tree.Root(), keys[ii], nil, tree.Prove(keys[ii])))
+	}
+}
+
+func TestSparseMerkleTreeOrderIndependence(t *testing.T) {
+	var (
+		r            = require.New(t)
+		slotA, _, _  = newSlot("smt.test.a")
+		slotB, _, _  = newSlot("smt.test.b")
+		treeA        = NewSparseMerkleTree(slotA)
+		treeB        = NewSparseMerkleTree(slotB)
+		keys, values = smtTestKV(16)
+	)
+	for ii := range keys {
+		treeA.Set(keys[ii], values[ii])
+	}
+	for ii := len(keys) - 1; ii >= 0; ii-- {
+		treeB.Set(keys[ii], []byte("overwritten"))
+		treeB.Set(keys[ii], values[ii])
+	}
+	r.Equal(treeA.Root(), treeB.Root())
+}
+
+func TestDeferredSparseMerkleTree(t *testing.T) {
+	var (
+		r            = require.New(t)
+		slotA, _, _  = newSlot("smt.test.eager")
+		slotB, _, _  = newSlot("smt.test.deferred")
+		eager        = NewSparseMerkleTree(slotA)
+		deferred     = NewDeferredSparseMerkleTree(slotB)
+		keys, values = smtTestKV(8)
+	)
+	for ii := range keys {
+		eager.Set(keys[ii], values[ii])
+		deferred.Set(keys[ii], values[ii])
+		deferred.Set(keys[ii], values[ii])
+	}
+	eager.Set(keys[0], nil)
+	deferred.Set(keys[0], nil)
+
+	r.Equal(common.Hash{}, deferred.Root())
+	r.Equal(values[1], deferred.Get(keys[1]))
+
+	deferred.Commit()
+	r.Equal(eager.Root(), deferred.Root())
+	r.Equal(uint64(0), deferred.pending.Length())
+
+	for ii := 1; ii < len(keys); ii++ {
+		r.True(VerifySparseMerkleProof(deferred.Root(), keys[ii], values[ii], deferred.Prove(keys[ii])))
+	}
+
+	// Committing again without changes is a no-op
+	root := deferred.Root()
+	deferred.Commit()
+	r.Equal(root, deferred.Root())
+}
+
+type smtTestStore map[common.Hash]common.Hash
+
+func (kv smtTestStore) Set(key common.Hash, value common.Hash) { kv[key] = value }
+func (kv smtTestStore) Get(key common.Hash) common.Hash        { return kv[key] }
+
+func TestDeferredSparseMerkleTreeStorage(t *testing.T) {
+	var (
+		r    = require.New(t)
+		kv   = smtTestStore{}
+		tree = NewDeferredSparseMerkleTree(NewKVDatastore(kv).Get([]byte("smt.test")))
+		keys = make([][]byte, 8)
+	)
+	for ii := range keys {
+		// Keys longer than a slot
+		keys[ii] = []byte(fmt.Sprintf("a key longer than thirty two bytes %d", ii))
+		tree.Set(keys[ii], []byte("value"))
+	}
+	tree.Commit()
+	r.NotEqual(common.Hash{}, tree.Root())
+	for ii := range keys {
+		r.True(VerifySparseMerkleProof(tree.Root(), keys[ii], []byte("value"), tree.Prove(keys[ii])))
+		tree.Set(keys[ii], nil)
+	}
+	tree.Commit()
+	r.Equal(common.Hash{}, tree.Root())
+
+	// Removing all the keys must not leave anything behind in storage
+	for slot, value := range kv {
+		r.Equal(common.Hash{}, value, "slot %x not cleared", slot)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// StorageViewSignature is the signature of the method added by
+// WithStorageView. It returns the values of the given slots of the
+// persistent storage of the precompile, so that contracts can read datamod
+// tables directly, e.g. through the Solidity libraries generated by datamod.
+const StorageViewSignature = "datamod_load(bytes32[])"
+
+var (
+	StorageViewSelector = crypto.Keccak256([]byte(StorageViewSignature))[:4]
+
+	errInvalidStorageViewInput = errors.New("invalid datamod_load input")
+)
+
+type storageViewPrecompile struct {
+	concrete.Precompile
+}
+
+// WithStorageView wraps a precompile so that it also serves reads of its
+// storage through StorageViewSignature. All other calls are forwarded to the
+// wrapped precompile.
+func WithStorageView(pc concrete.Precompile) concrete.Precompile {
+	return &storageViewPrecompile{pc}
+}
+
+func isStorageViewCall(input []byte) bool {
+	return len(input) >= 4 && bytes.Equal(input[:4], StorageViewSelector)
+}
+
+func (pc *storageViewPrecompile) IsStatic(input []byte) bool {
+	if isStorageViewCall(input) {
+		return true
+	}
+	return pc.Precompile.IsStatic(input)
+}
+
+func (pc *storageViewPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+	if !isStorageViewCall(input) {
+		return pc.Precompile.Run(env, input)
+	}
+	slots, err := decodeBytes32Array(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	output := make([]byte, 0, 64+32*len(slots))
+	output = append(output, abiWord(32)...)
+	output = append(output, abiWord(uint64(len(slots)))...)
+	for _, slot := range slots {
+		output = append(output, env.PersistentLoad(slot).Bytes()...)
+	}
+	return output, nil
+}
+
+// decodeBytes32Array decodes an ABI encoded tuple holding a single bytes32[].
+func decodeBytes32Array(data []byte) ([]common.Hash, error) {
+	offset, ok := abiReadWord(data, 0)
+	if !ok || offset > uint64(len(data)) {
+		return nil, errInvalidStorageViewInput
+	}
+	length, ok := abiReadWord(data, offset)
+	if !ok || length > uint64(len(data))/32 {
+		return nil, errInvalidStorageViewInput
+	}
+	start := offset + 32
+	if start+32*length > uint64(len(data)) {
+		return nil, errInvalidStorageViewInput
+	}
+	slots := make([]common.Hash, length)
+	for ii := range slots {
+		slots[ii] = common.BytesToHash(data[start+32*uint64(ii) : start+32*uint64(ii+1)])
+	}
+	return slots, nil
+}
+
+// abiReadWord reads a word at the given offset, if it fits in a uint64.
+func abiReadWord(data []byte, offset uint64) (uint64, bool) {
+	if offset+32 > uint64(len(data)) {
+		return 0, false
+	}
+	word := data[offset : offset+32]
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return binary.BigEndian.Uint64(word[24:]), true
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type echoPrecompile struct {
+	BlankPrecompile
+}
+
+func (pc *echoPrecompile) IsStatic(input []byte) bool {
+	return false
+}
+
+func (pc *echoPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+	return input, nil
+}
+
+func TestStorageView(t *testing.T) {
+	var (
+		r           = require.New(t)
+		address     = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		env         = mock.NewMockEnvironment(address, api.EnvConfig{}, false, 0)
+		pc          = WithStorageView(&echoPrecompile{})
+		parsed, err = abi.JSON(strings.NewReader(`[{"type": "function", "name": "datamod_load", "inputs": [{"name": "slots", "type": "bytes32[]"}], "outputs": [{"name": "values", "type": "bytes32[]"}]}]`))
+	)
+	r.NoError(err)
+	r.Equal(StorageViewSelector, parsed.Methods["datamod_load"].ID)
+
+	ds := NewDatastore(env)
+	row := NewDatastoreStruct(ds.Get([]byte("row")), []int{32, 4})
+	row.SetField(0, common.Hash{0x01}.Bytes())
+	row.SetField(1, []byte{0x02, 0x03, 0x04, 0x05})
+
+	slots := []common.Hash{
+		row.GetField_slot(0).Slot(),
+		row.GetField_slot(1).Slot(),
+		common.HexToHash("0xff"),
+	}
+	input, err := parsed.Pack("datamod_load", slots)
+	r.NoError(err)
+	r.True(pc.IsStatic(input))
+
+	output, err := pc.Run(env, input)
+	r.NoError(err)
+	values, err := parsed.Unpack("datamod_load", output)
+	r.NoError(err)
+	r.Equal([][32]byte{
+		common.Hash{0x01},
+		common.Hash{0x02, 0x03, 0x04, 0x05},
+		{},
+	}, values[0])
+
+	input, err = parsed.Pack("datamod_load", []common.Hash{})
+	r.NoError(err)
+	output, err = pc.Run(env, input)
+	r.NoError(err)
+	values, err = parsed.Unpack("datamod_load", output)
+	r.NoError(err)
+	r.Empty(values[0])
+
+	// Other calls are forwarded to the wrapped precompile.
+	output, err = pc.Run(env, []byte{0x01, 0x02, 0x03, 0x04, 0x05})
+	r.NoError(err)
+	r.Equal([]byte{0x01, 0x02, 0x03, 0x04, 0x05}, output)
+	r.False(pc.IsStatic([]byte{0x01, 0x02, 0x03, 0x04}))
+
+	for _, args := range [][]byte{
+		nil,
+		make([]byte, 31),
+		common.BigToHash(common.Big32).Bytes(),
+		append(common.BigToHash(common.Big32).Bytes(), common.BigToHash(common.Big2).Bytes()...),
+	} {
+		input := append(common.CopyBytes(StorageViewSelector), args...)
+		_, err := pc.Run(env, input)
+		r.ErrorIs(err, errInvalidStorageViewInput)
+	}
+}
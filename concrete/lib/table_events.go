@@ -0,0 +1,124 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// Table events are ABI encoded so they can be decoded by any EVM tooling:
+//
+//	event Datamod_SetField(bytes32 indexed tableId, bytes32 indexed tableSlot, bytes[] keys, uint8 field, bytes data)
+//	event Datamod_SetArray(bytes32 indexed tableId, bytes32 indexed tableSlot, bytes[] keys, uint8 field, bytes[] data)
+//	event Datamod_DeleteRecord(bytes32 indexed tableId, bytes32 indexed tableSlot, bytes[] keys)
+//
+// The table ID identifies the schema of the table and the table slot the
+// instance, as tables can be nested or stored at arbitrary slots. Keys and
+// fields are encoded as they are stored, and fields are identified by their
+// index in the row.
+var (
+	SetFieldEventID     = crypto.Keccak256Hash([]byte("Datamod_SetField(bytes32,bytes32,bytes[],uint8,bytes)"))
+	SetArrayEventID     = crypto.Keccak256Hash([]byte("Datamod_SetArray(bytes32,bytes32,bytes[],uint8,bytes[])"))
+	DeleteRecordEventID = crypto.Keccak256Hash([]byte("Datamod_DeleteRecord(bytes32,bytes32,bytes[])"))
+)
+
+// TableEvents emits the change events of a row. Events are dropped when the
+// datastore does not support logging.
+type TableEvents struct {
+	logger    EventLogger
+	tableID   common.Hash
+	tableSlot common.Hash
+	keys      [][]byte
+}
+
+func NewTableEvents(tableID []byte, tableSlot DatastoreSlot, keys [][]byte) *TableEvents {
+	logger, _ := tableSlot.Datastore().(EventLogger)
+	return &TableEvents{
+		logger:    logger,
+		tableID:   common.BytesToHash(tableID),
+		tableSlot: tableSlot.Slot(),
+		keys:      keys,
+	}
+}
+
+func (e *TableEvents) log(eventID common.Hash, data []byte) {
+	if e.logger == nil {
+		return
+	}
+	e.logger.Log([]common.Hash{eventID, e.tableID, e.tableSlot}, data)
+}
+
+func (e *TableEvents) SetField(index int, data []byte) {
+	e.log(SetFieldEventID, abiEncode(e.keys, uint64(index), data))
+}
+
+func (e *TableEvents) SetArray(index int, data [][]byte) {
+	e.log(SetArrayEventID, abiEncode(e.keys, uint64(index), data))
+}
+
+func (e *TableEvents) DeleteRecord() {
+	e.log(DeleteRecordEventID, abiEncode(e.keys))
+}
+
+// abiEncode encodes a tuple of uint64, []byte and [][]byte values following
+// the ABI specification. The accounts/abi package is not used as it does not
+// build with tinygo.
+func abiEncode(values ...interface{}) []byte {
+	head := make([]byte, 0, 32*len(values))
+	var tail []byte
+	for _, value := range values {
+		switch v := value.(type) {
+		case uint64:
+			head = append(head, abiWord(v)...)
+		case []byte:
+			head = append(head, abiWord(uint64(32*len(values)+len(tail)))...)
+			tail = append(tail, abiEncodeBytes(v)...)
+		case [][]byte:
+			head = append(head, abiWord(uint64(32*len(values)+len(tail)))...)
+			tail = append(tail, abiEncodeBytesArray(v)...)
+		default:
+			panic("unsupported abi type")
+		}
+	}
+	return append(head, tail...)
+}
+
+func abiWord(value uint64) []byte {
+	word := make([]byte, 32)
+	binary.BigEndian.PutUint64(word[24:], value)
+	return word
+}
+
+func abiEncodeBytes(data []byte) []byte {
+	padded := (len(data) + 31) / 32 * 32
+	encoded := make([]byte, 32+padded)
+	copy(encoded, abiWord(uint64(len(data))))
+	copy(encoded[32:], data)
+	return encoded
+}
+
+func abiEncodeBytesArray(data [][]byte) []byte {
+	encoded := abiWord(uint64(len(data)))
+	var tail []byte
+	for _, item := range data {
+		encoded = append(encoded, abiWord(uint64(32*len(data)+len(tail)))...)
+		tail = append(tail, abiEncodeBytes(item)...)
+	}
+	return append(encoded, tail...)
+}
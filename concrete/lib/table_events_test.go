@@ -0,0 +1,104 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/stretchr/testify/require"
+)
+
+const tableEventsABI = `[
+	{"type": "event", "name": "Datamod_SetField", "inputs": [
+		{"name": "tableId", "type": "bytes32", "indexed": true},
+		{"name": "tableSlot", "type": "bytes32", "indexed": true},
+		{"name": "keys", "type": "bytes[]"},
+		{"name": "field", "type": "uint8"},
+		{"name": "data", "type": "bytes"}
+	]},
+	{"type": "event", "name": "Datamod_SetArray", "inputs": [
+		{"name": "tableId", "type": "bytes32", "indexed": true},
+		{"name": "tableSlot", "type": "bytes32", "indexed": true},
+		{"name": "keys", "type": "bytes[]"},
+		{"name": "field", "type": "uint8"},
+		{"name": "data", "type": "bytes[]"}
+	]},
+	{"type": "event", "name": "Datamod_DeleteRecord", "inputs": [
+		{"name": "tableId", "type": "bytes32", "indexed": true},
+		{"name": "tableSlot", "type": "bytes32", "indexed": true},
+		{"name": "keys", "type": "bytes[]"}
+	]}
+]`
+
+func TestTableEvents(t *testing.T) {
+	var (
+		r           = require.New(t)
+		addr        = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		statedb     = mock.NewMockStateDB().(*state.StateDB)
+		env         = api.NewEnvironment(addr, api.EnvConfig{}, statedb, api.NewMockBlockContext(), api.NewMockCallContext(), api.NewMockCaller(), false, 0)
+		ds          = NewDatastore(env)
+		tableSlot   = ds.Get([]byte("table"))
+		tableID     = []byte("id")
+		keys        = [][]byte{{0x01}, []byte(strings.Repeat("key", 20))}
+		events      = NewTableEvents(tableID, tableSlot, keys)
+		parsed, err = abi.JSON(strings.NewReader(tableEventsABI))
+	)
+	r.NoError(err)
+
+	events.SetField(3, []byte("value"))
+	events.SetArray(4, [][]byte{{0x02}, {}, []byte(strings.Repeat("elem", 10))})
+	events.DeleteRecord()
+
+	logs := statedb.Logs()
+	r.Len(logs, 3)
+	for ii, name := range []string{"Datamod_SetField", "Datamod_SetArray", "Datamod_DeleteRecord"} {
+		r.Equal([]common.Hash{parsed.Events[name].ID, common.BytesToHash(tableID), tableSlot.Slot()}, logs[ii].Topics)
+	}
+	r.Equal(SetFieldEventID, parsed.Events["Datamod_SetField"].ID)
+	r.Equal(SetArrayEventID, parsed.Events["Datamod_SetArray"].ID)
+	r.Equal(DeleteRecordEventID, parsed.Events["Datamod_DeleteRecord"].ID)
+
+	values, err := parsed.Unpack("Datamod_SetField", logs[0].Data)
+	r.NoError(err)
+	r.Equal([]interface{}{keys, uint8(3), []byte("value")}, values)
+
+	values, err = parsed.Unpack("Datamod_SetArray", logs[1].Data)
+	r.NoError(err)
+	r.Equal([]interface{}{keys, uint8(4), [][]byte{{0x02}, {}, []byte(strings.Repeat("elem", 10))}}, values)
+
+	values, err = parsed.Unpack("Datamod_DeleteRecord", logs[2].Data)
+	r.NoError(err)
+	r.Equal([]interface{}{keys}, values)
+
+	// Keyless rows and datastores without a logger
+	NewTableEvents(tableID, tableSlot, nil).DeleteRecord()
+	values, err = parsed.Unpack("Datamod_DeleteRecord", statedb.Logs()[3].Data)
+	r.NoError(err)
+	r.Equal([]interface{}{[][]byte{}}, values)
+	NewTableEvents(tableID, NewEphemeralDatastore(env).Get(nil), keys).DeleteRecord()
+	r.Len(statedb.Logs(), 4)
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// The structures below back the secondary indexes of datamod tables. They are
+// stored at slots derived from the table slot, so they never overlap rows.
+
+func tableSubSlot(tableSlot DatastoreSlot, name string) DatastoreSlot {
+	key := crypto.Keccak256(tableSlot.Slot().Bytes(), []byte(name))
+	return tableSlot.Datastore().Get(key)
+}
+
+// NestedTableSlot returns the slot of a table stored in a row field. Nested
+// tables are rooted at keccak256(fieldSlot), so that the rows of keyless tables
+// do not overlap the fields that follow.
+func NestedTableSlot(fieldSlot DatastoreSlot) DatastoreSlot {
+	return fieldSlot.Datastore().Get(crypto.Keccak256(fieldSlot.Slot().Bytes()))
+}
+
+// TableIndex groups the rows of a table, identified by their slot, by the
+// encoded values of the indexed fields.
+type TableIndex struct {
+	groups Mapping
+}
+
+func NewTableIndex(tableSlot DatastoreSlot, name string) *TableIndex {
+	return &TableIndex{groups: tableSubSlot(tableSlot, "datamod.v1.index."+name).Mapping()}
+}
+
+func (i *TableIndex) Group(values ...[]byte) *HashSet {
+	return NewHashSet(i.groups.GetNested(values...))
+}
+
+// NewTableRows returns the set of rows of an enumerable table, identified by
+// their slot.
+func NewTableRows(tableSlot DatastoreSlot) *HashSet {
+	return NewHashSet(tableSubSlot(tableSlot, "datamod.v1.rows"))
+}
+
+// TableKeys records the encoded primary keys of rows so that index queries
+// can return them.
+type TableKeys struct {
+	records Mapping
+	sizes   []int
+	dynamic []bool
+}
+
+// NewTableKeys takes the sizes of the keys and whether they are stored as
+// dynamic bytes.
+func NewTableKeys(tableSlot DatastoreSlot, sizes []int, dynamic []bool) *TableKeys {
+	return &TableKeys{
+		records: tableSubSlot(tableSlot, "datamod.v1.keys").Mapping(),
+		sizes:   sizes,
+		dynamic: dynamic,
+	}
+}
+
+func (k *TableKeys) record(id common.Hash) *DatastoreStruct {
+	return NewDatastoreStruct(k.records.Get(id.Bytes()), k.sizes)
+}
+
+func (k *TableKeys) Set(id common.Hash, keys [][]byte) {
+	if len(k.sizes) == 0 {
+		return
+	}
+	record := k.record(id)
+	for ii, key := range keys {
+		if k.dynamic[ii] {
+			record.SetField_bytes(ii, key)
+		} else {
+			record.SetField(ii, key)
+		}
+	}
+}
+
+func (k *TableKeys) Get(id common.Hash) [][]byte {
+	keys := make([][]byte, len(k.sizes))
+	if len(k.sizes) == 0 {
+		return keys
+	}
+	record := k.record(id)
+	for ii := range keys {
+		if k.dynamic[ii] {
+			keys[ii] = record.GetField_bytes(ii)
+		} else {
+			keys[ii] = record.GetField(ii)
+		}
+	}
+	return keys
+}
+
+func (k *TableKeys) Delete(id common.Hash) {
+	if len(k.sizes) == 0 {
+		return
+	}
+	record := k.record(id)
+	for ii, size := range k.sizes {
+		if k.dynamic[ii] {
+			record.SetField_bytes(ii, nil)
+		} else {
+			record.SetField(ii, make([]byte, size))
+		}
+	}
+}
+
+// IndexedRow keeps the secondary indexes and the row set of a table
+// consistent as one of its rows is updated. Rows are added to an index when
+// one of its fields is set. The row set is nil for tables that are not
+// enumerable.
+type IndexedRow struct {
+	id      common.Hash
+	keys    [][]byte
+	records *TableKeys
+	rows    *HashSet
+	indexes []*TableIndex
+}
+
+func NewIndexedRow(rowSlot DatastoreSlot, keys [][]byte, records *TableKeys, rows *HashSet, indexes ...*TableIndex) *IndexedRow {
+	return &IndexedRow{
+		id:      rowSlot.Slot(),
+		keys:    keys,
+		records: records,
+		rows:    rows,
+		indexes: indexes,
+	}
+}
+
+// Exists reports whether the row is in the row set.
+func (r *IndexedRow) Exists() bool {
+	return r.rows != nil && r.rows.Has(r.id)
+}
+
+// Insert adds the row to the row set, if any.
+func (r *IndexedRow) Insert() {
+	if r.rows != nil && r.rows.Add(r.id) {
+		r.records.Set(r.id, r.keys)
+	}
+}
+
+// Groups returns the group the row belongs to in each index, given the
+// encoded values of the indexed fields of each index.
+func (r *IndexedRow) Groups(values ...[][]byte) []*HashSet {
+	groups := make([]*HashSet, len(r.indexes))
+	for ii, index := range r.indexes {
+		groups[ii] = index.Group(values[ii]...)
+	}
+	return groups
+}
+
+// Update moves the row from its previous groups to its current ones.
+func (r *IndexedRow) Update(prev []*HashSet, cur []*HashSet) {
+	for ii := range r.indexes {
+		if prev[ii].Slot().Slot() != cur[ii].Slot().Slot() {
+			prev[ii].Remove(r.id)
+		}
+		if cur[ii].Add(r.id) {
+			r.records.Set(r.id, r.keys)
+		}
+	}
+}
+
+// Delete removes the row from the given groups and the row set, and drops its
+// key record.
+func (r *IndexedRow) Delete(groups []*HashSet) {
+	for _, group := range groups {
+		group.Remove(r.id)
+	}
+	if r.rows != nil {
+		r.rows.Remove(r.id)
+	}
+	r.records.Delete(r.id)
+}
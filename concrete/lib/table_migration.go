@@ -0,0 +1,187 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// The fingerprint of the datamod schema a precompile storage was written with
+// is kept at a fixed slot, so that migrations run once and storage written
+// with an unexpected schema is detected.
+var schemaFingerprintKey = crypto.Keccak256([]byte("datamod.v1.fingerprint"))
+
+// MigrateSchema runs migrate if the storage was written with the previous
+// schema, and records the current fingerprint. Storage without a fingerprint
+// is assumed to be from the previous schema, or empty. Migrate may be nil.
+func MigrateSchema(ds Datastore, current common.Hash, previous common.Hash, migrate func()) error {
+	slot := ds.Get(schemaFingerprintKey)
+	stored := slot.Bytes32()
+	if stored == current {
+		return nil
+	}
+	if stored != (common.Hash{}) && stored != previous {
+		return fmt.Errorf("cannot migrate storage from schema %s to %s", stored.Hex(), current.Hex())
+	}
+	if migrate != nil {
+		migrate()
+	}
+	slot.SetBytes32(current)
+	return nil
+}
+
+// SchemaFingerprint returns the fingerprint recorded by MigrateSchema.
+func SchemaFingerprint(ds Datastore) common.Hash {
+	return ds.Get(schemaFingerprintKey).Bytes32()
+}
+
+const (
+	FieldValue = iota
+	FieldBytes
+	FieldArray
+	FieldFixedArray
+)
+
+// FieldMove moves a field from index From of the previous row layout to
+// index To of the new one. Length is the length of fixed arrays, and
+// DynamicElems is set for arrays of bytes.
+type FieldMove struct {
+	From         int
+	To           int
+	Kind         int
+	Length       int
+	DynamicElems bool
+}
+
+// RowMigration moves the fields of rows whose position changed between two
+// versions of a table. Fields that are not moved are left untouched.
+type RowMigration struct {
+	prevSizes []int
+	sizes     []int
+	moves     []FieldMove
+}
+
+func NewRowMigration(prevSizes []int, sizes []int, moves ...FieldMove) *RowMigration {
+	return &RowMigration{prevSizes: prevSizes, sizes: sizes, moves: moves}
+}
+
+// MigrateTable migrates every row of an enumerable table.
+func (m *RowMigration) MigrateTable(tableSlot DatastoreSlot) {
+	rows := NewTableRows(tableSlot)
+	ds := tableSlot.Datastore()
+	for ii := uint64(0); ii < rows.Length(); ii++ {
+		m.MigrateRow(ds.Get(rows.Get(ii).Bytes()))
+	}
+}
+
+// MigrateRow reads all moved fields before clearing and rewriting them, as
+// the new positions may overlap the previous ones.
+func (m *RowMigration) MigrateRow(rowSlot DatastoreSlot) {
+	var (
+		prev   = NewDatastoreStruct(rowSlot, m.prevSizes)
+		row    = NewDatastoreStruct(rowSlot, m.sizes)
+		values = make([][][]byte, len(m.moves))
+	)
+	for ii, move := range m.moves {
+		switch move.Kind {
+		case FieldValue:
+			values[ii] = [][]byte{prev.GetField(move.From)}
+		case FieldBytes:
+			values[ii] = [][]byte{prev.GetField_bytes(move.From)}
+		default:
+			values[ii] = readElems(prev.GetField_slot(move.From), move)
+		}
+	}
+	for _, move := range m.moves {
+		switch move.Kind {
+		case FieldValue:
+			prev.SetField(move.From, make([]byte, m.prevSizes[move.From]))
+		case FieldFixedArray:
+			for _, elem := range fixedArrayElems(prev.GetField_slot(move.From), move.Length) {
+				elem.SetBytes32(common.Hash{})
+			}
+			fallthrough
+		default:
+			prev.GetField_slot(move.From).SetBytes32(common.Hash{})
+		}
+	}
+	for ii, move := range m.moves {
+		switch move.Kind {
+		case FieldValue:
+			row.SetField(move.To, values[ii][0])
+		case FieldBytes:
+			row.SetField_bytes(move.To, values[ii][0])
+		default:
+			writeElems(row.GetField_slot(move.To), move, values[ii])
+		}
+	}
+}
+
+func fixedArrayElems(dsSlot DatastoreSlot, length int) []DatastoreSlot {
+	arr := dsSlot.Datastore().Get(crypto.Keccak256(dsSlot.Slot().Bytes())).SlotArray([]int{length})
+	elems := make([]DatastoreSlot, length)
+	for ii := range elems {
+		elems[ii] = arr.Get(ii)
+	}
+	return elems
+}
+
+func arrayElems(dsSlot DatastoreSlot, move FieldMove) []DatastoreSlot {
+	if move.Kind == FieldFixedArray {
+		return fixedArrayElems(dsSlot, move.Length)
+	}
+	arr := dsSlot.DynamicArray()
+	elems := make([]DatastoreSlot, arr.Length())
+	for ii := range elems {
+		elems[ii] = arr.Get(uint64(ii))
+	}
+	return elems
+}
+
+func readElems(dsSlot DatastoreSlot, move FieldMove) [][]byte {
+	elems := arrayElems(dsSlot, move)
+	values := make([][]byte, len(elems))
+	for ii, elem := range elems {
+		if move.DynamicElems {
+			values[ii] = elem.Bytes()
+		} else {
+			values[ii] = elem.Bytes32().Bytes()
+		}
+	}
+	return values
+}
+
+func writeElems(dsSlot DatastoreSlot, move FieldMove, values [][]byte) {
+	var elems []DatastoreSlot
+	if move.Kind == FieldFixedArray {
+		elems = fixedArrayElems(dsSlot, move.Length)
+	} else {
+		arr := dsSlot.DynamicArray()
+		for range values {
+			elems = append(elems, arr.Push())
+		}
+	}
+	for ii, elem := range elems {
+		if move.DynamicElems {
+			elem.SetBytes(values[ii])
+		} else {
+			elem.SetBytes32(common.BytesToHash(values[ii]))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowMigration(t *testing.T) {
+	var (
+		r        = require.New(t)
+		address  = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		ds       = NewDatastore(mock.NewMockEnvironment(address, api.EnvConfig{}, false, 0))
+		tableKey = []byte("table")
+		long     = []byte(strings.Repeat("long", 20) + ".")
+	)
+
+	// Previous row: uint32, bytes, bytes[], bytes32[2], uint8
+	prevSizes := []int{4, 32, 32, 32, 1}
+	// New row: bool, uint32, uint8, bytes32[2], bytes[], bytes
+	sizes := []int{1, 4, 1, 32, 32, 32}
+	migration := NewRowMigration(prevSizes, sizes,
+		FieldMove{From: 0, To: 1, Kind: FieldValue},
+		FieldMove{From: 1, To: 5, Kind: FieldBytes},
+		FieldMove{From: 2, To: 4, Kind: FieldArray, DynamicElems: true},
+		FieldMove{From: 3, To: 3, Kind: FieldFixedArray, Length: 2},
+		FieldMove{From: 4, To: 2, Kind: FieldValue},
+	)
+
+	tableSlot := ds.Get(tableKey)
+	rows := NewTableRows(tableSlot)
+	for ii := byte(0); ii < 3; ii++ {
+		rowSlot := tableSlot.Mapping().Get([]byte{ii})
+		rows.Add(rowSlot.Slot())
+		row := NewDatastoreStruct(rowSlot, prevSizes)
+		row.SetField(0, []byte{0, 0, 1, ii})
+		row.SetField_bytes(1, long)
+		NewArray(row.GetField_slot(2), codec.Bytes).SetValues([][]byte{{ii}, long})
+		NewFixedArray(row.GetField_slot(3), 2, codec.Hash).SetValues([]common.Hash{{ii}, {0xff}})
+		row.SetField(4, []byte{ii + 1})
+	}
+	migration.MigrateTable(tableSlot)
+
+	for ii := byte(0); ii < 3; ii++ {
+		rowSlot := tableSlot.Mapping().Get([]byte{ii})
+		row := NewDatastoreStruct(rowSlot, sizes)
+		r.Equal([]byte{0}, row.GetField(0))
+		r.Equal([]byte{0, 0, 1, ii}, row.GetField(1))
+		r.Equal([]byte{ii + 1}, row.GetField(2))
+		r.Equal([]common.Hash{{ii}, {0xff}}, NewFixedArray(row.GetField_slot(3), 2, codec.Hash).Values())
+		r.Equal([][]byte{{ii}, long}, NewArray(row.GetField_slot(4), codec.Bytes).Values())
+		r.Equal(long, row.GetField_bytes(5))
+	}
+}
+
+func TestMigrateSchema(t *testing.T) {
+	var (
+		r       = require.New(t)
+		address = common.HexToAddress("0x1234567890123456789012345678901234567890")
+		ds      = NewDatastore(mock.NewMockEnvironment(address, api.EnvConfig{}, false, 0))
+		v1      = common.Hash{0x01}
+		v2      = common.Hash{0x02}
+		v3      = common.Hash{0x03}
+		runs    = 0
+		migrate = func() { runs++ }
+	)
+
+	// Storage without a fingerprint is migrated.
+	r.NoError(MigrateSchema(ds, v1, common.Hash{}, nil))
+	r.Equal(v1, SchemaFingerprint(ds))
+	r.NoError(MigrateSchema(ds, v2, v1, migrate))
+	r.NoError(MigrateSchema(ds, v2, v1, migrate))
+	r.Equal(1, runs)
+	r.Equal(v2, SchemaFingerprint(ds))
+
+	r.Error(MigrateSchema(ds, v3, v1, migrate))
+	r.Equal(1, runs)
+	r.Equal(v2, SchemaFingerprint(ds))
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package lib
+
+import (
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
+	"github.com/ethereum/go-ethereum/concrete/crypto"
+)
+
+// The typed containers use the same layout as datamod tables: a Value is laid
+// out as a single-field row, a Map as a single-key table and an Array as a
+// DynamicArray of single-field rows. Each container takes a single slot.
+
+type Value[V any] struct {
+	row   *DatastoreStruct
+	codec codec.Codec[V]
+}
+
+func NewValue[V any](dsSlot DatastoreSlot, valueCodec codec.Codec[V]) *Value[V] {
+	row := NewDatastoreStruct(dsSlot, []int{valueCodec.Size})
+	return &Value[V]{row: row, codec: valueCodec}
+}
+
+func (v *Value[V]) Slot() DatastoreSlot {
+	return v.row.GetField_slot(0)
+}
+
+func (v *Value[V]) Get() V {
+	if v.codec.Dynamic {
+		return v.codec.Decode(v.row.GetField_bytes(0))
+	}
+	return v.codec.Decode(v.row.GetField(0))
+}
+
+func (v *Value[V]) Set(value V) {
+	data := v.codec.Encode(value)
+	if v.codec.Dynamic {
+		v.row.SetField_bytes(0, data)
+		return
+	}
+	v.row.SetField(0, data)
+}
+
+// Clear resets the value to its zero encoding.
+func (v *Value[V]) Clear() {
+	if v.codec.Dynamic {
+		v.row.SetField_bytes(0, nil)
+		return
+	}
+	v.row.SetField(0, make([]byte, v.codec.Size))
+}
+
+type Map[K any, V any] struct {
+	mapping    Mapping
+	keyCodec   codec.Codec[K]
+	valueCodec codec.Codec[V]
+}
+
+func NewMap[K any, V any](dsSlot DatastoreSlot, keyCodec codec.Codec[K], valueCodec codec.Codec[V]) *Map[K, V] {
+	return &Map[K, V]{
+		mapping:    dsSlot.Mapping(),
+		keyCodec:   keyCodec,
+		valueCodec: valueCodec,
+	}
+}
+
+func (m *Map[K, V]) Value(key K) *Value[V] {
+	dsSlot := m.mapping.Get(m.keyCodec.Encode(key))
+	return NewValue(dsSlot, m.valueCodec)
+}
+
+func (m *Map[K, V]) Get(key K) V {
+	return m.Value(key).Get()
+}
+
+func (m *Map[K, V]) Set(key K, value V) {
+	m.Value(key).Set(value)
+}
+
+type Array[V any] struct {
+	array      DynamicArray
+	valueCodec codec.Codec[V]
+}
+
+func NewArray[V any](dsSlot DatastoreSlot, valueCodec codec.Codec[V]) *Array[V] {
+	return &Array[V]{array: dsSlot.DynamicArray(), valueCodec: valueCodec}
+}
+
+func (a *Array[V]) Length() uint64 {
+	return a.array.Length()
+}
+
+// Value panics if the index is out of range.
+func (a *Array[V]) Value(index uint64) *Value[V] {
+	dsSlot := a.array.Get(index)
+	if dsSlot == nil {
+		panic("index out of range")
+	}
+	return NewValue(dsSlot, a.valueCodec)
+}
+
+func (a *Array[V]) Get(index uint64) V {
+	return a.Value(index).Get()
+}
+
+func (a *Array[V]) Set(index uint64, value V) {
+	a.Value(index).Set(value)
+}
+
+func (a *Array[V]) Push(value V) {
+	dsSlot := a.array.Push()
+	NewValue(dsSlot, a.valueCodec).Set(value)
+}
+
+// Pop removes and returns the last element. The element is cleared so that
+// pushing again starts from a zero value. It panics if the array is empty.
+func (a *Array[V]) Pop() V {
+	dsSlot := a.array.Pop()
+	if dsSlot == nil {
+		panic("pop from empty array")
+	}
+	element := NewValue(dsSlot, a.valueCodec)
+	value := element.Get()
+	element.Clear()
+	return value
+}
+
+// Clear removes all elements.
+func (a *Array[V]) Clear() {
+	for a.Length() > 0 {
+		a.Pop()
+	}
+}
+
+func (a *Array[V]) Values() []V {
+	length := a.Length()
+	values := make([]V, length)
+	for ii := uint64(0); ii < length; ii++ {
+		values[ii] = a.Get(ii)
+	}
+	return values
+}
+
+// SetValues replaces the contents of the array with the given values.
+func (a *Array[V]) SetValues(values []V) {
+	for a.Length() > uint64(len(values)) {
+		a.Pop()
+	}
+	for ii, value := range values {
+		if uint64(ii) < a.Length() {
+			a.Set(uint64(ii), value)
+		} else {
+			a.Push(value)
+		}
+	}
+}
+
+type FixedArray[V any] struct {
+	array      SlotArray
+	valueCodec codec.Codec[V]
+}
+
+// NewFixedArray returns an array of the given length where each element
+// takes a full slot. Elements are laid out contiguously starting at
+// keccak256(slot) so the array itself only takes a single slot, e.g. as a
+// field of a datamod row.
+func NewFixedArray[V any](dsSlot DatastoreSlot, length int, valueCodec codec.Codec[V]) *FixedArray[V] {
+	elemsSlot := dsSlot.Datastore().Get(crypto.Keccak256(dsSlot.Slot().Bytes()))
+	return &FixedArray[V]{array: elemsSlot.SlotArray([]int{length}), valueCodec: valueCodec}
+}
+
+func (a *FixedArray[V]) Length() int {
+	return a.array.Length()
+}
+
+// Value panics if the index is out of range.
+func (a *FixedArray[V]) Value(index int) *Value[V] {
+	dsSlot := a.array.Get(index)
+	if dsSlot == nil {
+		panic("index out of range")
+	}
+	return NewValue(dsSlot, a.valueCodec)
+}
+
+func (a *FixedArray[V]) Get(index int) V {
+	return a.Value(index).Get()
+}
+
+func (a *FixedArray[V]) Set(index int, value V) {
+	a.Value(index).Set(value)
+}
+
+func (a *FixedArray[V]) Values() []V {
+	values := make([]V, a.Length())
+	for ii := range values {
+		values[ii] = a.Get(ii)
+	}
+	return values
+}
+
+// Clear resets all elements to their zero encoding.
+func (a *FixedArray[V]) Clear() {
+	for ii := 0; ii < a.Length(); ii++ {
+		a.Value(ii).Clear()
+	}
+}
+
+// SetValues panics if the number of values does not match the array length.
+func (a *FixedArray[V]) SetValues(values []V) {
+	if len(values) != a.Length() {
+		panic("invalid number of values")
+	}
+	for ii, value := range values {
+		a.Set(ii, value)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !tinygo
+
+// This file will ignored when building with tinygo to prevent compatibility
+// issues.
+
+package lib
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/codegen/datamod/codec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedValue(t *testing.T) {
+	var (
+		r          = require.New(t)
+		slot, _, _ = newSlot("typed.value")
+		address    = common.HexToAddress("0xc0ffee")
+	)
+
+	value := NewValue(slot, codec.Address)
+	r.Equal(common.Address{}, value.Get())
+	value.Set(address)
+	r.Equal(address, value.Get())
+
+	// Same layout as a single-field datamod row
+	row := NewDatastoreStruct(slot, []int{20})
+	r.Equal(address.Bytes(), row.GetField(0))
+
+	str := NewValue(slot.Mapping().Get([]byte("str")), codec.String)
+	long := string(make([]byte, 65))
+	str.Set(long)
+	r.Equal(long, str.Get())
+	r.Equal([]byte(long), str.Slot().Bytes())
+}
+
+func TestTypedMap(t *testing.T) {
+	var (
+		r          = require.New(t)
+		slot, _, _ = newSlot("typed.map")
+		m          = NewMap(slot, codec.Uint64, codec.Uint256)
+	)
+
+	r.Equal(0, m.Get(1).Sign())
+	m.Set(1, big.NewInt(100))
+	m.Set(2, big.NewInt(200))
+	r.Equal(big.NewInt(100), m.Get(1))
+	r.Equal(big.NewInt(200), m.Get(2))
+
+	// Same layout as a single-key datamod table
+	key := codec.EncodeSmallUint64(8, 1)
+	r.Equal(common.BigToHash(big.NewInt(100)), slot.Mapping().GetNested(key).Bytes32())
+}
+
+func TestTypedArray(t *testing.T) {
+	var (
+		r          = require.New(t)
+		slot, _, _ = newSlot("typed.array")
+		arr        = NewArray(slot, codec.Int32)
+	)
+
+	r.Equal(uint64(0), arr.Length())
+	r.Panics(func() { arr.Get(0) })
+	r.Panics(func() { arr.Pop() })
+
+	arr.Push(-1)
+	arr.Push(2)
+	arr.Set(1, 3)
+	r.Equal([]int32{-1, 3}, arr.Values())
+	r.Equal(int32(3), arr.Pop())
+	r.Equal(uint64(1), arr.Length())
+	arr.Push(0)
+	r.Equal(int32(0), arr.Get(1))
+
+	arr.SetValues([]int32{5, 6, 7})
+	r.Equal([]int32{5, 6, 7}, arr.Values())
+	arr.SetValues([]int32{8})
+	r.Equal([]int32{8}, arr.Values())
+
+	fixed := NewFixedArray(slot.Mapping().Get([]byte("fixed")), 3, codec.Bool)
+	r.Equal([]bool{false, false, false}, fixed.Values())
+	fixed.SetValues([]bool{true, false, true})
+	r.Equal([]bool{true, false, true}, fixed.Values())
+	r.Panics(func() { fixed.Get(3) })
+	r.Panics(func() { fixed.SetValues([]bool{true}) })
+}
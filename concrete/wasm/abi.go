@@ -0,0 +1,38 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/concrete/wasm/memory"
+)
+
+// Modules export the version of the host ABI they implement, checked when
+// they are instantiated. The ABI is specified in ABI.md.
+
+var (
+	ErrMissingAbiVersion     = errors.New("wasm module does not export the host ABI version")
+	ErrUnsupportedAbiVersion = errors.New("unsupported host ABI version")
+)
+
+func checkAbiVersion(version uint64) error {
+	if version != memory.AbiVersion {
+		return fmt.Errorf("%w: module implements version %d, host implements version %d", ErrUnsupportedAbiVersion, version, memory.AbiVersion)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/ethereum/go-ethereum/concrete/utils"
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+// The host ABI conformance suite runs the guests in testdata/abi on every
+// runtime. Guests implement the protocol of the reference guest in
+// testdata/abi/guest.wat. Prebuilt guests written in other languages are
+// added to the suite by copying them to testdata/abi as .wasm files.
+
+const (
+	abiGuestEcho = iota
+	abiGuestFail
+	abiGuestEnvironment
+	abiGuestTrap
+)
+
+type abiTestGuest struct {
+	name string
+	code []byte
+}
+
+func readAbiTestModule(t *testing.T, name string) []byte {
+	wat, err := os.ReadFile(filepath.Join("testdata", "abi", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := wasmer.Wat2Wasm(string(wat))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return code
+}
+
+func abiTestGuests(t *testing.T) []abiTestGuest {
+	guests := []abiTestGuest{{"wat", readAbiTestModule(t, "guest.wat")}}
+	paths, err := filepath.Glob(filepath.Join("testdata", "abi", "*.wasm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		code, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		guests = append(guests, abiTestGuest{filepath.Base(path), code})
+	}
+	return guests
+}
+
+// encodeAbiTestValues encodes values as (big endian u32 size, bytes) pairs.
+func encodeAbiTestValues(values ...[]byte) []byte {
+	var data []byte
+	for _, value := range values {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(value)))
+		data = append(data, value...)
+	}
+	return data
+}
+
+func decodeAbiTestValues(data []byte) ([][]byte, error) {
+	values := [][]byte{}
+	for len(data) > 0 {
+		if len(data) < 4 || uint32(len(data)-4) < binary.BigEndian.Uint32(data) {
+			return nil, errors.New("invalid encoding")
+		}
+		size := binary.BigEndian.Uint32(data)
+		values = append(values, data[4:4+size])
+		data = data[4+size:]
+	}
+	return values, nil
+}
+
+type abiOpcodeTest struct {
+	op      api.OpCode
+	args    [][]byte
+	trusted bool
+}
+
+// abiOpcodeTests returns valid arguments for every opcode of the environment,
+// and no arguments for undefined opcodes.
+func abiOpcodeTests() []abiOpcodeTest {
+	var (
+		hash    = common.HexToHash("0x01").Bytes()
+		address = common.HexToAddress("0x02").Bytes()
+		value   = common.BigToHash(common.Big0).Bytes()
+		gas     = utils.Uint64ToBytes(10_000)
+	)
+	defined := map[api.OpCode]abiOpcodeTest{
+		api.EnableGasMetering_OpCode:   {args: [][]byte{{0x01}}, trusted: true},
+		api.Debug_OpCode:               {args: [][]byte{[]byte("debug")}, trusted: true},
+		api.TimeNow_OpCode:             {trusted: true},
+		api.Keccak256_OpCode:           {args: [][]byte{[]byte("data")}},
+		api.UseGas_OpCode:              {args: [][]byte{utils.Uint64ToBytes(10)}},
+		api.EphemeralStore_OpCode:      {args: [][]byte{hash, hash}, trusted: true},
+		api.EphemeralLoad_OpCode:       {args: [][]byte{hash}, trusted: true},
+		api.GetAddress_OpCode:          {},
+		api.GetGasLeft_OpCode:          {},
+		api.GetBlockNumber_OpCode:      {},
+		api.GetBlockGasLimit_OpCode:    {},
+		api.GetBlockTimestamp_OpCode:   {},
+		api.GetBlockDifficulty_OpCode:  {},
+		api.GetBlockBaseFee_OpCode:     {},
+		api.GetBlockCoinbase_OpCode:    {},
+		api.GetPrevRandom_OpCode:       {},
+		api.GetBlockHash_OpCode:        {args: [][]byte{utils.Uint64ToBytes(1)}},
+		api.GetBalance_OpCode:          {},
+		api.GetTxGasPrice_OpCode:       {},
+		api.GetTxOrigin_OpCode:         {},
+		api.GetCallData_OpCode:         {},
+		api.GetCallDataSize_OpCode:     {},
+		api.GetCaller_OpCode:           {},
+		api.GetCallValue_OpCode:        {},
+		api.StorageLoad_OpCode:         {args: [][]byte{hash}},
+		api.GetCode_OpCode:             {},
+		api.GetCodeSize_OpCode:         {},
+		api.StorageStore_OpCode:        {args: [][]byte{hash, hash}},
+		api.Log_OpCode:                 {args: [][]byte{hash, []byte("data")}},
+		api.Snapshot_OpCode:            {},
+		api.RevertToSnapshot_OpCode:    {args: [][]byte{utils.Uint64ToBytes(0)}},
+		api.GetExternalBalance_OpCode:  {args: [][]byte{address}},
+		api.CallStatic_OpCode:          {args: [][]byte{address, []byte("input"), gas}},
+		api.GetExternalCode_OpCode:     {args: [][]byte{address}},
+		api.GetExternalCodeSize_OpCode: {args: [][]byte{address}},
+		api.GetExternalCodeHash_OpCode: {args: [][]byte{address}},
+		api.Call_OpCode:                {args: [][]byte{address, []byte("input"), gas, value}},
+		api.CallDelegate_OpCode:        {args: [][]byte{address, []byte("input"), gas}},
+		api.Create_OpCode:              {args: [][]byte{[]byte("code"), value}},
+		api.Create2_OpCode:             {args: [][]byte{[]byte("code"), value, hash}},
+	}
+	tests := make([]abiOpcodeTest, 0, 256)
+	for op := 0; op < 256; op++ {
+		test := defined[api.OpCode(op)]
+		test.op = api.OpCode(op)
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+// runAbiEnvironmentCall runs an environment call through the guest, and
+// directly in an identical environment.
+func runAbiEnvironmentCall(t *testing.T, pc concrete.Precompile, config api.EnvConfig, gas uint64, op api.OpCode, args [][]byte) (guest, host [][]byte, guestErr, hostErr error) {
+	newEnv := func() *api.Env {
+		return mock.NewMockEnvironment(common.HexToAddress("0x03"), config, true, gas)
+	}
+	env := newEnv()
+	input := append([]byte{abiGuestEnvironment}, encodeAbiTestValues(append([][]byte{op.Encode()}, args...)...)...)
+	output, err := pc.Run(env, input)
+	if env.Error() == nil {
+		if err != nil {
+			t.Fatalf("opcode 0x%x: unexpected error: %v", op, err)
+		}
+		if guest, err = decodeAbiTestValues(output); err != nil {
+			t.Fatalf("opcode 0x%x: %v", op, err)
+		}
+	}
+
+	directEnv := newEnv()
+	host, _ = directEnv.Execute(op, args)
+	return guest, host, env.Error(), directEnv.Error()
+}
+
+func TestAbiConformance(t *testing.T) {
+	for _, guest := range abiTestGuests(t) {
+		for _, rt := range sandboxRuntimes {
+			t.Run(guest.name+"/"+rt.name, func(t *testing.T) {
+				// Metering would make the gas seen by the guest differ from the host
+				pc, err := rt.new(guest.code, SandboxConfig{Unmetered: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				env := newUntrustedEnvironment(1_000_000)
+
+				if !pc.IsStatic([]byte{abiGuestEcho}) || pc.IsStatic([]byte{abiGuestFail}) {
+					t.Fatal("unexpected IsStatic")
+				}
+				for _, input := range [][]byte{nil, {abiGuestEcho}, {abiGuestEcho, 0x01, 0x02}, append([]byte{abiGuestEcho}, make([]byte, 100_000)...)} {
+					output, err := pc.Run(env, input)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if len(input) > 0 && !bytes.Equal(output, input[1:]) || len(input) == 0 && len(output) != 0 {
+						t.Fatalf("unexpected output: %x", output)
+					}
+				}
+				if _, err := pc.Run(env, []byte{abiGuestFail, 'e', 'r', 'r'}); err == nil || err.Error() != "err" {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if _, err := pc.Run(env, []byte{abiGuestTrap}); !errors.Is(err, ErrTrap) {
+					t.Fatalf("expected trap, got: %v", err)
+				}
+				if err := pc.Finalise(env); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := pc.Commit(env); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				for _, test := range abiOpcodeTests() {
+					config := api.EnvConfig{Trusted: test.trusted, Ephemeral: true}
+					guest, host, guestErr, hostErr := runAbiEnvironmentCall(t, pc, config, 1_000_000, test.op, test.args)
+					if guestErr != hostErr {
+						t.Fatalf("opcode 0x%x: error mismatch: guest %v, host %v", test.op, guestErr, hostErr)
+					}
+					if test.op == api.TimeNow_OpCode {
+						continue
+					}
+					if hostErr == nil && !equalAbiTestValues(guest, host) {
+						t.Fatalf("opcode 0x%x: output mismatch: guest %x, host %x", test.op, guest, host)
+					}
+				}
+			})
+		}
+	}
+}
+
+func equalAbiTestValues(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAbiConformanceErrors(t *testing.T) {
+	hash := common.HexToHash("0x01").Bytes()
+	tests := []struct {
+		name    string
+		config  api.EnvConfig
+		gas     uint64
+		op      api.OpCode
+		args    [][]byte
+		wantErr error
+	}{
+		{"undefined", api.EnvConfig{}, 1_000_000, api.ManyOps_OpCode, nil, api.ErrInvalidOpCode},
+		{"not trusted", api.EnvConfig{}, 1_000_000, api.EphemeralLoad_OpCode, [][]byte{hash}, api.ErrEnvNotTrusted},
+		{"write protection", api.EnvConfig{Static: true}, 1_000_000, api.StorageStore_OpCode, [][]byte{hash, hash}, api.ErrWriteProtection},
+		{"out of gas", api.EnvConfig{}, 10, api.StorageLoad_OpCode, [][]byte{hash}, api.ErrOutOfGas},
+		{"invalid input", api.EnvConfig{}, 1_000_000, api.StorageLoad_OpCode, [][]byte{hash[:31]}, api.ErrInvalidInput},
+	}
+	for _, guest := range abiTestGuests(t) {
+		for _, rt := range sandboxRuntimes {
+			t.Run(guest.name+"/"+rt.name, func(t *testing.T) {
+				pc, err := rt.new(guest.code, SandboxConfig{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, test := range tests {
+					_, _, guestErr, hostErr := runAbiEnvironmentCall(t, pc, test.config, test.gas, test.op, test.args)
+					if guestErr != test.wantErr || hostErr != test.wantErr {
+						t.Fatalf("%s: unexpected errors: guest %v, host %v", test.name, guestErr, hostErr)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestAbiHostErrors checks that hosts fail gracefully on guests violating the
+// ABI.
+func TestAbiHostErrors(t *testing.T) {
+	code := readAbiTestModule(t, "errors.wat")
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc, err := rt.new(code, SandboxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := newUntrustedEnvironment(1_000_000)
+			if pc.IsStatic([]byte{0x00}) {
+				t.Fatal("expected non static precompile")
+			}
+			if err := pc.Finalise(env); err == nil || err.Error() != "finalise failed" {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := pc.Commit(env); err == nil {
+				t.Fatal("expected error")
+			}
+			for op := byte(0); op < 4; op++ {
+				env := newUntrustedEnvironment(1_000_000)
+				if _, _, err := concrete.RunPrecompile(pc, env, []byte{op}, false); err == nil {
+					t.Fatalf("op %d: expected error", op)
+				}
+			}
+		})
+	}
+}
+
+// TestAbiHostFailureTraps checks that guests stop at an environment call
+// failing in the host, instead of running on to the next call.
+func TestAbiHostFailureTraps(t *testing.T) {
+	code, err := wasmer.Wat2Wasm(`(module
+  (import "env" "concrete_Environment" (func $environment (param i64) (result i64)))
+  (memory (export "memory") 1)
+  ;; Arguments of UseGas(1000): the opcode, the gas and the list of both
+  (data (i32.const 64) "\50")
+  (data (i32.const 72) "\00\00\00\00\00\00\03\e8")
+  (data (i32.const 96) "\00\00\00\40\00\00\00\01\00\00\00\48\00\00\00\08")
+  (func (export "concrete_AbiVersion") (result i64) (i64.const 1))
+  (func (export "concrete_Malloc") (param i64) (result i64) (i64.const 0x0000040000000000))
+  (func (export "concrete_Free") (param i64))
+  (func (export "concrete_Prune"))
+  (func (export "concrete_IsStatic") (param i64) (result i64) (i64.const 0))
+  (func (export "concrete_Finalise") (result i64) (i64.const 0))
+  (func (export "concrete_Commit") (result i64) (i64.const 0))
+  (func (export "concrete_Run") (param i64) (result i64)
+    (drop (call $environment (i64.const -1)))
+    (drop (call $environment (i64.const 0x0000006000000010)))
+    (i64.const 0)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc, err := rt.new(code, SandboxConfig{Unmetered: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := newUntrustedEnvironment(1_000_000)
+			if _, err := pc.Run(env, nil); !errors.Is(err, ErrTrap) {
+				t.Fatalf("expected trap, got: %v", err)
+			}
+			if gas := env.Gas(); gas != 1_000_000 {
+				t.Fatalf("guest ran on after the failure, %d gas left", gas)
+			}
+		})
+	}
+}
+
+func TestAbiVersion(t *testing.T) {
+	tests := []struct {
+		module  string
+		wantErr error
+	}{
+		{"abi_missing.wat", ErrMissingAbiVersion},
+		{"abi_mismatch.wat", ErrUnsupportedAbiVersion},
+	}
+	for _, test := range tests {
+		code := readAbiTestModule(t, test.module)
+		for _, rt := range sandboxRuntimes {
+			if _, err := rt.new(code, SandboxConfig{}); !errors.Is(err, test.wantErr) {
+				t.Fatalf("%s/%s: unexpected error: %v", test.module, rt.name, err)
+			}
+		}
+	}
+}
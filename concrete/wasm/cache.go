@@ -0,0 +1,381 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/tetratelabs/wazero"
+)
+
+// Compiling large modules can take a while, so compiled precompiles are
+// cached on disk and reused across restarts. The cache directory holds one
+// directory per runtime, named after the runtime version and platform:
+//
+//	wazero-<version>-<arch>-<os>/  managed by the wazero compilation cache, with
+//	                               a <entry>.sum checksum next to each entry
+//	wasmer-<version>-<arch>-<os>/  one <code hash> file per module, holding the
+//	                               checksum of the serialized module followed
+//	                               by the module
+//
+// Entries that do not match their checksum are removed and compiled again.
+
+const (
+	// CacheDirName is the name of the cache directory in the data directory.
+	CacheDirName = "wasmcache"
+
+	wazeroModulePath = "github.com/tetratelabs/wazero"
+	wasmerModulePath = "github.com/wasmerio/wasmer-go"
+	checksumSuffix   = ".sum"
+	tempPrefix       = "tmp-"
+)
+
+var errCorruptedCacheEntry = errors.New("corrupted wasm cache entry")
+
+// DefaultCacheDir is the cache directory used when none is given, under the
+// default data directory of geth. Precompiles run by a node should use the
+// directory resolved by the node instead, i.e. stack.ResolvePath(CacheDirName),
+// so that the cache follows --datadir.
+var DefaultCacheDir = filepath.Join(defaultDataDir(), "geth", CacheDirName)
+
+// defaultDataDir mirrors node.DefaultDataDir, which is not imported to keep
+// the node package out of wasm builds.
+func defaultDataDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Ethereum")
+	case "windows":
+		return filepath.Join(home, "AppData", "Roaming", "Ethereum")
+	default:
+		return filepath.Join(home, ".ethereum")
+	}
+}
+
+// moduleVersion returns the version of a dependency, as done by wazero to
+// name its cache directory.
+func moduleVersion(path string) string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if strings.Contains(dep.Path, path) && dep.Version != "" && dep.Version != "(devel)" {
+				return dep.Version
+			}
+		}
+	}
+	return "dev"
+}
+
+func runtimeDirName(runtimeName string, version string) string {
+	return runtimeName + "-" + version + "-" + runtime.GOARCH + "-" + runtime.GOOS
+}
+
+// Cache is an on-disk cache of compiled precompiles.
+type Cache struct {
+	dir       string
+	wazeroDir string
+	wasmerDir string
+	mutex     sync.Mutex
+	wazero    wazero.CompilationCache
+	// wazeroVerified is set once the wazero entries written before the cache
+	// was opened are verified. Later entries are checksummed as they are
+	// written, so they are not verified again.
+	wazeroVerified bool
+}
+
+var (
+	cachesLock sync.Mutex
+	caches     = make(map[string]*Cache)
+)
+
+// OpenCache returns the cache in the given directory, creating it if needed.
+// Precompiles opening the same directory share the cache.
+func OpenCache(dir string) (*Cache, error) {
+	if dir == "" {
+		dir = DefaultCacheDir
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	cachesLock.Lock()
+	defer cachesLock.Unlock()
+	if cache, ok := caches[dir]; ok {
+		return cache, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	cache := &Cache{
+		dir:       dir,
+		wazeroDir: filepath.Join(dir, runtimeDirName("wazero", moduleVersion(wazeroModulePath))),
+		wasmerDir: filepath.Join(dir, runtimeDirName("wasmer", moduleVersion(wasmerModulePath))),
+	}
+	caches[dir] = cache
+	return cache, nil
+}
+
+// openCacheOrWarn opens the cache for a precompile constructor. Precompiles
+// are compiled without cache if it cannot be opened.
+func openCacheOrWarn(dir string) *Cache {
+	cache, err := OpenCache(dir)
+	if err != nil {
+		log.Warn("Failed to open wasm cache, compiling without it", "dir", dir, "err", err)
+		return nil
+	}
+	return cache
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func checksum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+// writeFile writes a file atomically, so that readers never see partial
+// entries.
+func writeFile(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), tempPrefix)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// readEntry reads a module stored with its checksum.
+func readEntry(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < sha256.Size || !bytes.Equal(content[:sha256.Size], checksum(content[sha256.Size:])) {
+		return nil, errCorruptedCacheEntry
+	}
+	return content[sha256.Size:], nil
+}
+
+func writeEntry(path string, data []byte) error {
+	return writeFile(path, append(checksum(data), data...))
+}
+
+func (c *Cache) wasmerEntry(code []byte) string {
+	return filepath.Join(c.wasmerDir, crypto.Keccak256Hash(code).Hex())
+}
+
+// wazeroConfig returns the runtime config using the wazero compilation cache.
+// The cache must be locked.
+func (c *Cache) wazeroConfig(config wazero.RuntimeConfig) (wazero.RuntimeConfig, error) {
+	if c.wazero == nil {
+		cache, err := wazero.NewCompilationCacheWithDir(c.dir)
+		if err != nil {
+			return nil, err
+		}
+		c.wazero = cache
+	}
+	return config.WithCompilationCache(c.wazero), nil
+}
+
+// compileWazero compiles a module with the wazero compilation cache. Entries
+// are verified before wazero first reads them and checksummed once written.
+// The module is compiled without cache if it cannot be used.
+func (c *Cache) compileWazero(config wazero.RuntimeConfig, compile func(wazero.RuntimeConfig) error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.wazeroVerified {
+		if removed, err := c.verifyWazero(); err != nil {
+			log.Warn("Failed to verify wasm cache, compiling without it", "dir", c.wazeroDir, "err", err)
+			return compile(config)
+		} else if removed > 0 {
+			log.Warn("Removed corrupted wasm cache entries", "dir", c.wazeroDir, "count", removed)
+		}
+		c.wazeroVerified = true
+	}
+	cachedConfig, err := c.wazeroConfig(config)
+	if err != nil {
+		log.Warn("Failed to open wasm cache, compiling without it", "dir", c.wazeroDir, "err", err)
+		return compile(config)
+	}
+	if err := compile(cachedConfig); err != nil {
+		return err
+	}
+	if err := c.sealWazero(); err != nil {
+		log.Warn("Failed to checksum wasm cache entries", "dir", c.wazeroDir, "err", err)
+	}
+	return nil
+}
+
+func listEntries(dir string) ([]string, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var entries []string
+	for _, file := range files {
+		if file.IsDir() || strings.HasSuffix(file.Name(), checksumSuffix) {
+			continue
+		}
+		entries = append(entries, filepath.Join(dir, file.Name()))
+	}
+	return entries, nil
+}
+
+// verifyWazero removes the wazero entries that do not match their checksum,
+// or that have none. It returns the number of removed entries.
+func (c *Cache) verifyWazero() (int, error) {
+	entries, err := listEntries(c.wazeroDir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		content, err := os.ReadFile(entry)
+		if err != nil {
+			return removed, err
+		}
+		sum, err := os.ReadFile(entry + checksumSuffix)
+		if err == nil && bytes.Equal(sum, checksum(content)) {
+			continue
+		}
+		if err := removeEntry(entry); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// sealWazero writes the checksum of the new wazero entries.
+func (c *Cache) sealWazero() error {
+	entries, err := listEntries(c.wazeroDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := os.Stat(entry + checksumSuffix); err == nil {
+			continue
+		}
+		content, err := os.ReadFile(entry)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(entry+checksumSuffix, checksum(content)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyWasmer removes the wasmer entries that do not match their checksum.
+func (c *Cache) verifyWasmer() (int, error) {
+	entries, err := listEntries(c.wasmerDir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		if _, err := readEntry(entry); err == nil {
+			continue
+		}
+		if err := removeEntry(entry); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func removeEntry(entry string) error {
+	for _, path := range []string{entry, entry + checksumSuffix} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func countEntries(dir string) int {
+	entries, _ := listEntries(dir)
+	return len(entries)
+}
+
+// Prune removes the entries of other runtime versions, and the entries that
+// are corrupted or were not fully written. All entries are removed if all is
+// set. It returns the number of removed entries.
+func (c *Cache) Prune(all bool) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	dirs, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, dir := range dirs {
+		if !dir.IsDir() || !(strings.HasPrefix(dir.Name(), "wazero-") || strings.HasPrefix(dir.Name(), "wasmer-")) {
+			continue
+		}
+		path := filepath.Join(c.dir, dir.Name())
+		if all || (path != c.wazeroDir && path != c.wasmerDir) {
+			count := countEntries(path)
+			if err := os.RemoveAll(path); err != nil {
+				return removed, err
+			}
+			removed += count
+		}
+	}
+	if all {
+		// The wazero cache is created again with its directory. The previous
+		// one is not closed as running precompiles may use it.
+		c.wazero = nil
+		return removed, nil
+	}
+
+	count, err := c.verifyWazero()
+	removed += count
+	if err != nil {
+		return removed, err
+	}
+	count, err = c.verifyWasmer()
+	removed += count
+	return removed, err
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+// cacheTestCode is a module exporting a function f returning 42.
+var cacheTestCode = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x05, 0x01, 0x60, 0x00, 0x01, 0x7f,
+	0x03, 0x02, 0x01, 0x00,
+	0x07, 0x05, 0x01, 0x01, 0x66, 0x00, 0x00,
+	0x0a, 0x06, 0x01, 0x04, 0x00, 0x41, 0x2a, 0x0b,
+}
+
+func newTestCache(t *testing.T) *Cache {
+	cache, err := OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cache
+}
+
+// reopenCache opens the cache as a new process would, without the modules
+// compiled in memory.
+func reopenCache(t *testing.T, cache *Cache) *Cache {
+	cachesLock.Lock()
+	delete(caches, cache.Dir())
+	cachesLock.Unlock()
+	reopened, err := OpenCache(cache.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reopened
+}
+
+func loadWasmerModule(t *testing.T, cache *Cache) {
+	store := wasmer.NewStore(wasmer.NewEngine())
+	module, err := cache.wasmerModule(store, cacheTestCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := wasmer.NewInstance(module, wasmer.NewImportObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := instance.Exports.GetFunction("f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := f()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != int32(42) {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func compileCachedWazeroModule(t *testing.T, cache *Cache) {
+	ctx := context.Background()
+	err := cache.compileWazero(wazero.NewRuntimeConfigCompiler(), func(config wazero.RuntimeConfig) error {
+		r := wazero.NewRuntimeWithConfig(ctx, config)
+		defer r.Close(ctx)
+		_, err := r.CompileModule(ctx, cacheTestCode)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := OpenCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := OpenCache(filepath.Join(dir, "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache != other {
+		t.Fatal("expected the cache to be shared")
+	}
+	if cache.Dir() != dir {
+		t.Fatalf("unexpected dir: %s", cache.Dir())
+	}
+}
+
+func TestWasmerCache(t *testing.T) {
+	cache := newTestCache(t)
+	entry := cache.wasmerEntry(cacheTestCode)
+
+	loadWasmerModule(t, cache)
+	data, err := readEntry(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached modules are loaded without compiling
+	loadWasmerModule(t, cache)
+
+	// Corrupted modules are compiled again
+	content, err := os.ReadFile(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content[len(content)-1] ^= 0xff
+	if err := os.WriteFile(entry, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEntry(entry); err != errCorruptedCacheEntry {
+		t.Fatalf("expected corrupted entry, got: %v", err)
+	}
+	loadWasmerModule(t, cache)
+	rewritten, err := readEntry(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rewritten) != len(data) {
+		t.Fatalf("unexpected entry size: %d != %d", len(rewritten), len(data))
+	}
+}
+
+func TestWazeroCache(t *testing.T) {
+	cache := newTestCache(t)
+
+	compileCachedWazeroModule(t, cache)
+	if countEntries(cache.wazeroDir) == 0 {
+		t.Fatal("expected cache entries")
+	}
+	entries, err := listEntries(cache.wazeroDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if _, err := os.Stat(entry + checksumSuffix); err != nil {
+			t.Fatalf("missing checksum: %v", err)
+		}
+	}
+	if removed, err := cache.verifyWazero(); err != nil || removed != 0 {
+		t.Fatalf("unexpected verification: removed %d, err %v", removed, err)
+	}
+
+	// Entries are only verified once per cache
+	unsealed := filepath.Join(cache.wazeroDir, "unsealed")
+	if err := os.WriteFile(unsealed, []byte("unsealed"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	compileCachedWazeroModule(t, cache)
+	if _, err := os.Stat(unsealed); err != nil {
+		t.Fatalf("expected entry not to be verified again: %v", err)
+	}
+	if err := removeEntry(unsealed); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupted entries are removed before compiling
+	if err := os.WriteFile(entries[0], []byte("corrupted"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cache = reopenCache(t, cache)
+	if removed, err := cache.verifyWazero(); err != nil || removed != 1 {
+		t.Fatalf("unexpected verification: removed %d, err %v", removed, err)
+	}
+	compileCachedWazeroModule(t, cache)
+	if countEntries(cache.wazeroDir) != len(entries) {
+		t.Fatal("expected entries to be compiled again")
+	}
+}
+
+func TestPruneCache(t *testing.T) {
+	cache := newTestCache(t)
+	loadWasmerModule(t, cache)
+	compileCachedWazeroModule(t, cache)
+
+	stale := filepath.Join(cache.Dir(), runtimeDirName("wasmer", "v0.0.0"))
+	if err := os.MkdirAll(stale, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stale, "entry"), []byte{}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := filepath.Join(cache.wasmerDir, "corrupted")
+	if err := os.WriteFile(corrupted, []byte("corrupted"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := cache.Prune(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Fatalf("unexpected removed entries: %d", removed)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatal("expected stale directory to be removed")
+	}
+	if countEntries(cache.wasmerDir) != 1 || countEntries(cache.wazeroDir) == 0 {
+		t.Fatal("expected current entries to be kept")
+	}
+
+	total := countEntries(cache.wasmerDir) + countEntries(cache.wazeroDir)
+	removed, err = cache.Prune(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != total {
+		t.Fatalf("unexpected removed entries: %d != %d", removed, total)
+	}
+
+	// The cache can still be used after being cleared
+	loadWasmerModule(t, cache)
+	compileCachedWazeroModule(t, cache)
+}
@@ -19,8 +19,12 @@ const (
 	// Host functions
 	Environment_WasmFuncName = "concrete_Environment"
 	// WASM functions
-	IsStatic_WasmFuncName = "concrete_IsStatic"
-	Finalise_WasmFuncName = "concrete_Finalise"
-	Commit_WasmFuncName   = "concrete_Commit"
-	Run_WasmFuncName      = "concrete_Run"
+	AbiVersion_WasmFuncName = "concrete_AbiVersion"
+	IsStatic_WasmFuncName   = "concrete_IsStatic"
+	Finalise_WasmFuncName   = "concrete_Finalise"
+	Commit_WasmFuncName     = "concrete_Commit"
+	Run_WasmFuncName        = "concrete_Run"
+	Start_WasmFuncName      = "_start"
+	// WASM globals
+	Gas_WasmGlobalName = "concrete_Gas"
 )
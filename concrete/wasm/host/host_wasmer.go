@@ -122,7 +122,7 @@ func (m *wasmerMemory) Write(data []byte) memory.MemPointer {
 	pointer := m.Malloc(len(data))
 	offset, size := pointer.Unpack()
 	memSize := m.memory.Size()
-	if uint(offset+size) >= memSize.ToBytes() {
+	if uint(offset+size) > memSize.ToBytes() {
 		panic(ErrMemoryReadOutOfRange)
 	}
 	mem := m.memory.Data()
@@ -136,7 +136,7 @@ func (m *wasmerMemory) Read(pointer memory.MemPointer) []byte {
 	}
 	offset, size := pointer.Unpack()
 	memSize := m.memory.Size()
-	if uint(offset+size) >= memSize.ToBytes() {
+	if uint(offset+size) > memSize.ToBytes() {
 		panic(ErrMemoryReadOutOfRange)
 	}
 	mem := m.memory.Data()
@@ -174,7 +174,10 @@ func NewWasmerEnvironmentCaller(apiGetter func() api.Environment) WasmerHostFunc
 
 		out, err := env.Execute(opcode, args)
 		if err != nil {
-			return nil, err
+			// Returning an error from a host function double frees its trap
+			// in wasmer-go. The error is kept by the environment, and the
+			// precompile traps the module once the function returns.
+			return []wasmer.Value{wasmer.NewI64(0)}, nil
 		}
 
 		retPointer := memory.PutValues(mem, out)
@@ -19,6 +19,11 @@ import (
 	"github.com/ethereum/go-ethereum/concrete/utils"
 )
 
+// AbiVersion is the version of the host ABI, exported by guests from
+// concrete_AbiVersion. It changes with any incompatible change to the
+// encoding of values, errors and environment calls. See concrete/wasm/ABI.md.
+const AbiVersion = 1
+
 type MemPointer uint64
 
 const (
@@ -113,7 +113,7 @@ func TestMockMemoryPutGetValues(t *testing.T) {
 func newWazeroMemory() (memory.Memory, memory.Allocator) {
 	envCall := host.NewWazeroEnvironmentCaller(func() api.Environment { return nil })
 	config := wazero.NewRuntimeConfigInterpreter()
-	mod, _, err := newWazeroModule(envCall, blankCode, config)
+	mod, _, _, err := newWazeroModule(envCall, blankCode, config, nil)
 	if err != nil {
 		panic(err)
 	}
@@ -124,7 +124,7 @@ func newWazeroMemory() (memory.Memory, memory.Allocator) {
 func newWasmerMemory() (memory.Memory, memory.Allocator) {
 	envCall := host.NewWasmerEnvironmentCaller(func() api.Environment { return nil })
 	config := wasmer.NewConfig().UseSinglepassCompiler()
-	_, instance, err := newWasmerModule(envCall, blankCode, config)
+	_, instance, err := newWasmerModule(envCall, func(error) {}, blankCode, config, nil)
 	if err != nil {
 		panic(err)
 	}
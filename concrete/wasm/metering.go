@@ -0,0 +1,388 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ethereum/go-ethereum/concrete/api"
+)
+
+// Precompiles only pay gas for the environment operations they call, so
+// computation in the guest is metered by instrumenting the module. The code
+// of each function is split in blocks at control flow instructions, and each
+// block starts by charging the gas for all of its instructions to a mutable
+// i64 global, exported as Gas_WasmGlobalName. When the global cannot pay for
+// a block, it is set to the max uint64 and the guest traps.
+//
+// The host sets the global to the gas available in the environment before
+// calling into the guest, and charges the environment for the gas consumed
+// when the guest returns or calls an environment operation. As metering is
+// done by the guest code itself, it is identical across runtimes.
+
+var (
+	ErrAlreadyMetered    = errors.New("wasm module already metered")
+	ErrUnsupportedOpcode = errors.New("unsupported wasm opcode")
+)
+
+// InstrumentGasMetering returns the code of a module metering its own
+// execution, charging gasPerInstruction for each executed instruction.
+// Precompiles created from the returned code charge the metered gas to the
+// environment, and fail with api.ErrOutOfGas if it runs out.
+func InstrumentGasMetering(code []byte, gasPerInstruction uint64) ([]byte, error) {
+	sections, err := parseModule(code)
+	if err != nil {
+		return nil, err
+	}
+
+	// The gas global is appended after the imported and defined globals
+	var gasGlobal uint32
+	for _, s := range sections {
+		switch s.id {
+		case importSectionID:
+			imports, err := countImports(s.content)
+			if err != nil {
+				return nil, err
+			}
+			gasGlobal += imports[globalExternalKind]
+		case globalSectionID:
+			r := &wasmReader{data: s.content}
+			gasGlobal += r.u32()
+			if r.err != nil {
+				return nil, r.err
+			}
+		case exportSectionID:
+			if err := checkExports(s.content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// (global (mut i64) (i64.const 0))
+	sections, i := withSection(sections, globalSectionID)
+	global := []byte{i64ValueType, mutableGlobal, 0x42, 0x00, 0x0b}
+	if sections[i].content, err = appendToVector(sections[i].content, global); err != nil {
+		return nil, err
+	}
+	sections, i = withSection(sections, exportSectionID)
+	export := appendU32(nil, uint32(len(Gas_WasmGlobalName)))
+	export = append(export, Gas_WasmGlobalName...)
+	export = append(export, globalExternalKind)
+	export = appendU32(export, gasGlobal)
+	if sections[i].content, err = appendToVector(sections[i].content, export); err != nil {
+		return nil, err
+	}
+
+	for i := range sections {
+		if sections[i].id == codeSectionID {
+			if sections[i].content, err = instrumentCode(sections[i].content, gasGlobal, gasPerInstruction); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return encodeModule(sections), nil
+}
+
+func checkExports(content []byte) error {
+	r := &wasmReader{data: content}
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		if r.name() == Gas_WasmGlobalName {
+			return ErrAlreadyMetered
+		}
+		r.byte()
+		r.u32()
+	}
+	return r.err
+}
+
+func instrumentCode(content []byte, gasGlobal uint32, gasPerInstruction uint64) ([]byte, error) {
+	r := &wasmReader{data: content}
+	n := r.u32()
+	out := appendU32(nil, n)
+	for ; n > 0 && r.err == nil; n-- {
+		body := r.bytes(int(r.u32()))
+		if r.err != nil {
+			break
+		}
+		instrumented, err := instrumentFunction(body, gasGlobal, gasPerInstruction)
+		if err != nil {
+			return nil, err
+		}
+		out = appendU32(out, uint32(len(instrumented)))
+		out = append(out, instrumented...)
+	}
+	if r.err == nil && !r.done() {
+		r.fail("trailing bytes in code section")
+	}
+	return out, r.err
+}
+
+// meteredBlock is a sequence of instructions executed together, charged at
+// its start.
+type meteredBlock struct {
+	start        int // Offset of the first instruction in the function body
+	instructions uint64
+}
+
+func instrumentFunction(body []byte, gasGlobal uint32, gasPerInstruction uint64) ([]byte, error) {
+	r := &wasmReader{data: body}
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		r.u32()
+		r.byte()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var (
+		blocks = []meteredBlock{{start: r.pos}}
+		depth  = 1
+	)
+	for depth > 0 {
+		if r.done() {
+			r.fail("unexpected end of function")
+			return nil, r.err
+		}
+		blocks[len(blocks)-1].instructions++
+		opcode := r.byte()
+		split, err := skipImmediates(r, opcode)
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case 0x02, 0x03, 0x04: // block, loop, if
+			depth++
+		case 0x0b: // end
+			depth--
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		if split && depth > 0 {
+			blocks = append(blocks, meteredBlock{start: r.pos})
+		}
+	}
+	if !r.done() {
+		r.fail("trailing bytes in function")
+		return nil, r.err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(body[:blocks[0].start])
+	for i, block := range blocks {
+		end := len(body)
+		if i+1 < len(blocks) {
+			end = blocks[i+1].start
+		}
+		if block.instructions > 0 && gasPerInstruction > 0 {
+			if block.instructions > math.MaxInt64/gasPerInstruction {
+				return nil, fmt.Errorf("%w: gas per block overflows", ErrInvalidWasm)
+			}
+			buf.Write(chargeGasCode(gasGlobal, int64(block.instructions*gasPerInstruction)))
+		}
+		buf.Write(body[block.start:end])
+	}
+	return buf.Bytes(), nil
+}
+
+// chargeGasCode returns the instructions charging gas to the gas global.
+func chargeGasCode(gasGlobal uint32, gas int64) []byte {
+	var code []byte
+	getGlobal := appendU32([]byte{0x23}, gasGlobal) // global.get
+	setGlobal := appendU32([]byte{0x24}, gasGlobal) // global.set
+	constGas := appendS64([]byte{0x42}, gas)        // i64.const
+
+	// if gas > global { global = max uint64; unreachable }
+	code = append(code, getGlobal...)
+	code = append(code, constGas...)
+	code = append(code, 0x54, 0x04, emptyBlockType) // i64.lt_u, if
+	code = append(code, 0x42, 0x7f)                 // i64.const -1
+	code = append(code, setGlobal...)
+	code = append(code, 0x00, 0x0b) // unreachable, end
+	// global -= gas
+	code = append(code, getGlobal...)
+	code = append(code, constGas...)
+	code = append(code, 0x7d) // i64.sub
+	code = append(code, setGlobal...)
+	return code
+}
+
+// skipImmediates skips the immediates of an instruction, and returns whether
+// the instruction ends a metered block.
+func skipImmediates(r *wasmReader, opcode byte) (bool, error) {
+	switch {
+	case opcode == 0x00 || opcode == 0x01: // unreachable, nop
+		return opcode == 0x00, nil
+	case opcode >= 0x02 && opcode <= 0x04: // block, loop, if
+		switch bt := r.byte(); {
+		case bt == emptyBlockType, bt >= 0x6f && bt <= 0x7f:
+		default:
+			// Type index encoded as a signed leb128
+			r.pos--
+			r.skipLEB()
+		}
+		return true, nil
+	case opcode == 0x05 || opcode == 0x0b: // else, end
+		return true, nil
+	case opcode == 0x0c || opcode == 0x0d: // br, br_if
+		r.u32()
+		return true, nil
+	case opcode == 0x0e: // br_table
+		for n := r.u32(); n > 0 && r.err == nil; n-- {
+			r.u32()
+		}
+		r.u32()
+		return true, nil
+	case opcode == 0x0f: // return
+		return true, nil
+	case opcode == 0x10: // call
+		r.u32()
+	case opcode == 0x11: // call_indirect
+		r.u32()
+		r.u32()
+	case opcode == 0x1a || opcode == 0x1b: // drop, select
+	case opcode == 0x1c: // select t*
+		r.bytes(int(r.u32()))
+	case opcode >= 0x20 && opcode <= 0x26: // local, global and table access
+		r.u32()
+	case opcode >= 0x28 && opcode <= 0x3e: // loads and stores
+		r.u32()
+		r.u32()
+	case opcode == 0x3f || opcode == 0x40: // memory.size, memory.grow
+		r.byte()
+	case opcode == 0x41 || opcode == 0x42: // i32.const, i64.const
+		r.skipLEB()
+	case opcode == 0x43: // f32.const
+		r.bytes(4)
+	case opcode == 0x44: // f64.const
+		r.bytes(8)
+	case opcode >= 0x45 && opcode <= 0xc4: // numeric instructions
+	case opcode == 0xd0: // ref.null
+		r.byte()
+	case opcode == 0xd1: // ref.is_null
+	case opcode == 0xd2: // ref.func
+		r.u32()
+	case opcode == 0xfc:
+		switch sub := r.u32(); {
+		case sub <= 7: // saturating truncations
+		case sub == 8: // memory.init
+			r.u32()
+			r.byte()
+		case sub == 9 || sub == 13: // data.drop, elem.drop
+			r.u32()
+		case sub == 10: // memory.copy
+			r.bytes(2)
+		case sub == 11: // memory.fill
+			r.byte()
+		case sub == 12 || sub == 14: // table.init, table.copy
+			r.u32()
+			r.u32()
+		case sub >= 15 && sub <= 17: // table.grow, table.size, table.fill
+			r.u32()
+		default:
+			return false, fmt.Errorf("%w: 0xfc 0x%x", ErrUnsupportedOpcode, sub)
+		}
+	default:
+		return false, fmt.Errorf("%w: 0x%x", ErrUnsupportedOpcode, opcode)
+	}
+	return false, nil
+}
+
+// gasGlobal is the gas global of a metered module.
+type gasGlobal interface {
+	get() uint64
+	set(uint64)
+}
+
+// gasMeter charges the gas consumed by a metered module to the environment.
+// It does nothing for modules that are not metered. Calls the environment
+// does not meter, such as IsStatic, Finalise, Commit and start functions, run
+// with the gas of the limit instead, unless it is zero.
+type gasMeter struct {
+	global gasGlobal
+	env    *api.Env
+	limit  uint64
+	budget uint64
+}
+
+func (m *gasMeter) start(env *api.Env) {
+	m.env = env
+	m.refill()
+}
+
+func (m *gasMeter) stop() {
+	m.charge()
+	m.env = nil
+}
+
+func (m *gasMeter) metered() bool {
+	return m.env != nil && m.env.MeterGas()
+}
+
+// refill sets the gas global to the gas available in the environment, to the
+// limit if the environment does not meter gas, or leaves the guest unmetered.
+func (m *gasMeter) refill() {
+	if m.global == nil {
+		return
+	}
+	switch {
+	case m.metered():
+		m.budget = m.env.Gas()
+	case m.limit > 0:
+		m.budget = m.limit
+	default:
+		m.budget = math.MaxUint64
+	}
+	m.global.set(m.budget)
+}
+
+// charge charges the gas consumed since the last refill to the environment,
+// and refills the gas global. Calls the environment does not meter keep the
+// rest of their limit instead.
+func (m *gasMeter) charge() {
+	if m.global == nil {
+		return
+	}
+	remaining := m.global.get()
+	if !m.metered() {
+		if remaining <= m.budget {
+			m.budget = remaining
+		}
+		return
+	}
+	if remaining > m.budget {
+		// The guest ran out of gas
+		m.env.UseGas(math.MaxUint64)
+	} else {
+		m.env.UseGas(m.budget - remaining)
+	}
+	m.refill()
+}
+
+// initialize runs the start function of a new instance of the module with
+// the gas of the limit, and then refills the gas global for the current call.
+func (m *gasMeter) initialize(start func() error) error {
+	env := m.env
+	m.env = nil
+	m.refill()
+	err := start()
+	m.env = env
+	m.refill()
+	return err
+}
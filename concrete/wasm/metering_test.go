@@ -0,0 +1,235 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/tetratelabs/wazero"
+	wz_api "github.com/tetratelabs/wazero/api"
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+// newLoopModule returns a module exporting a function loop(n) iterating n
+// times, optionally defining a global before the gas global.
+func newLoopModule(withGlobal bool) []byte {
+	code := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x05, 0x01, 0x60, 0x01, 0x7f, 0x00, // type (func (param i32))
+		0x03, 0x02, 0x01, 0x00, // func 0
+	}
+	if withGlobal {
+		// (global i32 (i32.const 7))
+		code = append(code, 0x06, 0x06, 0x01, 0x7f, 0x00, 0x41, 0x07, 0x0b)
+	}
+	code = append(code,
+		0x07, 0x08, 0x01, 0x04, 'l', 'o', 'o', 'p', 0x00, 0x00, // export "loop"
+		0x0a, 0x18, 0x01, 0x16, 0x00,
+		0x02, 0x40, // block
+		0x03, 0x40, // loop
+		0x20, 0x00, 0x45, 0x0d, 0x01, // br_if 1 (i32.eqz (local.get 0))
+		0x20, 0x00, 0x41, 0x01, 0x6b, 0x21, 0x00, // local.set 0 (i32.sub (local.get 0) (i32.const 1))
+		0x0c, 0x00, // br 0
+		0x0b, 0x0b, 0x0b,
+	)
+	return code
+}
+
+// loopGas is the gas used by loop(n): 3 blocks run once, the loop condition
+// n+1 times and the loop body n times.
+func loopGas(n uint64, gasPerInstruction uint64) uint64 {
+	return (3 + 3*(n+1) + 5*n) * gasPerInstruction
+}
+
+func runWazeroLoop(t *testing.T, code []byte, n uint64, gas uint64) (uint64, error) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigInterpreter())
+	defer r.Close(ctx)
+	mod, err := r.Instantiate(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	global := mod.ExportedGlobal(Gas_WasmGlobalName).(wz_api.MutableGlobal)
+	global.Set(gas)
+	_, err = mod.ExportedFunction("loop").Call(ctx, n)
+	return global.Get(), err
+}
+
+func runWasmerLoop(t *testing.T, code []byte, n uint64, gas uint64) (uint64, error) {
+	store := wasmer.NewStore(wasmer.NewEngine())
+	module, err := wasmer.NewModule(store, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := wasmer.NewInstance(module, wasmer.NewImportObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	global, err := instance.Exports.GetGlobal(Gas_WasmGlobalName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wasmerGlobal := wasmerGasGlobal{global}
+	wasmerGlobal.set(gas)
+	loop, err := instance.Exports.GetFunction("loop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = loop(int32(n))
+	return wasmerGlobal.get(), err
+}
+
+func TestGasMetering(t *testing.T) {
+	runtimes := []struct {
+		name string
+		run  func(t *testing.T, code []byte, n uint64, gas uint64) (uint64, error)
+	}{
+		{"wazero", runWazeroLoop},
+		{"wasmer", runWasmerLoop},
+	}
+	for _, withGlobal := range []bool{false, true} {
+		code, err := InstrumentGasMetering(newLoopModule(withGlobal), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, runtime := range runtimes {
+			name := runtime.name
+			if withGlobal {
+				name += "/global"
+			}
+			t.Run(name, func(t *testing.T) {
+				for _, n := range []uint64{0, 1, 10, 1000} {
+					used := loopGas(n, 2)
+					remaining, err := runtime.run(t, code, n, used+5)
+					if err != nil {
+						t.Fatalf("loop(%d): unexpected error: %v", n, err)
+					}
+					if remaining != 5 {
+						t.Fatalf("loop(%d): unexpected gas used: %d != %d", n, used+5-remaining, used)
+					}
+
+					remaining, err = runtime.run(t, code, n, used-1)
+					if err == nil {
+						t.Fatalf("loop(%d): expected out of gas", n)
+					}
+					if remaining != math.MaxUint64 {
+						t.Fatalf("loop(%d): unexpected gas after running out: %d", n, remaining)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestInstrumentGasMeteringErrors(t *testing.T) {
+	code, err := InstrumentGasMetering(newLoopModule(false), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := InstrumentGasMetering(code, 1); !errors.Is(err, ErrAlreadyMetered) {
+		t.Fatalf("expected already metered error, got: %v", err)
+	}
+	if _, err := InstrumentGasMetering([]byte("not wasm"), 1); !errors.Is(err, ErrInvalidWasm) {
+		t.Fatalf("expected invalid wasm error, got: %v", err)
+	}
+	truncated := newLoopModule(false)
+	if _, err := InstrumentGasMetering(truncated[:len(truncated)-2], 1); !errors.Is(err, ErrInvalidWasm) {
+		t.Fatalf("expected invalid wasm error, got: %v", err)
+	}
+	simd := newLoopModule(false)
+	simd[len(simd)-3] = 0xfd
+	if _, err := InstrumentGasMetering(simd, 1); !errors.Is(err, ErrUnsupportedOpcode) {
+		t.Fatalf("expected unsupported opcode error, got: %v", err)
+	}
+}
+
+type mockGasGlobal struct {
+	gas uint64
+}
+
+func (g *mockGasGlobal) get() uint64 {
+	return g.gas
+}
+
+func (g *mockGasGlobal) set(gas uint64) {
+	g.gas = gas
+}
+
+func TestGasMeter(t *testing.T) {
+	global := &mockGasGlobal{}
+	meter := gasMeter{global: global}
+
+	env := mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: true}, true, 100)
+	meter.start(env)
+	if global.gas != 100 {
+		t.Fatalf("unexpected gas: %d", global.gas)
+	}
+	global.gas -= 30
+	meter.charge()
+	if env.Gas() != 70 || global.gas != 70 {
+		t.Fatalf("unexpected gas: %d, %d", env.Gas(), global.gas)
+	}
+	env.UseGas(20)
+	meter.refill()
+	global.gas -= 10
+	meter.stop()
+	if env.Gas() != 40 || env.Error() != nil {
+		t.Fatalf("unexpected gas: %d, %v", env.Gas(), env.Error())
+	}
+
+	meter.start(env)
+	global.gas = math.MaxUint64
+	meter.stop()
+	if env.Error() != api.ErrOutOfGas {
+		t.Fatalf("expected out of gas, got: %v", env.Error())
+	}
+
+	// Environments not metering gas leave the guest unmetered
+	env = mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: true}, false, 0)
+	meter.start(env)
+	if global.gas != math.MaxUint64 {
+		t.Fatalf("unexpected gas: %d", global.gas)
+	}
+	global.gas -= 1000
+	meter.stop()
+	if env.Gas() != 0 || env.Error() != nil {
+		t.Fatalf("unexpected gas: %d, %v", env.Gas(), env.Error())
+	}
+
+	// Or give it the gas of the limit, kept across charges
+	meter.limit = 100
+	meter.start(env)
+	if global.gas != 100 {
+		t.Fatalf("unexpected gas: %d", global.gas)
+	}
+	global.gas -= 30
+	meter.charge()
+	if global.gas != 70 || meter.budget != 70 {
+		t.Fatalf("unexpected gas: %d, %d", global.gas, meter.budget)
+	}
+	meter.stop()
+	meter.start(env)
+	if global.gas != 100 {
+		t.Fatalf("unexpected gas: %d", global.gas)
+	}
+	meter.stop()
+}
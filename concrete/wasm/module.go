@@ -0,0 +1,291 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// Helpers to read and rewrite wasm modules, see
+// https://webassembly.github.io/spec/core/binary/index.html.
+
+var ErrInvalidWasm = errors.New("invalid wasm module")
+
+const (
+	wasmMagic   = "\x00asm"
+	wasmVersion = "\x01\x00\x00\x00"
+
+	customSectionID   = 0
+	typeSectionID     = 1
+	importSectionID   = 2
+	functionSectionID = 3
+	memorySectionID   = 5
+	globalSectionID   = 6
+	exportSectionID   = 7
+	codeSectionID     = 10
+
+	functionExternalKind = 0x00
+	tableExternalKind    = 0x01
+	memoryExternalKind   = 0x02
+	globalExternalKind   = 0x03
+
+	i32ValueType   = 0x7f
+	i64ValueType   = 0x7e
+	f32ValueType   = 0x7d
+	f64ValueType   = 0x7c
+	funcTypeForm   = 0x60
+	mutableGlobal  = 0x01
+	emptyBlockType = 0x40
+)
+
+// sectionOrder is the position of the known sections in a module, which
+// differs from their id for the tag and data count sections.
+var sectionOrder = map[byte]int{
+	1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 13: 6, 6: 7, 7: 8, 8: 9, 9: 10, 12: 11, 10: 12, 11: 13,
+}
+
+type wasmReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *wasmReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: %s at offset %d", ErrInvalidWasm, fmt.Sprintf(format, args...), r.pos)
+	}
+}
+
+func (r *wasmReader) done() bool {
+	return r.err != nil || r.pos >= len(r.data)
+}
+
+func (r *wasmReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.fail("unexpected end")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *wasmReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.fail("unexpected end")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *wasmReader) u32() uint32 {
+	var value uint32
+	for shift := 0; shift < 35; shift += 7 {
+		b := r.byte()
+		value |= uint32(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+	}
+	r.fail("invalid leb128")
+	return 0
+}
+
+// skipLEB skips a signed or unsigned leb128 integer of up to 64 bits.
+func (r *wasmReader) skipLEB() {
+	for i := 0; i < 10; i++ {
+		if r.byte()&0x80 == 0 {
+			return
+		}
+	}
+	r.fail("invalid leb128")
+}
+
+func (r *wasmReader) name() string {
+	return string(r.bytes(int(r.u32())))
+}
+
+func (r *wasmReader) limits() {
+	if flags := r.byte(); flags&0x01 != 0 {
+		r.u32()
+		r.u32()
+	} else {
+		r.u32()
+	}
+}
+
+func appendU32(buf []byte, value uint32) []byte {
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(buf, b)
+		}
+		buf = append(buf, b|0x80)
+	}
+}
+
+func appendS64(buf []byte, value int64) []byte {
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if (value == 0 && b&0x40 == 0) || (value == -1 && b&0x40 != 0) {
+			return append(buf, b)
+		}
+		buf = append(buf, b|0x80)
+	}
+}
+
+type wasmSection struct {
+	id      byte
+	content []byte
+}
+
+func parseModule(code []byte) ([]wasmSection, error) {
+	if len(code) < 8 || string(code[:4]) != wasmMagic || string(code[4:8]) != wasmVersion {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidWasm)
+	}
+	var sections []wasmSection
+	r := &wasmReader{data: code, pos: 8}
+	for !r.done() {
+		id := r.byte()
+		content := r.bytes(int(r.u32()))
+		sections = append(sections, wasmSection{id, content})
+	}
+	return sections, r.err
+}
+
+func encodeModule(sections []wasmSection) []byte {
+	code := []byte(wasmMagic + wasmVersion)
+	for _, section := range sections {
+		code = append(code, section.id)
+		code = appendU32(code, uint32(len(section.content)))
+		code = append(code, section.content...)
+	}
+	return code
+}
+
+// withSection returns the index of a section, inserting an empty one in its
+// position if the module has none.
+func withSection(sections []wasmSection, id byte) ([]wasmSection, int) {
+	for i, section := range sections {
+		if section.id == id {
+			return sections, i
+		}
+	}
+	i := len(sections)
+	for j, section := range sections {
+		if section.id != customSectionID && sectionOrder[section.id] > sectionOrder[id] {
+			i = j
+			break
+		}
+	}
+	sections = append(sections[:i], append([]wasmSection{{id: id}}, sections[i:]...)...)
+	return sections, i
+}
+
+// appendToVector appends an item to a section made of a vector.
+func appendToVector(content []byte, item []byte) ([]byte, error) {
+	r := &wasmReader{data: content}
+	var count uint32
+	if len(content) > 0 {
+		count = r.u32()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	buf := appendU32(nil, count+1)
+	buf = append(buf, content[r.pos:]...)
+	return append(buf, item...), nil
+}
+
+type wasmImport struct {
+	module    string
+	name      string
+	kind      byte
+	typeIndex uint32 // For functions only
+}
+
+func parseImports(content []byte) ([]wasmImport, error) {
+	r := &wasmReader{data: content}
+	var imports []wasmImport
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		imp := wasmImport{module: r.name(), name: r.name(), kind: r.byte()}
+		switch imp.kind {
+		case functionExternalKind:
+			imp.typeIndex = r.u32()
+		case tableExternalKind:
+			r.byte()
+			r.limits()
+		case memoryExternalKind:
+			r.limits()
+		case globalExternalKind:
+			r.byte()
+			r.byte()
+		default:
+			r.fail("unknown import kind 0x%x", imp.kind)
+		}
+		imports = append(imports, imp)
+	}
+	return imports, r.err
+}
+
+// countImports returns the number of imports of each external kind.
+func countImports(content []byte) ([4]uint32, error) {
+	var counts [4]uint32
+	imports, err := parseImports(content)
+	if err != nil {
+		return counts, err
+	}
+	for _, imp := range imports {
+		counts[imp.kind]++
+	}
+	return counts, nil
+}
+
+type funcType struct {
+	params  []byte
+	results []byte
+}
+
+func (t funcType) equal(other funcType) bool {
+	return bytes.Equal(t.params, other.params) && bytes.Equal(t.results, other.results)
+}
+
+func parseFuncTypes(content []byte) ([]funcType, error) {
+	r := &wasmReader{data: content}
+	var types []funcType
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		if form := r.byte(); form != funcTypeForm {
+			r.fail("unknown type form 0x%x", form)
+			break
+		}
+		params := r.bytes(int(r.u32()))
+		results := r.bytes(int(r.u32()))
+		types = append(types, funcType{params, results})
+	}
+	return types, r.err
+}
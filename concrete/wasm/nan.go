@@ -0,0 +1,183 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Float operations returning NaN may set any sign and payload, which differ
+// between runtimes and platforms. Sandboxed modules must run identically on
+// every node, so the NaNs returned by their float operations are replaced by
+// the canonical NaN of their type. Operations that only move the bits of their
+// operands, like loads, stores, abs, neg, copysign and reinterpretations, are
+// deterministic and left untouched.
+
+const (
+	canonicalF32NaN uint32 = 0x7fc00000
+	canonicalF64NaN uint64 = 0x7ff8000000000000
+)
+
+// nanResultType returns the type of the result of the instructions that may
+// return a non canonical NaN, or zero for other instructions.
+func nanResultType(opcode byte) byte {
+	switch {
+	case opcode >= 0x8d && opcode <= 0x97, opcode == 0xb6: // f32 arithmetic, f32.demote_f64
+		return f32ValueType
+	case opcode >= 0x9b && opcode <= 0xa5, opcode == 0xbb: // f64 arithmetic, f64.promote_f32
+		return f64ValueType
+	}
+	return 0
+}
+
+// canonicalizeNaNCode replaces a NaN on top of the stack by the canonical NaN,
+// using a local of the same type:
+//
+//	local.tee $l; const nan; local.get $l; local.get $l; eq; select
+func canonicalizeNaNCode(valueType byte, local uint32) []byte {
+	code := appendU32([]byte{0x22}, local)
+	if valueType == f32ValueType {
+		code = append(code, 0x43)
+		code = binary.LittleEndian.AppendUint32(code, canonicalF32NaN)
+	} else {
+		code = append(code, 0x44)
+		code = binary.LittleEndian.AppendUint64(code, canonicalF64NaN)
+	}
+	code = appendU32(append(code, 0x20), local)
+	code = appendU32(append(code, 0x20), local)
+	if valueType == f32ValueType {
+		code = append(code, 0x5b) // f32.eq
+	} else {
+		code = append(code, 0x61) // f64.eq
+	}
+	return append(code, 0x1b) // select
+}
+
+// canonicalizeNaNs returns the code of a module whose float operations return
+// the canonical NaN instead of any NaN.
+func canonicalizeNaNs(code []byte) ([]byte, error) {
+	sections, err := parseModule(code)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		types []funcType
+		funcs []uint32
+	)
+	for _, s := range sections {
+		switch s.id {
+		case typeSectionID:
+			if types, err = parseFuncTypes(s.content); err != nil {
+				return nil, err
+			}
+		case functionSectionID:
+			r := &wasmReader{data: s.content}
+			for n := r.u32(); n > 0 && r.err == nil; n-- {
+				funcs = append(funcs, r.u32())
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+		}
+	}
+	for i := range sections {
+		if sections[i].id != codeSectionID {
+			continue
+		}
+		r := &wasmReader{data: sections[i].content}
+		n := r.u32()
+		if int(n) != len(funcs) {
+			return nil, fmt.Errorf("%w: %d function bodies for %d functions", ErrInvalidWasm, n, len(funcs))
+		}
+		out := appendU32(nil, n)
+		for _, typeIndex := range funcs {
+			body := r.bytes(int(r.u32()))
+			if r.err != nil {
+				return nil, r.err
+			}
+			if int(typeIndex) >= len(types) {
+				return nil, fmt.Errorf("%w: unknown type %d", ErrInvalidWasm, typeIndex)
+			}
+			body, err := canonicalizeFunctionNaNs(body, len(types[typeIndex].params))
+			if err != nil {
+				return nil, err
+			}
+			out = appendU32(out, uint32(len(body)))
+			out = append(out, body...)
+		}
+		if !r.done() {
+			r.fail("trailing bytes in code section")
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		sections[i].content = out
+	}
+	return encodeModule(sections), nil
+}
+
+// canonicalizeFunctionNaNs canonicalizes the NaNs of a function body, adding
+// an f32 and an f64 local after the locals of the function if needed.
+func canonicalizeFunctionNaNs(body []byte, params int) ([]byte, error) {
+	r := &wasmReader{data: body}
+	decls := r.u32()
+	declsStart := r.pos
+	locals := uint64(params)
+	for n := decls; n > 0 && r.err == nil; n-- {
+		locals += uint64(r.u32())
+		r.byte()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if locals+2 > uint64(^uint32(0)) {
+		return nil, fmt.Errorf("%w: too many locals", ErrInvalidWasm)
+	}
+	var (
+		codeStart = r.pos
+		f32Local  = uint32(locals)
+		f64Local  = uint32(locals) + 1
+		code      []byte
+		changed   bool
+	)
+	for !r.done() {
+		start := r.pos
+		opcode := r.byte()
+		if _, err := skipImmediates(r, opcode); err != nil {
+			return nil, err
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		code = append(code, body[start:r.pos]...)
+		switch nanResultType(opcode) {
+		case f32ValueType:
+			code = append(code, canonicalizeNaNCode(f32ValueType, f32Local)...)
+			changed = true
+		case f64ValueType:
+			code = append(code, canonicalizeNaNCode(f64ValueType, f64Local)...)
+			changed = true
+		}
+	}
+	if !changed {
+		return body, nil
+	}
+	out := appendU32(nil, decls+2)
+	out = append(out, body[declsStart:codeStart]...)
+	out = append(out, 0x01, f32ValueType, 0x01, f64ValueType)
+	return append(out, code...), nil
+}
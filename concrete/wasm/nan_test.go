@@ -0,0 +1,144 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+// newNaNModule returns a module exporting f32(x) returning the bits of x + x,
+// with a local before the one added by canonicalization, and f64(x) returning
+// the bits of sqrt(x).
+func newNaNModule() []byte {
+	return []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		// types (func (param f32) (result i32)) (func (param f64) (result i64))
+		0x01, 0x0b, 0x02, 0x60, 0x01, 0x7d, 0x01, 0x7f, 0x60, 0x01, 0x7c, 0x01, 0x7e,
+		0x03, 0x03, 0x02, 0x00, 0x01, // funcs 0 1
+		0x07, 0x0d, 0x02, 0x03, 'f', '3', '2', 0x00, 0x00, 0x03, 'f', '6', '4', 0x00, 0x01, // exports
+		0x0a, 0x17, 0x02,
+		// (local i32) local.set 1 (i32.reinterpret_f32 (f32.add (local.get 0) (local.get 0))) local.get 1
+		0x0e, 0x01, 0x01, 0x7f, 0x20, 0x00, 0x20, 0x00, 0x92, 0xbc, 0x21, 0x01, 0x20, 0x01, 0x0b,
+		// i64.reinterpret_f64 (f64.sqrt (local.get 0))
+		0x06, 0x00, 0x20, 0x00, 0x9f, 0xbd, 0x0b,
+	}
+}
+
+func runWazeroNaNModule(t *testing.T, code []byte, f32 uint32, f64 uint64) (uint32, uint64) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfigCompiler())
+	defer r.Close(ctx)
+	mod, err := r.Instantiate(ctx, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res32, err := mod.ExportedFunction("f32").Call(ctx, uint64(f32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res64, err := mod.ExportedFunction("f64").Call(ctx, f64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uint32(res32[0]), res64[0]
+}
+
+func runWasmerNaNModule(t *testing.T, code []byte, f32 uint32, f64 uint64) (uint32, uint64) {
+	store := wasmer.NewStore(wasmer.NewEngine())
+	module, err := wasmer.NewModule(store, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance, err := wasmer.NewInstance(module, wasmer.NewImportObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn32, err := instance.Exports.GetFunction("f32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn64, err := instance.Exports.GetFunction("f64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res32, err := fn32(math.Float32frombits(f32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res64, err := fn64(math.Float64frombits(f64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return uint32(res32.(int32)), uint64(res64.(int64))
+}
+
+func TestCanonicalizeNaNs(t *testing.T) {
+	code, err := canonicalizeNaNs(newNaNModule())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		f32, want32  uint32
+		in64, want64 uint64
+	}{
+		// 1.5 + 1.5, sqrt(4)
+		{f32: math.Float32bits(1.5), want32: math.Float32bits(3), in64: math.Float64bits(4), want64: math.Float64bits(2)},
+		// NaN with a payload, sqrt(-1)
+		{f32: 0x7fa00001, want32: canonicalF32NaN, in64: math.Float64bits(-1), want64: canonicalF64NaN},
+		// Negative NaN, NaN with a payload
+		{f32: 0xffc00000, want32: canonicalF32NaN, in64: 0x7ff0000000000001, want64: canonicalF64NaN},
+	}
+	runtimes := []struct {
+		name string
+		run  func(t *testing.T, code []byte, f32 uint32, f64 uint64) (uint32, uint64)
+	}{
+		{"wazero", runWazeroNaNModule},
+		{"wasmer", runWasmerNaNModule},
+	}
+	for _, runtime := range runtimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			for _, test := range tests {
+				got32, got64 := runtime.run(t, code, test.f32, test.in64)
+				if got32 != test.want32 {
+					t.Errorf("f32(%#x): got %#x, want %#x", test.f32, got32, test.want32)
+				}
+				if got64 != test.want64 {
+					t.Errorf("f64(%#x): got %#x, want %#x", test.in64, got64, test.want64)
+				}
+			}
+		})
+	}
+
+	// Sandboxed precompiles run canonicalized code
+	sandboxed, err := sandboxCode(newNaNModule(), &SandboxConfig{Unmetered: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got32, got64 := runWazeroNaNModule(t, sandboxed, 0x7fa00001, math.Float64bits(-1)); got32 != canonicalF32NaN || got64 != canonicalF64NaN {
+		t.Fatalf("unexpected sandboxed results: %#x, %#x", got32, got64)
+	}
+
+	// Modules without float operations are unchanged
+	loop := newLoopModule(true)
+	if unchanged, err := canonicalizeNaNs(loop); err != nil || string(unchanged) != string(loop) {
+		t.Fatalf("unexpected canonicalization: %x, %v", unchanged, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import "github.com/ethereum/go-ethereum/concrete"
+
+// DefaultPoolSize is the number of instances of the module of precompiles
+// created without a pool size.
+const DefaultPoolSize = 1
+
+// instancePool holds the instances of the module of a precompile, all created
+// from the same compiled module. Each call takes an instance for its whole
+// duration, so calls run concurrently without sharing the state of an
+// instance. Instances are instantiated again after each call, so that the
+// result of a call does not depend on the instance it ran in.
+type instancePool[T any] struct {
+	instances chan T
+	all       []T
+}
+
+func newInstancePool[T any](size int, newInstance func() (T, error)) (*instancePool[T], error) {
+	if size <= 0 {
+		size = DefaultPoolSize
+	}
+	pool := &instancePool[T]{instances: make(chan T, size)}
+	for i := 0; i < size; i++ {
+		instance, err := newInstance()
+		if err != nil {
+			return nil, err
+		}
+		pool.instances <- instance
+		pool.all = append(pool.all, instance)
+	}
+	return pool, nil
+}
+
+// get takes an instance from the pool, waiting for one to be released if
+// all are in use.
+func (p *instancePool[T]) get() T {
+	return <-p.instances
+}
+
+func (p *instancePool[T]) put(instance T) {
+	p.instances <- instance
+}
+
+// MemoryStats describes the guest memory of the instances of a precompile.
+type MemoryStats struct {
+	Instances int    // Number of instances of the module
+	Size      uint64 // Total size of their linear memories in bytes
+}
+
+// memoryReporter is implemented by the wasm precompiles.
+type memoryReporter interface {
+	memoryStats() MemoryStats
+}
+
+// MemoryUsage returns the guest memory of a wasm precompile, as of the end of
+// the last call into each of its instances. It returns false if the
+// precompile is not a wasm precompile.
+func MemoryUsage(pc concrete.Precompile) (MemoryStats, bool) {
+	reporter, ok := pc.(memoryReporter)
+	if !ok {
+		return MemoryStats{}, false
+	}
+	return reporter.memoryStats(), true
+}
+
+func poolMemoryStats[T interface{ memorySize() uint64 }](pool *instancePool[T]) MemoryStats {
+	stats := MemoryStats{Instances: len(pool.all)}
+	for _, instance := range pool.all {
+		stats.Size += instance.memorySize()
+	}
+	return stats
+}
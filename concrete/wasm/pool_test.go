@@ -0,0 +1,265 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+)
+
+func TestInstancePool(t *testing.T) {
+	created := 0
+	pool, err := newInstancePool(3, func() (int, error) {
+		created++
+		return created, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 3 {
+		t.Fatalf("unexpected instances: %d", created)
+	}
+	seen := make(map[int]bool)
+	for i := 0; i < 3; i++ {
+		seen[pool.get()] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("expected distinct instances, got: %v", seen)
+	}
+	for instance := range seen {
+		pool.put(instance)
+	}
+
+	if _, err := newInstancePool(0, func() (int, error) { return 0, fmt.Errorf("failed") }); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestPrecompilePoolConcurrency(t *testing.T) {
+	code := newSandboxTestModule(1)
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc, err := rt.new(code, SandboxConfig{PoolSize: 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var (
+				wg     sync.WaitGroup
+				errors = make(chan error, 16)
+			)
+			for i := 0; i < 16; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for j := 0; j < 50; j++ {
+						op, want := byte(sandboxTestWork), error(nil)
+						if (i+j)%5 == 0 {
+							op, want = sandboxTestTrap, api.ErrExecutionReverted
+						}
+						env := newUntrustedEnvironment(1_000_000)
+						if _, _, err := concrete.RunPrecompile(pc, env, []byte{op}, false); err != want {
+							errors <- fmt.Errorf("op %d: unexpected error: %v", op, err)
+							return
+						}
+					}
+				}(i)
+			}
+			wg.Wait()
+			close(errors)
+			for err := range errors {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestPrecompilePoolIsolation checks that calls do not see the globals and
+// memory written by previous calls, whichever instance they run in.
+func TestPrecompilePoolIsolation(t *testing.T) {
+	code := newSandboxTestModule(1)
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			sandboxed, err := rt.new(code, SandboxConfig{PoolSize: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			trusted := rt.newTrusted(code)
+			for i := 0; i < 5; i++ {
+				env := newUntrustedEnvironment(1_000_000)
+				if _, _, err := concrete.RunPrecompile(sandboxed, env, []byte{sandboxTestCount}, false); err != nil {
+					t.Fatalf("sandboxed call %d: unexpected error: %v", i, err)
+				}
+				env = mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: true}, false, 0)
+				if _, err := trusted.Run(env, []byte{sandboxTestCount}); err != nil {
+					t.Fatalf("trusted call %d: unexpected error: %v", i, err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPrecompilePool measures the throughput of concurrent calls, as
+// served to RPC requests, with a single instance and with an instance per
+// thread. Run with -cpu to compare GOMAXPROCS values.
+func BenchmarkPrecompilePool(b *testing.B) {
+	code := newSandboxTestModule(1)
+	for _, rt := range sandboxRuntimes {
+		for _, perThread := range []bool{false, true} {
+			name := rt.name + "/pool=1"
+			if perThread {
+				name = rt.name + "/pool=procs"
+			}
+			b.Run(name, func(b *testing.B) {
+				size := 1
+				if perThread {
+					size = goruntime.GOMAXPROCS(0)
+				}
+				pc, err := rt.new(code, SandboxConfig{PoolSize: size})
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					env := mock.NewMockEnvironment(common.Address{}, api.EnvConfig{}, false, 0)
+					for pb.Next() {
+						if _, err := pc.Run(env, []byte{sandboxTestWork}); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// TestPrecompileMemorySoak checks that the guest memory of a precompile and
+// the time of its calls stay flat over many calls, each allocating the input
+// in guest memory.
+func TestPrecompileMemorySoak(t *testing.T) {
+	calls := 1_000_000
+	if testing.Short() {
+		calls = 10_000
+	}
+	const batch = 1_000
+	code := newSandboxTestModule(1)
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc := rt.newTrusted(code)
+			env := mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: true}, false, 0)
+			input := make([]byte, 1024)
+			input[0] = sandboxTestOk
+
+			if _, err := pc.Run(env, input); err != nil {
+				t.Fatal(err)
+			}
+			before, ok := MemoryUsage(pc)
+			if !ok {
+				t.Fatal("expected memory usage")
+			}
+			if before.Instances != DefaultPoolSize || before.Size != 65536 {
+				t.Fatalf("unexpected memory usage: %+v", before)
+			}
+			var first, last time.Duration
+			for i := 0; i < calls; i += batch {
+				start := time.Now()
+				for j := i; j < i+batch; j++ {
+					if _, err := pc.Run(env, input); err != nil {
+						t.Fatalf("call %d: %v", j, err)
+					}
+				}
+				if last = time.Since(start); i == 0 {
+					first = last
+				}
+			}
+			if last > 4*first {
+				t.Fatalf("calls slowed down from %v to %v per %d calls", first, last, batch)
+			}
+			if after, _ := MemoryUsage(pc); after != before {
+				t.Fatalf("memory grew from %+v to %+v", before, after)
+			}
+		})
+	}
+}
+
+// TestTinygoMemorySoak runs the tinygo blank precompile, whose infra allocator
+// keeps every buffer malloc'd by the host until it is freed or pruned, and
+// checks that its guest memory stays bounded over many calls.
+func TestTinygoMemorySoak(t *testing.T) {
+	if len(blankCode) == 0 {
+		t.Skip("testdata/blank.wasm is empty, run make concrete-wasm")
+	}
+	calls := 10_000
+	if testing.Short() {
+		calls = 100
+	}
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc := rt.newTrusted(blankCode)
+			env := mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: true}, false, 0)
+			input := make([]byte, 64*1024)
+
+			call := func() {
+				if _, err := pc.Run(env, input); err != nil {
+					t.Fatal(err)
+				}
+				if err := pc.Finalise(env); err != nil {
+					t.Fatal(err)
+				}
+				if err := pc.Commit(env); err != nil {
+					t.Fatal(err)
+				}
+			}
+			call()
+			before, ok := MemoryUsage(pc)
+			if !ok {
+				t.Fatal("expected memory usage")
+			}
+			for i := 0; i < calls; i++ {
+				call()
+			}
+			if after, _ := MemoryUsage(pc); after.Size > before.Size {
+				t.Fatalf("memory grew from %+v to %+v", before, after)
+			}
+		})
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	code := newSandboxTestModule(2)
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc, err := rt.new(code, SandboxConfig{PoolSize: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats, _ := MemoryUsage(pc); stats != (MemoryStats{Instances: 3, Size: 3 * 2 * 65536}) {
+				t.Fatalf("unexpected memory usage: %+v", stats)
+			}
+		})
+	}
+	if _, ok := MemoryUsage(nil); ok {
+		t.Fatal("expected no memory usage")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sandboxed precompiles run modules that are not trusted by the node. Unlike
+// other precompiles, constructors return errors instead of panicking, and
+// traps, memory limit hits and timeouts revert the call. The module is
+// instantiated again after a failed call, as its state may be inconsistent.
+// Sandboxed precompiles can run in untrusted environments.
+//
+// As untrusted modules run in consensus, their execution is metered and their
+// float operations return canonical NaNs by default, so that all nodes agree
+// on their gas use and results.
+
+var (
+	ErrTrap               = errors.New("wasm precompile trapped")
+	ErrTimeout            = errors.New("wasm precompile timed out")
+	ErrMemoryLimit        = errors.New("wasm memory exceeds limit")
+	ErrTimeoutUnsupported = errors.New("wasm runtime does not support timeouts")
+)
+
+const (
+	// DefaultMaxMemoryPages is the default memory limit of sandboxed modules,
+	// in 64KiB pages.
+	DefaultMaxMemoryPages = 256
+	// DefaultGasPerInstruction is the default gas charged for each instruction
+	// executed by sandboxed modules.
+	DefaultGasPerInstruction = 1
+	// DefaultMaxUnmeteredGas is the default gas of the calls into sandboxed
+	// modules that are not metered by the environment.
+	DefaultMaxUnmeteredGas = 50_000_000
+)
+
+// SandboxConfig configures sandboxed precompiles.
+type SandboxConfig struct {
+	// MaxMemoryPages caps the memory of the module, in 64KiB pages. Zero uses
+	// DefaultMaxMemoryPages.
+	MaxMemoryPages uint32
+	// Timeout reverts calls running for longer. Zero disables it. Timeouts
+	// depend on the machine running the module, so they cannot replace gas
+	// metering in consensus.
+	Timeout time.Duration
+	// PoolSize is the number of calls that can run concurrently, each in its
+	// own instance of the module. Zero uses DefaultPoolSize.
+	PoolSize int
+	// GasPerInstruction is the gas charged for each executed instruction, see
+	// InstrumentGasMetering. Zero uses DefaultGasPerInstruction. Modules that
+	// are already metered run with their own metering.
+	GasPerInstruction uint64
+	// Unmetered disables gas metering. Calls are then only bounded by the
+	// timeout, so unmetered modules must not run in consensus.
+	Unmetered bool
+	// MaxUnmeteredGas caps the gas of the calls the environment does not
+	// meter, IsStatic, Finalise and Commit, which trap once it runs out. Zero
+	// uses DefaultMaxUnmeteredGas.
+	MaxUnmeteredGas uint64
+}
+
+func (c *SandboxConfig) maxMemoryPages() uint32 {
+	if c.MaxMemoryPages == 0 {
+		return DefaultMaxMemoryPages
+	}
+	return c.MaxMemoryPages
+}
+
+func (c *SandboxConfig) maxUnmeteredGas() uint64 {
+	if c.MaxUnmeteredGas == 0 {
+		return DefaultMaxUnmeteredGas
+	}
+	return c.MaxUnmeteredGas
+}
+
+func (c *SandboxConfig) gasPerInstruction() uint64 {
+	if c.GasPerInstruction == 0 {
+		return DefaultGasPerInstruction
+	}
+	return c.GasPerInstruction
+}
+
+// sandboxCode returns the code run by a sandboxed precompile: the memory of
+// the module is limited, its NaNs are canonicalized and it is metered unless
+// disabled or already done.
+func sandboxCode(code []byte, sandbox *SandboxConfig) ([]byte, error) {
+	code, err := limitMemory(code, sandbox.maxMemoryPages())
+	if err != nil {
+		return nil, err
+	}
+	if code, err = canonicalizeNaNs(code); err != nil {
+		return nil, err
+	}
+	if sandbox.Unmetered {
+		return code, nil
+	}
+	metered, err := InstrumentGasMetering(code, sandbox.gasPerInstruction())
+	if errors.Is(err, ErrAlreadyMetered) {
+		return code, nil
+	}
+	return metered, err
+}
+
+// limitMemory caps the maximum size of the memories defined by a module, so
+// that growing them further fails in the guest regardless of the runtime.
+func limitMemory(code []byte, maxPages uint32) ([]byte, error) {
+	sections, err := parseModule(code)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range sections {
+		switch s.id {
+		case importSectionID:
+			imports, err := countImports(s.content)
+			if err != nil {
+				return nil, err
+			}
+			if imports[memoryExternalKind] > 0 {
+				return nil, fmt.Errorf("%w: imported memories cannot be limited", ErrMemoryLimit)
+			}
+		case memorySectionID:
+			r := &wasmReader{data: s.content}
+			n := r.u32()
+			content := appendU32(nil, n)
+			for ; n > 0 && r.err == nil; n-- {
+				flags := r.byte()
+				min, max := r.u32(), maxPages
+				switch flags {
+				case 0x00:
+				case 0x01:
+					if declared := r.u32(); declared < max {
+						max = declared
+					}
+				default:
+					return nil, fmt.Errorf("%w: unsupported memory flags 0x%x", ErrInvalidWasm, flags)
+				}
+				if min > maxPages {
+					return nil, fmt.Errorf("%w: %d initial pages, limit is %d", ErrMemoryLimit, min, maxPages)
+				}
+				content = append(content, 0x01)
+				content = appendU32(content, min)
+				content = appendU32(content, max)
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+			sections[i].content = content
+		}
+	}
+	return encodeModule(sections), nil
+}
+
+// sandboxError returns the error reverting a call that panicked.
+func sandboxError(r interface{}) error {
+	return fmt.Errorf("%w: %v", ErrTrap, r)
+}
@@ -0,0 +1,424 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/ethereum/go-ethereum/concrete/wasm/host"
+	"github.com/ethereum/go-ethereum/concrete/wasm/memory"
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+const (
+	sandboxTestOk = iota
+	sandboxTestTrap
+	sandboxTestLoop
+	sandboxTestGrow
+	sandboxTestBadPointer
+	sandboxTestWork
+	sandboxTestCount
+)
+
+// newSandboxTestModule returns a precompile module with a bump allocator
+// growing the memory as needed and released by Prune, whose Run behaves
+// according to the first input byte.
+func newSandboxTestModule(initialPages uint32) []byte {
+	type function struct {
+		name   string
+		typ    byte
+		locals []byte
+		body   []byte
+	}
+	functions := []function{
+		{AbiVersion_WasmFuncName, 3, nil, []byte{0x42, memory.AbiVersion}},
+		{host.Malloc_WasmFuncName, 0, nil, []byte{
+			// while heap + size > memory.size << 16 { trap on memory.grow(1) failure }
+			0x02, 0x40, 0x03, 0x40,
+			0x23, 0x00, 0x20, 0x00, 0xa7, 0x6a, 0x3f, 0x00, 0x41, 0x10, 0x74, 0x4d, 0x0d, 0x01,
+			0x41, 0x01, 0x40, 0x00, 0x41, 0x7f, 0x46, 0x04, 0x40, 0x00, 0x0b,
+			0x0c, 0x00, 0x0b, 0x0b,
+			// pointer = heap << 32 | size; heap += size
+			0x23, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x00, 0x84,
+			0x23, 0x00, 0x20, 0x00, 0xa7, 0x6a, 0x24, 0x00,
+		}},
+		{host.Free_WasmFuncName, 1, nil, nil},
+		{host.Prune_WasmFuncName, 2, nil, []byte{
+			// heap = 1024
+			0x41, 0x80, 0x08, 0x24, 0x00,
+		}},
+		{IsStatic_WasmFuncName, 0, nil, []byte{0x42, 0x01}},
+		{Finalise_WasmFuncName, 3, nil, []byte{0x42, 0x00}},
+		{Commit_WasmFuncName, 3, nil, []byte{0x42, 0x00}},
+		{Run_WasmFuncName, 0, []byte{0x01, 0x01, 0x7f}, []byte{
+			// if input is null, return null
+			0x20, 0x00, 0x50, 0x04, 0x40, 0x42, 0x00, 0x0f, 0x0b,
+			// op = memory[input >> 32]
+			0x20, 0x00, 0x42, 0x20, 0x88, 0xa7, 0x2d, 0x00, 0x00, 0x21, 0x01,
+			// trap
+			0x20, 0x01, 0x41, sandboxTestTrap, 0x46, 0x04, 0x40, 0x00, 0x0b,
+			// loop forever
+			0x20, 0x01, 0x41, sandboxTestLoop, 0x46, 0x04, 0x40, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b,
+			// grow memory by 1000 pages, trap on failure
+			0x20, 0x01, 0x41, sandboxTestGrow, 0x46, 0x04, 0x40,
+			0x41, 0xe8, 0x07, 0x40, 0x00, 0x41, 0x7f, 0x46, 0x04, 0x40, 0x00, 0x0b, 0x0b,
+			// return a pointer out of memory
+			0x20, 0x01, 0x41, sandboxTestBadPointer, 0x46, 0x04, 0x40, 0x42, 0x7f, 0x0f, 0x0b,
+			// loop 1000 times
+			0x20, 0x01, 0x41, sandboxTestWork, 0x46, 0x04, 0x40, 0x41, 0xe8, 0x07, 0x21, 0x01,
+			0x03, 0x40, 0x20, 0x01, 0x41, 0x01, 0x6b, 0x22, 0x01, 0x0d, 0x00, 0x0b, 0x0b,
+			// count = count + 1, trap if count != 1 or memory[16] != 0, memory[16] = 1
+			0x20, 0x01, 0x41, sandboxTestCount, 0x46, 0x04, 0x40,
+			0x23, 0x01, 0x41, 0x01, 0x6a, 0x24, 0x01,
+			0x23, 0x01, 0x41, 0x01, 0x47, 0x04, 0x40, 0x00, 0x0b,
+			0x41, 0x10, 0x2d, 0x00, 0x00, 0x04, 0x40, 0x00, 0x0b,
+			0x41, 0x10, 0x41, 0x01, 0x3a, 0x00, 0x00, 0x0b,
+			0x42, 0x00,
+		}},
+	}
+
+	var sections []wasmSection
+	// (i64) -> i64, (i64) -> (), () -> (), () -> i64
+	sections = append(sections, wasmSection{1, []byte{
+		0x04,
+		0x60, 0x01, 0x7e, 0x01, 0x7e,
+		0x60, 0x01, 0x7e, 0x00,
+		0x60, 0x00, 0x00,
+		0x60, 0x00, 0x01, 0x7e,
+	}})
+	funcs := appendU32(nil, uint32(len(functions)))
+	for _, f := range functions {
+		funcs = append(funcs, f.typ)
+	}
+	sections = append(sections, wasmSection{3, funcs})
+	sections = append(sections, wasmSection{memorySectionID, appendU32([]byte{0x01, 0x00}, initialPages)})
+	// (global (mut i32) (i32.const 1024)) (global (mut i32) (i32.const 0))
+	sections = append(sections, wasmSection{globalSectionID, []byte{0x02, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b, 0x7f, 0x01, 0x41, 0x00, 0x0b}})
+	exports := appendU32(nil, uint32(len(functions)+1))
+	exports = append(exports, 0x06, 'm', 'e', 'm', 'o', 'r', 'y', memoryExternalKind, 0x00)
+	for i, f := range functions {
+		exports = appendU32(exports, uint32(len(f.name)))
+		exports = append(exports, f.name...)
+		exports = append(exports, functionExternalKind, byte(i))
+	}
+	sections = append(sections, wasmSection{exportSectionID, exports})
+	code := appendU32(nil, uint32(len(functions)))
+	for _, f := range functions {
+		locals := f.locals
+		if locals == nil {
+			locals = []byte{0x00}
+		}
+		body := append(append(append([]byte{}, locals...), f.body...), 0x0b)
+		code = appendU32(code, uint32(len(body)))
+		code = append(code, body...)
+	}
+	sections = append(sections, wasmSection{codeSectionID, code})
+	return encodeModule(sections)
+}
+
+type sandboxRuntime struct {
+	name       string
+	new        func(code []byte, sandbox SandboxConfig) (concrete.Precompile, error)
+	timeouts   bool
+	newTrusted func(code []byte) concrete.Precompile
+}
+
+var sandboxRuntimes = []sandboxRuntime{
+	{
+		name: "wazero",
+		new: func(code []byte, sandbox SandboxConfig) (concrete.Precompile, error) {
+			return NewWazeroSandboxedPrecompile(code, nil, sandbox)
+		},
+		timeouts:   true,
+		newTrusted: NewWazeroPrecompile,
+	},
+	{
+		name: "wasmer",
+		new: func(code []byte, sandbox SandboxConfig) (concrete.Precompile, error) {
+			return NewWasmerSandboxedPrecompile(code, wasmer.NewConfig().UseSinglepassCompiler(), sandbox)
+		},
+		newTrusted: NewWasmerPrecompile,
+	},
+}
+
+func newUntrustedEnvironment(gas uint64) *api.Env {
+	return mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: false}, true, gas)
+}
+
+func TestSandboxedPrecompile(t *testing.T) {
+	code := newSandboxTestModule(1)
+	for _, runtime := range sandboxRuntimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			sandbox := SandboxConfig{MaxMemoryPages: 16, Unmetered: true}
+			if runtime.timeouts {
+				sandbox.Timeout = 100 * time.Millisecond
+			}
+			pc, err := runtime.new(code, sandbox)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			run := func(op byte) error {
+				env := newUntrustedEnvironment(1_000_000)
+				_, _, err := concrete.RunPrecompile(pc, env, []byte{op}, false)
+				return err
+			}
+			if err := run(sandboxTestOk); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !pc.IsStatic([]byte{sandboxTestOk}) {
+				t.Fatal("expected static precompile")
+			}
+
+			failures := []byte{sandboxTestTrap, sandboxTestGrow, sandboxTestBadPointer}
+			if runtime.timeouts {
+				failures = append(failures, sandboxTestLoop)
+			}
+			for _, op := range failures {
+				if err := run(op); err != api.ErrExecutionReverted {
+					t.Fatalf("op %d: expected revert, got: %v", op, err)
+				}
+				// The module is usable after failures
+				if err := run(sandboxTestOk); err != nil {
+					t.Fatalf("op %d: unexpected error after failure: %v", op, err)
+				}
+			}
+
+			output, err := pc.Run(newUntrustedEnvironment(1_000_000), []byte{sandboxTestTrap})
+			if !errors.Is(err, ErrTrap) || output != nil {
+				t.Fatalf("expected trap, got: %x, %v", output, err)
+			}
+			if runtime.timeouts {
+				_, err = pc.Run(newUntrustedEnvironment(1_000_000), []byte{sandboxTestLoop})
+				if err != ErrTimeout {
+					t.Fatalf("expected timeout, got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSandboxedPrecompileGasMetering(t *testing.T) {
+	code, err := InstrumentGasMetering(newSandboxTestModule(1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, runtime := range sandboxRuntimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			pc, err := runtime.new(code, SandboxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := newUntrustedEnvironment(1_000_000)
+			_, remaining, err := concrete.RunPrecompile(pc, env, []byte{sandboxTestLoop}, false)
+			if err != api.ErrOutOfGas {
+				t.Fatalf("expected out of gas, got: %v", err)
+			}
+			if remaining != 1_000_000 {
+				t.Fatalf("unexpected remaining gas: %d", remaining)
+			}
+
+			env = newUntrustedEnvironment(1_000_000)
+			_, remaining, err = concrete.RunPrecompile(pc, env, []byte{sandboxTestOk}, false)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if remaining >= 1_000_000 {
+				t.Fatal("expected gas to be used")
+			}
+		})
+	}
+}
+
+func TestSandboxedPrecompileDefaultMetering(t *testing.T) {
+	code := newSandboxTestModule(1)
+	for _, runtime := range sandboxRuntimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			// Infinite loops run out of gas without timeout
+			pc, err := runtime.new(code, SandboxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _, err = concrete.RunPrecompile(pc, newUntrustedEnvironment(1_000_000), []byte{sandboxTestLoop}, false)
+			if err != api.ErrOutOfGas {
+				t.Fatalf("expected out of gas, got: %v", err)
+			}
+
+			run := func(sandbox SandboxConfig) uint64 {
+				pc, err := runtime.new(code, sandbox)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, remaining, err := concrete.RunPrecompile(pc, newUntrustedEnvironment(1_000_000), []byte{sandboxTestWork}, false)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return 1_000_000 - remaining
+			}
+			used := run(SandboxConfig{})
+			if used < 1000 {
+				t.Fatalf("expected the loop to be metered, used %d gas", used)
+			}
+			if doubled := run(SandboxConfig{GasPerInstruction: 2 * DefaultGasPerInstruction}); doubled <= used {
+				t.Fatalf("expected more gas per instruction to use more gas: %d <= %d", doubled, used)
+			}
+			if unmetered := run(SandboxConfig{Unmetered: true}); unmetered >= used {
+				t.Fatalf("expected unmetered module to use less gas: %d >= %d", unmetered, used)
+			}
+		})
+	}
+}
+
+// TestSandboxedPrecompileUnmeteredLimit checks that the calls the environment
+// does not meter trap once they run out of the unmetered gas, instead of
+// looping forever.
+func TestSandboxedPrecompileUnmeteredLimit(t *testing.T) {
+	code, err := wasmer.Wat2Wasm(`(module
+  (memory (export "memory") 1)
+  (func $loop (loop (br 0)))
+  (func (export "concrete_AbiVersion") (result i64) (i64.const 1))
+  (func (export "concrete_Malloc") (param i64) (result i64) (i64.const 0x0000040000000000))
+  (func (export "concrete_Free") (param i64))
+  (func (export "concrete_Prune"))
+  (func (export "concrete_IsStatic") (param i64) (result i64) (call $loop) (i64.const 1))
+  (func (export "concrete_Finalise") (result i64) (call $loop) (i64.const 0))
+  (func (export "concrete_Commit") (result i64) (call $loop) (i64.const 0))
+  (func (export "concrete_Run") (param i64) (result i64) (i64.const 0)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, runtime := range sandboxRuntimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			pc, err := runtime.new(code, SandboxConfig{MaxUnmeteredGas: 100_000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := mock.NewMockEnvironment(common.Address{}, api.EnvConfig{}, false, 0)
+			for i := 0; i < 2; i++ {
+				if pc.IsStatic(nil) {
+					t.Fatal("expected looping IsStatic to fail")
+				}
+				if err := pc.Finalise(env); !errors.Is(err, ErrTrap) {
+					t.Fatalf("expected Finalise to trap, got: %v", err)
+				}
+				if err := pc.Commit(env); !errors.Is(err, ErrTrap) {
+					t.Fatalf("expected Commit to trap, got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// TestSandboxedPrecompileStart checks that metered modules run their _start
+// function with gas on every runtime, once for each instance of the module.
+func TestSandboxedPrecompileStart(t *testing.T) {
+	newModule := func(start string) []byte {
+		code, err := wasmer.Wat2Wasm(`(module
+  (memory (export "memory") 1)
+  (func (export "_start") ` + start + `
+    (i32.store (i32.const 0) (i32.add (i32.load (i32.const 0)) (i32.const 1))))
+  (func (export "concrete_AbiVersion") (result i64) (i64.const 1))
+  (func (export "concrete_Malloc") (param i64) (result i64) (i64.const 0x0000040000000000))
+  (func (export "concrete_Free") (param i64))
+  (func (export "concrete_Prune"))
+  (func (export "concrete_IsStatic") (param i64) (result i64)
+    (i64.extend_i32_u (i32.eq (i32.load (i32.const 0)) (i32.const 1))))
+  (func (export "concrete_Finalise") (result i64) (i64.const 0))
+  (func (export "concrete_Commit") (result i64) (i64.const 0))
+  (func (export "concrete_Run") (param i64) (result i64) (i64.const 0)))`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	for _, runtime := range sandboxRuntimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			pc, err := runtime.new(newModule(""), SandboxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 3; i++ {
+				if !pc.IsStatic(nil) {
+					t.Fatalf("call %d: expected _start to run once", i)
+				}
+			}
+
+			// Start functions are bounded by the unmetered gas
+			_, err = runtime.new(newModule("(loop (br 0))"), SandboxConfig{MaxUnmeteredGas: 100_000})
+			if err == nil {
+				t.Fatal("expected looping _start to fail")
+			}
+		})
+	}
+}
+
+func TestSandboxedPrecompileErrors(t *testing.T) {
+	for _, runtime := range sandboxRuntimes {
+		t.Run(runtime.name, func(t *testing.T) {
+			if _, err := runtime.new([]byte("not wasm"), SandboxConfig{}); err == nil {
+				t.Fatal("expected error for invalid code")
+			}
+			if _, err := runtime.new(newSandboxTestModule(32), SandboxConfig{MaxMemoryPages: 16}); !errors.Is(err, ErrMemoryLimit) {
+				t.Fatalf("expected memory limit error, got: %v", err)
+			}
+			if _, err := runtime.new(newLoopModule(false), SandboxConfig{}); err == nil {
+				t.Fatal("expected error for missing exports")
+			}
+			if !runtime.timeouts {
+				_, err := runtime.new(newSandboxTestModule(1), SandboxConfig{Timeout: time.Second})
+				if err != ErrTimeoutUnsupported {
+					t.Fatalf("expected unsupported timeout error, got: %v", err)
+				}
+			}
+
+			// Trusted precompiles cannot run in untrusted environments
+			pc := runtime.newTrusted(newSandboxTestModule(1))
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			pc.Run(newUntrustedEnvironment(0), []byte{sandboxTestOk})
+		})
+	}
+}
+
+func TestLimitMemory(t *testing.T) {
+	code, err := limitMemory(newSandboxTestModule(2), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := parseModule(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sections {
+		if s.id == memorySectionID {
+			// One memory of 2 to 16 pages
+			if want := []byte{0x01, 0x01, 0x02, 0x10}; string(s.content) != string(want) {
+				t.Fatalf("unexpected memory section: %x", s.content)
+			}
+			return
+		}
+	}
+	t.Fatal("memory section not found")
+}
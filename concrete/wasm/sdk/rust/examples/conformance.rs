@@ -0,0 +1,91 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//! Guest of the host ABI conformance suite, implementing the protocol of
+//! concrete/wasm/testdata/abi/guest.wat with the SDK.
+
+#![no_std]
+#![no_main]
+
+extern crate alloc;
+
+use alloc::vec::Vec;
+use concrete_guest::{call_environment, export_precompile, Error, Precompile};
+
+const ECHO: u8 = 0x00;
+const FAIL: u8 = 0x01;
+const ENVIRONMENT: u8 = 0x02;
+
+struct Conformance;
+
+impl Precompile for Conformance {
+    fn is_static(&self, input: &[u8]) -> bool {
+        input.first() == Some(&ECHO)
+    }
+
+    fn run(&self, input: &[u8]) -> Result<Vec<u8>, Error> {
+        let (command, data) = match input.split_first() {
+            Some((command, data)) => (*command, data),
+            None => return Ok(Vec::new()),
+        };
+        match command {
+            ECHO => Ok(data.to_vec()),
+            FAIL => Err(Error(data.to_vec())),
+            ENVIRONMENT => {
+                let values = decode_values(data);
+                let (opcode, args) = values
+                    .split_first()
+                    .ok_or_else(|| Error::new("missing opcode"))?;
+                let args: Vec<&[u8]> = args.iter().map(|arg| arg.as_slice()).collect();
+                Ok(encode_values(&call_environment(opcode[0], &args)))
+            }
+            // 0x03 traps, as unknown commands
+            _ => trap(),
+        }
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+fn trap() -> ! {
+    core::arch::wasm32::unreachable()
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn trap() -> ! {
+    panic!("trap")
+}
+
+/// Decodes (big endian u32 size, bytes) pairs.
+fn decode_values(mut data: &[u8]) -> Vec<Vec<u8>> {
+    let mut values = Vec::new();
+    while data.len() >= 4 {
+        let size = u32::from_be_bytes([data[0], data[1], data[2], data[3]]) as usize;
+        let end = core::cmp::min(4 + size, data.len());
+        values.push(data[4..end].to_vec());
+        data = &data[end..];
+    }
+    values
+}
+
+fn encode_values(values: &[Vec<u8>]) -> Vec<u8> {
+    let mut data = Vec::new();
+    for value in values {
+        data.extend_from_slice(&(value.len() as u32).to_be_bytes());
+        data.extend_from_slice(value);
+    }
+    data
+}
+
+export_precompile!(Conformance);
@@ -0,0 +1,346 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+//! Minimal guest SDK for concrete wasm precompiles, implementing the host ABI
+//! specified in concrete/wasm/ABI.md.
+//!
+//! Precompiles implement [`Precompile`] and export it with
+//! [`export_precompile!`]. The SDK allocates from an arena released at the
+//! end of every call, so precompiles must not keep heap allocations across
+//! calls.
+
+#![no_std]
+
+extern crate alloc;
+
+use alloc::vec::Vec;
+
+/// Version of the host ABI implemented by the SDK.
+pub const ABI_VERSION: u64 = 1;
+
+/// Pointer to a value in guest memory, packing offset << 32 | size.
+#[derive(Clone, Copy, Debug, PartialEq, Eq)]
+pub struct MemPointer(pub u64);
+
+impl MemPointer {
+    pub const NULL: MemPointer = MemPointer(0);
+
+    pub fn new(offset: u32, size: u32) -> Self {
+        MemPointer((offset as u64) << 32 | size as u64)
+    }
+
+    pub fn offset(self) -> u32 {
+        (self.0 >> 32) as u32
+    }
+
+    pub fn size(self) -> u32 {
+        self.0 as u32
+    }
+
+    pub fn is_null(self) -> bool {
+        self == Self::NULL
+    }
+}
+
+/// Error returned by precompiles, encoded as its message.
+#[derive(Clone, Debug, PartialEq, Eq)]
+pub struct Error(pub Vec<u8>);
+
+impl Error {
+    pub fn new(msg: &str) -> Self {
+        Error(msg.as_bytes().to_vec())
+    }
+}
+
+pub trait Precompile: Sync {
+    fn is_static(&self, input: &[u8]) -> bool;
+
+    fn finalise(&self) -> Result<(), Error> {
+        Ok(())
+    }
+
+    fn commit(&self) -> Result<(), Error> {
+        Ok(())
+    }
+
+    fn run(&self, input: &[u8]) -> Result<Vec<u8>, Error>;
+}
+
+#[cfg(target_arch = "wasm32")]
+mod arena {
+    use core::alloc::{GlobalAlloc, Layout};
+    use core::arch::wasm32;
+    use core::ptr;
+
+    const PAGE_SIZE: usize = 65536;
+
+    extern "C" {
+        static __heap_base: u8;
+    }
+
+    static mut NEXT: usize = 0;
+
+    /// Bump allocator growing the memory as needed, released by reset.
+    pub struct Arena;
+
+    unsafe impl GlobalAlloc for Arena {
+        unsafe fn alloc(&self, layout: Layout) -> *mut u8 {
+            if NEXT == 0 {
+                NEXT = ptr::addr_of!(__heap_base) as usize;
+            }
+            let start = (NEXT + layout.align() - 1) & !(layout.align() - 1);
+            let end = match start.checked_add(layout.size()) {
+                Some(end) => end,
+                None => return ptr::null_mut(),
+            };
+            let available = wasm32::memory_size(0) * PAGE_SIZE;
+            if end > available {
+                let pages = (end - available + PAGE_SIZE - 1) / PAGE_SIZE;
+                if wasm32::memory_grow(0, pages) == usize::MAX {
+                    return ptr::null_mut();
+                }
+            }
+            NEXT = end;
+            start as *mut u8
+        }
+
+        unsafe fn dealloc(&self, _ptr: *mut u8, _layout: Layout) {}
+    }
+
+    pub unsafe fn reset() {
+        NEXT = 0;
+    }
+
+    #[global_allocator]
+    static ALLOCATOR: Arena = Arena;
+}
+
+#[cfg(target_arch = "wasm32")]
+mod imports {
+    #[link(wasm_import_module = "env")]
+    #[allow(non_snake_case)]
+    extern "C" {
+        pub fn concrete_Environment(pointer: u64) -> u64;
+    }
+
+    #[link(wasm_import_module = "wasi_snapshot_preview1")]
+    extern "C" {
+        pub fn proc_exit(code: i32) -> !;
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+#[panic_handler]
+fn panic(_info: &core::panic::PanicInfo) -> ! {
+    unsafe { imports::proc_exit(1) }
+}
+
+#[doc(hidden)]
+pub fn malloc(size: u64) -> MemPointer {
+    if size == 0 {
+        return MemPointer::NULL;
+    }
+    let mut buf = Vec::<u8>::with_capacity(size as usize);
+    let offset = buf.as_mut_ptr() as usize as u32;
+    core::mem::forget(buf);
+    MemPointer::new(offset, size as u32)
+}
+
+#[doc(hidden)]
+pub fn prune() {
+    #[cfg(target_arch = "wasm32")]
+    unsafe {
+        arena::reset()
+    }
+}
+
+/// Copies a value to guest memory. Empty values are null pointers.
+pub fn put_value(value: &[u8]) -> MemPointer {
+    let pointer = malloc(value.len() as u64);
+    if !pointer.is_null() {
+        unsafe {
+            core::ptr::copy_nonoverlapping(
+                value.as_ptr(),
+                pointer.offset() as usize as *mut u8,
+                value.len(),
+            );
+        }
+    }
+    pointer
+}
+
+/// Copies a value from guest memory.
+pub fn get_value(pointer: MemPointer) -> Vec<u8> {
+    if pointer.is_null() {
+        return Vec::new();
+    }
+    let data = unsafe {
+        core::slice::from_raw_parts(
+            pointer.offset() as usize as *const u8,
+            pointer.size() as usize,
+        )
+    };
+    data.to_vec()
+}
+
+/// Puts a list of values, as a value packing their big endian pointers.
+pub fn put_values(values: &[&[u8]]) -> MemPointer {
+    if values.is_empty() {
+        return MemPointer::NULL;
+    }
+    let mut pointers = Vec::with_capacity(values.len() * 8);
+    for value in values {
+        pointers.extend_from_slice(&put_value(value).0.to_be_bytes());
+    }
+    put_value(&pointers)
+}
+
+pub fn get_values(pointer: MemPointer) -> Vec<Vec<u8>> {
+    get_value(pointer)
+        .chunks_exact(8)
+        .map(|chunk| {
+            let mut data = [0u8; 8];
+            data.copy_from_slice(chunk);
+            get_value(MemPointer(u64::from_be_bytes(data)))
+        })
+        .collect()
+}
+
+/// Encodes an error: 0x00 for no error, otherwise 0x01 followed by the
+/// message.
+pub fn encode_error(result: &Result<(), Error>) -> Vec<u8> {
+    match result {
+        Ok(()) => alloc::vec![0x00],
+        Err(Error(msg)) => {
+            let mut data = Vec::with_capacity(msg.len() + 1);
+            data.push(0x01);
+            data.extend_from_slice(msg);
+            data
+        }
+    }
+}
+
+#[doc(hidden)]
+pub fn put_error(result: &Result<(), Error>) -> MemPointer {
+    put_value(&encode_error(result))
+}
+
+#[doc(hidden)]
+pub fn put_return_with_error(result: Result<Vec<u8>, Error>) -> MemPointer {
+    match result {
+        Ok(output) => put_values(&[&output, &encode_error(&Ok(()))]),
+        Err(err) => put_values(&[&[], &encode_error(&Err(err))]),
+    }
+}
+
+/// Calls the environment with an opcode of concrete/api, returning its
+/// return values. Failed calls return no values, the host reverts the call
+/// once the precompile returns.
+pub fn call_environment(opcode: u8, args: &[&[u8]]) -> Vec<Vec<u8>> {
+    let mut values: Vec<&[u8]> = Vec::with_capacity(args.len() + 1);
+    let opcode = [opcode];
+    values.push(&opcode);
+    values.extend_from_slice(args);
+    get_values(environment(put_values(&values)))
+}
+
+#[cfg(target_arch = "wasm32")]
+fn environment(pointer: MemPointer) -> MemPointer {
+    MemPointer(unsafe { imports::concrete_Environment(pointer.0) })
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn environment(_pointer: MemPointer) -> MemPointer {
+    unimplemented!("the environment is only available in wasm")
+}
+
+/// Exports a precompile, given as an expression of a type implementing
+/// [`Precompile`].
+#[macro_export]
+macro_rules! export_precompile {
+    ($precompile:expr) => {
+        static PRECOMPILE: &dyn $crate::Precompile = &$precompile;
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_AbiVersion() -> u64 {
+            $crate::ABI_VERSION
+        }
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_Malloc(size: u64) -> u64 {
+            $crate::malloc(size).0
+        }
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_Free(_pointer: u64) {}
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_Prune() {
+            $crate::prune()
+        }
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_IsStatic(pointer: u64) -> u64 {
+            let input = $crate::get_value($crate::MemPointer(pointer));
+            PRECOMPILE.is_static(&input) as u64
+        }
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_Finalise() -> u64 {
+            $crate::put_error(&PRECOMPILE.finalise()).0
+        }
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_Commit() -> u64 {
+            $crate::put_error(&PRECOMPILE.commit()).0
+        }
+
+        #[no_mangle]
+        #[allow(non_snake_case)]
+        pub extern "C" fn concrete_Run(pointer: u64) -> u64 {
+            let input = $crate::get_value($crate::MemPointer(pointer));
+            $crate::put_return_with_error(PRECOMPILE.run(&input)).0
+        }
+    };
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_mem_pointer() {
+        let pointer = MemPointer::new(0x1234, 0x56);
+        assert_eq!(pointer.0, 0x0000_1234_0000_0056);
+        assert_eq!((pointer.offset(), pointer.size()), (0x1234, 0x56));
+        assert!(MemPointer::new(0, 0).is_null());
+    }
+
+    #[test]
+    fn test_encode_error() {
+        assert_eq!(encode_error(&Ok(())), [0x00]);
+        assert_eq!(
+            encode_error(&Err(Error::new("err"))),
+            [0x01, b'e', b'r', b'r']
+        );
+    }
+}
@@ -0,0 +1,17 @@
+;; Guest not exporting the host ABI version, rejected by hosts.
+(module
+  (memory (export "memory") 1)
+
+  (func (export "concrete_Malloc") (param $size i64) (result i64)
+    (i64.const 0))
+  (func (export "concrete_Free") (param $pointer i64))
+  (func (export "concrete_Prune"))
+  (func (export "concrete_IsStatic") (param $input i64) (result i64)
+    (i64.const 0))
+  (func (export "concrete_Finalise") (result i64)
+    (i64.const 0))
+  (func (export "concrete_Commit") (result i64)
+    (i64.const 0))
+  (func (export "concrete_Run") (param $input i64) (result i64)
+    (i64.const 0))
+)
@@ -0,0 +1,60 @@
+;; Guest violating the host ABI, to check that hosts fail gracefully. Run
+;; dispatches on the first input byte:
+;;   0x00: return a pointer out of memory
+;;   0x01: return a list of values out of memory
+;;   0x02: call the environment with a null pointer
+;;   0x03: call the environment with a pointer out of memory
+;; IsStatic traps, Finalise fails and Commit returns a pointer out of memory.
+(module
+  (import "env" "concrete_Environment" (func $environment (param i64) (result i64)))
+
+  (memory (export "memory") 1)
+  (global $heap (mut i32) (i32.const 1024))
+  ;; Encoded error returned by Finalise, and a list of values out of memory
+  (data (i32.const 16) "\01finalise failed")
+  (data (i32.const 32) "\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff\ff")
+
+  (func (export "concrete_AbiVersion") (result i64)
+    (i64.const 1))
+
+  ;; Bump allocator in the first page, released by concrete_Prune
+  (func (export "concrete_Malloc") (param $size i64) (result i64)
+    (local $offset i32)
+    (local.set $offset (global.get $heap))
+    (global.set $heap (i32.add (global.get $heap) (i32.wrap_i64 (local.get $size))))
+    (if (i32.gt_u (global.get $heap) (i32.const 65536))
+      (then unreachable))
+    (i64.or
+      (i64.shl (i64.extend_i32_u (local.get $offset)) (i64.const 32))
+      (local.get $size)))
+
+  (func (export "concrete_Free") (param $pointer i64))
+
+  (func (export "concrete_Prune")
+    (global.set $heap (i32.const 1024)))
+
+  (func (export "concrete_IsStatic") (param $input i64) (result i64)
+    unreachable)
+
+  (func (export "concrete_Finalise") (result i64)
+    (i64.const 0x0000_0010_0000_0010))
+
+  (func (export "concrete_Commit") (result i64)
+    (i64.const -1))
+
+  (func (export "concrete_Run") (param $input i64) (result i64)
+    (local $command i32)
+    (if (i64.eqz (local.get $input))
+      (then unreachable))
+    (local.set $command
+      (i32.load8_u (i32.wrap_i64 (i64.shr_u (local.get $input) (i64.const 32)))))
+    (if (i32.eq (local.get $command) (i32.const 0))
+      (then (return (i64.const -1))))
+    (if (i32.eq (local.get $command) (i32.const 1))
+      (then (return (i64.const 0x0000_0020_0000_0010))))
+    (if (i32.eq (local.get $command) (i32.const 2))
+      (then (return (call $environment (i64.const 0)))))
+    (if (i32.eq (local.get $command) (i32.const 3))
+      (then (return (call $environment (i64.const -1)))))
+    unreachable)
+)
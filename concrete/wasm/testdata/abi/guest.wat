@@ -0,0 +1,244 @@
+;; Reference guest of the host ABI conformance suite, see ABI.md. Run
+;; dispatches on the first input byte:
+;;   0x00: return the rest of the input
+;;   0x01: fail with the rest of the input as error message
+;;   0x02: call the environment with the values encoded in the rest of the
+;;         input as (big endian u32 size, bytes) pairs, starting with the
+;;         opcode, and return its return values encoded the same way
+;;   0x03: trap
+;; IsStatic is true for 0x00 only. Finalise and Commit succeed.
+(module
+  (import "env" "concrete_Environment" (func $environment (param i64) (result i64)))
+
+  (memory (export "memory") 1)
+  (global $heap (mut i32) (i32.const 1024))
+
+  (func (export "concrete_AbiVersion") (result i64)
+    (i64.const 1))
+
+  ;; Bump allocator growing the memory as needed, released by concrete_Prune
+  (func $malloc (param $size i32) (result i32)
+    (local $offset i32)
+    (block $fits
+      (loop $grow
+        (br_if $fits
+          (i32.le_u
+            (i32.add (global.get $heap) (local.get $size))
+            (i32.shl (memory.size) (i32.const 16))))
+        (if (i32.eq (memory.grow (i32.const 1)) (i32.const -1))
+          (then unreachable))
+        (br $grow)))
+    (local.set $offset (global.get $heap))
+    (global.set $heap (i32.add (global.get $heap) (local.get $size)))
+    (local.get $offset))
+
+  (func (export "concrete_Malloc") (param $size i64) (result i64)
+    (if (i64.eqz (local.get $size))
+      (then (return (i64.const 0))))
+    (call $pack
+      (call $malloc (i32.wrap_i64 (local.get $size)))
+      (i32.wrap_i64 (local.get $size))))
+
+  (func (export "concrete_Free") (param $pointer i64))
+
+  (func (export "concrete_Prune")
+    (global.set $heap (i32.const 1024)))
+
+  ;; Pointers pack offset << 32 | size
+  (func $pack (param $offset i32) (param $size i32) (result i64)
+    (i64.or
+      (i64.shl (i64.extend_i32_u (local.get $offset)) (i64.const 32))
+      (i64.extend_i32_u (local.get $size))))
+
+  (func $pointer_offset (param $pointer i64) (result i32)
+    (i32.wrap_i64 (i64.shr_u (local.get $pointer) (i64.const 32))))
+
+  (func $pointer_size (param $pointer i64) (result i32)
+    (i32.wrap_i64 (local.get $pointer)))
+
+  ;; Lists of values are encoded as big endian pointers
+  (func $bswap64 (param $value i64) (result i64)
+    (local $result i64)
+    (local $i i32)
+    (loop $next
+      (local.set $result
+        (i64.or
+          (i64.shl (local.get $result) (i64.const 8))
+          (i64.and (local.get $value) (i64.const 0xff))))
+      (local.set $value (i64.shr_u (local.get $value) (i64.const 8)))
+      (local.set $i (i32.add (local.get $i) (i32.const 1)))
+      (br_if $next (i32.lt_u (local.get $i) (i32.const 8))))
+    (local.get $result))
+
+  (func $store64be (param $offset i32) (param $value i64)
+    (i64.store (local.get $offset) (call $bswap64 (local.get $value))))
+
+  (func $load64be (param $offset i32) (result i64)
+    (call $bswap64 (i64.load (local.get $offset))))
+
+  (func $store32be (param $offset i32) (param $value i32)
+    (i32.store (local.get $offset)
+      (i32.wrap_i64
+        (i64.shr_u
+          (call $bswap64 (i64.extend_i32_u (local.get $value)))
+          (i64.const 32)))))
+
+  (func $load32be (param $offset i32) (result i32)
+    (i32.wrap_i64
+      (i64.shr_u
+        (call $bswap64 (i64.extend_i32_u (i32.load (local.get $offset))))
+        (i64.const 32))))
+
+  ;; put copies a value to a new allocation. Empty values are null pointers.
+  (func $put (param $offset i32) (param $size i32) (result i64)
+    (local $dst i32)
+    (if (i32.eqz (local.get $size))
+      (then (return (i64.const 0))))
+    (local.set $dst (call $malloc (local.get $size)))
+    (memory.copy (local.get $dst) (local.get $offset) (local.get $size))
+    (call $pack (local.get $dst) (local.get $size)))
+
+  ;; put_error puts an encoded error: 0x00 if it did not fail, otherwise
+  ;; 0x01 followed by the message.
+  (func $put_error (param $failed i32) (param $offset i32) (param $size i32) (result i64)
+    (local $dst i32)
+    (local.set $dst (call $malloc (i32.add (local.get $size) (i32.const 1))))
+    (i32.store8 (local.get $dst) (local.get $failed))
+    (memory.copy
+      (i32.add (local.get $dst) (i32.const 1))
+      (local.get $offset)
+      (local.get $size))
+    (call $pack (local.get $dst) (i32.add (local.get $size) (i32.const 1))))
+
+  ;; return_with_error puts the list of an output and an error
+  (func $return_with_error (param $output i64) (param $err i64) (result i64)
+    (local $list i32)
+    (local.set $list (call $malloc (i32.const 16)))
+    (call $store64be (local.get $list) (local.get $output))
+    (call $store64be (i32.add (local.get $list) (i32.const 8)) (local.get $err))
+    (call $pack (local.get $list) (i32.const 16)))
+
+  (func $call_environment (param $data i32) (param $size i32) (result i64)
+    (local $end i32)
+    (local $args i32)
+    (local $count i32)
+    (local $value_size i32)
+    (local $ret i64)
+    (local $ret_list i32)
+    (local $ret_count i32)
+    (local $value i64)
+    (local $i i32)
+    (local $output i32)
+    (local $output_size i32)
+    (local $dst i32)
+
+    ;; Put the arguments, at most one per 4 bytes of data
+    (local.set $end (i32.add (local.get $data) (local.get $size)))
+    (local.set $args
+      (call $malloc
+        (i32.shl
+          (i32.add (i32.shr_u (local.get $size) (i32.const 2)) (i32.const 1))
+          (i32.const 3))))
+    (block $args_done
+      (loop $args_next
+        (br_if $args_done (i32.ge_u (local.get $data) (local.get $end)))
+        (local.set $value_size (call $load32be (local.get $data)))
+        (local.set $data (i32.add (local.get $data) (i32.const 4)))
+        (call $store64be
+          (i32.add (local.get $args) (i32.shl (local.get $count) (i32.const 3)))
+          (call $put (local.get $data) (local.get $value_size)))
+        (local.set $count (i32.add (local.get $count) (i32.const 1)))
+        (local.set $data (i32.add (local.get $data) (local.get $value_size)))
+        (br $args_next)))
+
+    (local.set $ret
+      (call $environment
+        (call $pack (local.get $args) (i32.shl (local.get $count) (i32.const 3)))))
+    (local.set $ret_list (call $pointer_offset (local.get $ret)))
+    (local.set $ret_count (i32.shr_u (call $pointer_size (local.get $ret)) (i32.const 3)))
+
+    ;; Size of the encoded return values
+    (block $size_done
+      (loop $size_next
+        (br_if $size_done (i32.ge_u (local.get $i) (local.get $ret_count)))
+        (local.set $value
+          (call $load64be (i32.add (local.get $ret_list) (i32.shl (local.get $i) (i32.const 3)))))
+        (local.set $output_size
+          (i32.add
+            (local.get $output_size)
+            (i32.add (call $pointer_size (local.get $value)) (i32.const 4))))
+        (local.set $i (i32.add (local.get $i) (i32.const 1)))
+        (br $size_next)))
+    (if (i32.eqz (local.get $output_size))
+      (then (return (i64.const 0))))
+
+    (local.set $output (call $malloc (local.get $output_size)))
+    (local.set $dst (local.get $output))
+    (local.set $i (i32.const 0))
+    (block $copy_done
+      (loop $copy_next
+        (br_if $copy_done (i32.ge_u (local.get $i) (local.get $ret_count)))
+        (local.set $value
+          (call $load64be (i32.add (local.get $ret_list) (i32.shl (local.get $i) (i32.const 3)))))
+        (call $store32be (local.get $dst) (call $pointer_size (local.get $value)))
+        (memory.copy
+          (i32.add (local.get $dst) (i32.const 4))
+          (call $pointer_offset (local.get $value))
+          (call $pointer_size (local.get $value)))
+        (local.set $dst
+          (i32.add
+            (local.get $dst)
+            (i32.add (call $pointer_size (local.get $value)) (i32.const 4))))
+        (local.set $i (i32.add (local.get $i) (i32.const 1)))
+        (br $copy_next)))
+    (call $pack (local.get $output) (local.get $output_size)))
+
+  (func (export "concrete_IsStatic") (param $input i64) (result i64)
+    (if (i32.eqz (call $pointer_size (local.get $input)))
+      (then (return (i64.const 0))))
+    (i64.extend_i32_u
+      (i32.eqz (i32.load8_u (call $pointer_offset (local.get $input))))))
+
+  (func (export "concrete_Finalise") (result i64)
+    (call $put_error (i32.const 0) (i32.const 0) (i32.const 0)))
+
+  ;; A null error pointer is a nil error
+  (func (export "concrete_Commit") (result i64)
+    (i64.const 0))
+
+  (func (export "concrete_Run") (param $input i64) (result i64)
+    (local $offset i32)
+    (local $size i32)
+    (local $command i32)
+    (local.set $offset (call $pointer_offset (local.get $input)))
+    (local.set $size (call $pointer_size (local.get $input)))
+    (if (i32.eqz (local.get $size))
+      (then
+        (return
+          (call $return_with_error
+            (i64.const 0)
+            (call $put_error (i32.const 0) (i32.const 0) (i32.const 0))))))
+    (local.set $command (i32.load8_u (local.get $offset)))
+    (local.set $offset (i32.add (local.get $offset) (i32.const 1)))
+    (local.set $size (i32.sub (local.get $size) (i32.const 1)))
+
+    (if (i32.eq (local.get $command) (i32.const 0))
+      (then
+        (return
+          (call $return_with_error
+            (call $put (local.get $offset) (local.get $size))
+            (call $put_error (i32.const 0) (i32.const 0) (i32.const 0))))))
+    (if (i32.eq (local.get $command) (i32.const 1))
+      (then
+        (return
+          (call $return_with_error
+            (i64.const 0)
+            (call $put_error (i32.const 1) (local.get $offset) (local.get $size))))))
+    (if (i32.eq (local.get $command) (i32.const 2))
+      (then
+        (return
+          (call $return_with_error
+            (call $call_environment (local.get $offset) (local.get $size))
+            (call $put_error (i32.const 0) (i32.const 0) (i32.const 0))))))
+    unreachable)
+)
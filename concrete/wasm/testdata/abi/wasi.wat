@@ -0,0 +1,52 @@
+;; Guest calling the deterministic WASI profile. Run with a null input stores
+;; the results of a sequence of WASI calls and returns them, see wasi_test.go.
+;; Run with any other input exits with code 3 and returns.
+(module
+  (import "wasi_snapshot_preview1" "clock_time_get" (func $clock_time_get (param i32 i64 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "clock_res_get" (func $clock_res_get (param i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "args_sizes_get" (func $args_sizes_get (param i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "environ_sizes_get" (func $environ_sizes_get (param i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "random_get" (func $random_get (param i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_write" (func $fd_write (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "poll_oneoff" (func $poll_oneoff (param i32 i32 i32 i32) (result i32)))
+  (import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+
+  (memory (export "memory") 1)
+  ;; iovec {1032, 5} and its data
+  (data (i32.const 1024) "\08\04\00\00\05\00\00\00hello")
+  ;; overwritten with zeros by random_get
+  (data (i32.const 312) "\ff\ff\ff\ff\ff\ff\ff\ff")
+
+  (func (export "concrete_AbiVersion") (result i64)
+    (i64.const 1))
+  (func (export "concrete_Malloc") (param $size i64) (result i64)
+    (i64.or (i64.const 0x80000000000) (local.get $size)))
+  (func (export "concrete_Free") (param $pointer i64))
+  (func (export "concrete_Prune"))
+  (func (export "concrete_IsStatic") (param $input i64) (result i64)
+    (i64.const 1))
+  (func (export "concrete_Finalise") (result i64)
+    (i64.const 0))
+  (func (export "concrete_Commit") (result i64)
+    (i64.const 0))
+
+  (func (export "concrete_Run") (param $input i64) (result i64)
+    (if (i64.ne (local.get $input) (i64.const 0))
+      (then
+        (call $proc_exit (i32.const 3))
+        (return (i64.const 0))))
+    (i32.store (i32.const 256) (call $clock_time_get (i32.const 0) (i64.const 1) (i32.const 260)))
+    (i32.store (i32.const 268) (call $clock_res_get (i32.const 1) (i32.const 272)))
+    (i32.store (i32.const 280) (call $clock_time_get (i32.const 4) (i64.const 1) (i32.const 260)))
+    (i32.store (i32.const 284) (call $args_sizes_get (i32.const 288) (i32.const 292)))
+    (i32.store (i32.const 296) (call $environ_sizes_get (i32.const 300) (i32.const 304)))
+    (i32.store (i32.const 308) (call $random_get (i32.const 312) (i32.const 8)))
+    (i32.store (i32.const 320) (call $fd_write (i32.const 1) (i32.const 1024) (i32.const 1) (i32.const 324)))
+    (i32.store (i32.const 328) (call $fd_write (i32.const 3) (i32.const 1024) (i32.const 1) (i32.const 332)))
+    (i32.store (i32.const 336) (call $clock_time_get (i32.const 0) (i64.const 1) (i32.const 0xfffffff0)))
+    (i32.store (i32.const 340) (call $poll_oneoff (i32.const 0) (i32.const 0) (i32.const 0) (i32.const 344)))
+    ;; return the list of the 88 bytes at 256 and no error, stored big endian
+    (i64.store (i32.const 512) (i64.const 0x5800000000010000))
+    (i64.store (i32.const 520) (i64.const 0))
+    (i64.const 0x020000000010))
+)
@@ -0,0 +1,289 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Precompiles run in a deterministic WASI profile, as guests must not observe
+// anything that differs between nodes: arguments and environment variables
+// are empty, clocks are fixed at zero, random_get returns zeros, there is no
+// filesystem, stdin is empty and writes to stdout and stderr are discarded.
+// Modules importing anything else than the profile and the environment are
+// rejected when loaded.
+//
+// random_get succeeds as the tinygo runtime seeds itself from it when
+// initialized, through the arc4random of wasi-libc, which aborts if it fails.
+// The file functions imported by tinygo modules linking the os package are
+// provided for the same reason.
+
+var (
+	ErrForbiddenImport = errors.New("wasm module import not allowed")
+	ErrExit            = errors.New("wasm module exited")
+)
+
+const wasiModuleName = "wasi_snapshot_preview1"
+
+const (
+	wasiErrnoSuccess = 0
+	wasiErrnoBadf    = 8
+	wasiErrnoFault   = 21
+	wasiErrnoInval   = 28
+	wasiErrnoNosys   = 52
+	wasiErrnoSpipe   = 70
+)
+
+const (
+	wasiClockCount              = 4
+	wasiFiletypeCharacterDevice = 2
+	wasiRightFdRead             = 1 << 1
+	wasiRightFdWrite            = 1 << 6
+)
+
+// wasiMemory is the memory of the module calling a WASI function.
+type wasiMemory interface {
+	Read(offset, byteCount uint32) ([]byte, bool)
+	Write(offset uint32, data []byte) bool
+}
+
+// wasiFunction is a function of the deterministic WASI profile. Arguments are
+// passed as uint64, and functions return an errno, or an error to abort the
+// call into the module.
+type wasiFunction struct {
+	typ  funcType
+	call func(mem wasiMemory, args []uint64) (uint32, error)
+}
+
+func wasiType(params ...byte) funcType {
+	return funcType{params: params, results: []byte{i32ValueType}}
+}
+
+var wasiFunctions = map[string]wasiFunction{
+	"args_get":            {wasiType(i32ValueType, i32ValueType), wasiNoop},
+	"args_sizes_get":      {wasiType(i32ValueType, i32ValueType), wasiEmptySizes},
+	"environ_get":         {wasiType(i32ValueType, i32ValueType), wasiNoop},
+	"environ_sizes_get":   {wasiType(i32ValueType, i32ValueType), wasiEmptySizes},
+	"clock_res_get":       {wasiType(i32ValueType, i32ValueType), wasiClockResGet},
+	"clock_time_get":      {wasiType(i32ValueType, i64ValueType, i32ValueType), wasiClockTimeGet},
+	"random_get":          {wasiType(i32ValueType, i32ValueType), wasiRandomGet},
+	"fd_read":             {wasiType(i32ValueType, i32ValueType, i32ValueType, i32ValueType), wasiFdRead},
+	"fd_write":            {wasiType(i32ValueType, i32ValueType, i32ValueType, i32ValueType), wasiFdWrite},
+	"fd_close":            {wasiType(i32ValueType), wasiFdClose},
+	"fd_seek":             {wasiType(i32ValueType, i64ValueType, i32ValueType, i32ValueType), wasiFdSeek},
+	"fd_fdstat_get":       {wasiType(i32ValueType, i32ValueType), wasiFdFdstatGet},
+	"fd_filestat_get":     {wasiType(i32ValueType, i32ValueType), wasiFdFilestatGet},
+	"fd_prestat_get":      {wasiType(i32ValueType, i32ValueType), wasiErrno(wasiErrnoBadf)},
+	"fd_prestat_dir_name": {wasiType(i32ValueType, i32ValueType, i32ValueType), wasiErrno(wasiErrnoBadf)},
+	"path_open":           {wasiType(i32ValueType, i32ValueType, i32ValueType, i32ValueType, i32ValueType, i64ValueType, i64ValueType, i32ValueType, i32ValueType), wasiErrno(wasiErrnoBadf)},
+	"poll_oneoff":         {wasiType(i32ValueType, i32ValueType, i32ValueType, i32ValueType), wasiErrno(wasiErrnoNosys)},
+	"sched_yield":         {wasiType(), wasiNoop},
+	"proc_exit":           {funcType{params: []byte{i32ValueType}}, wasiProcExit},
+}
+
+func wasiNoop(mem wasiMemory, args []uint64) (uint32, error) {
+	return wasiErrnoSuccess, nil
+}
+
+func wasiErrno(errno uint32) func(wasiMemory, []uint64) (uint32, error) {
+	return func(wasiMemory, []uint64) (uint32, error) {
+		return errno, nil
+	}
+}
+
+func wasiWriteUint32(mem wasiMemory, offset uint64, value uint32) bool {
+	return mem.Write(uint32(offset), binary.LittleEndian.AppendUint32(nil, value))
+}
+
+func wasiWriteUint64(mem wasiMemory, offset uint64, value uint64) bool {
+	return mem.Write(uint32(offset), binary.LittleEndian.AppendUint64(nil, value))
+}
+
+func isStdio(fd uint64) bool {
+	return fd <= 2
+}
+
+func isStdout(fd uint64) bool {
+	return fd == 1 || fd == 2
+}
+
+// wasiEmptySizes returns the sizes of the empty arguments and environment.
+func wasiEmptySizes(mem wasiMemory, args []uint64) (uint32, error) {
+	if !wasiWriteUint32(mem, args[0], 0) || !wasiWriteUint32(mem, args[1], 0) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiClockResGet(mem wasiMemory, args []uint64) (uint32, error) {
+	if args[0] >= wasiClockCount {
+		return wasiErrnoInval, nil
+	}
+	if !wasiWriteUint64(mem, args[1], 1) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiClockTimeGet(mem wasiMemory, args []uint64) (uint32, error) {
+	if args[0] >= wasiClockCount {
+		return wasiErrnoInval, nil
+	}
+	if !wasiWriteUint64(mem, args[2], 0) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+// wasiRandomGet fills the buffer with zeros.
+func wasiRandomGet(mem wasiMemory, args []uint64) (uint32, error) {
+	buf, size := uint32(args[0]), uint32(args[1])
+	if _, ok := mem.Read(buf, size); !ok {
+		return wasiErrnoFault, nil
+	}
+	if !mem.Write(buf, make([]byte, size)) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+// wasiFdRead reads nothing from the empty stdin.
+func wasiFdRead(mem wasiMemory, args []uint64) (uint32, error) {
+	if args[0] != 0 {
+		return wasiErrnoBadf, nil
+	}
+	if !wasiWriteUint32(mem, args[3], 0) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiFdWrite(mem wasiMemory, args []uint64) (uint32, error) {
+	fd, iovs, iovsLen, nwritten := args[0], uint32(args[1]), uint32(args[2]), args[3]
+	if !isStdout(fd) {
+		return wasiErrnoBadf, nil
+	}
+	var written uint32
+	for i := uint32(0); i < iovsLen; i++ {
+		iov, ok := mem.Read(iovs+8*i, 8)
+		if !ok {
+			return wasiErrnoFault, nil
+		}
+		size := binary.LittleEndian.Uint32(iov[4:])
+		if _, ok := mem.Read(binary.LittleEndian.Uint32(iov), size); !ok {
+			return wasiErrnoFault, nil
+		}
+		written += size
+	}
+	if !wasiWriteUint32(mem, nwritten, written) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiFdClose(mem wasiMemory, args []uint64) (uint32, error) {
+	if !isStdio(args[0]) {
+		return wasiErrnoBadf, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiFdSeek(mem wasiMemory, args []uint64) (uint32, error) {
+	if !isStdio(args[0]) {
+		return wasiErrnoBadf, nil
+	}
+	return wasiErrnoSpipe, nil
+}
+
+func wasiFdFdstatGet(mem wasiMemory, args []uint64) (uint32, error) {
+	if !isStdio(args[0]) {
+		return wasiErrnoBadf, nil
+	}
+	stat := make([]byte, 24)
+	stat[0] = wasiFiletypeCharacterDevice
+	if args[0] == 0 {
+		binary.LittleEndian.PutUint64(stat[8:], wasiRightFdRead)
+	} else {
+		binary.LittleEndian.PutUint64(stat[8:], wasiRightFdWrite)
+	}
+	if !mem.Write(uint32(args[1]), stat) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiFdFilestatGet(mem wasiMemory, args []uint64) (uint32, error) {
+	if !isStdio(args[0]) {
+		return wasiErrnoBadf, nil
+	}
+	stat := make([]byte, 64)
+	stat[16] = wasiFiletypeCharacterDevice
+	if !mem.Write(uint32(args[1]), stat) {
+		return wasiErrnoFault, nil
+	}
+	return wasiErrnoSuccess, nil
+}
+
+func wasiProcExit(mem wasiMemory, args []uint64) (uint32, error) {
+	return 0, fmt.Errorf("%w with code %d", ErrExit, uint32(args[0]))
+}
+
+// checkImports returns an error if the module imports anything else than the
+// environment and the functions of the deterministic WASI profile, with their
+// signatures.
+func checkImports(code []byte) error {
+	sections, err := parseModule(code)
+	if err != nil {
+		return err
+	}
+	var (
+		types   []funcType
+		imports []wasmImport
+	)
+	for _, section := range sections {
+		switch section.id {
+		case typeSectionID:
+			types, err = parseFuncTypes(section.content)
+		case importSectionID:
+			imports, err = parseImports(section.content)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	environmentType := funcType{params: []byte{i64ValueType}, results: []byte{i64ValueType}}
+	for _, imp := range imports {
+		var want funcType
+		switch {
+		case imp.module == "env" && imp.name == Environment_WasmFuncName:
+			want = environmentType
+		case imp.module == wasiModuleName:
+			function, ok := wasiFunctions[imp.name]
+			if !ok {
+				return fmt.Errorf("%w: %s.%s", ErrForbiddenImport, imp.module, imp.name)
+			}
+			want = function.typ
+		default:
+			return fmt.Errorf("%w: %s.%s", ErrForbiddenImport, imp.module, imp.name)
+		}
+		if imp.kind != functionExternalKind || imp.typeIndex >= uint32(len(types)) || !types[imp.typeIndex].equal(want) {
+			return fmt.Errorf("%w: %s.%s has an invalid type", ErrForbiddenImport, imp.module, imp.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 The concrete-geth Authors
+//
+// The concrete-geth library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The concrete library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the concrete library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	"github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/mock"
+	"github.com/wasmerio/wasmer-go/wasmer"
+)
+
+func TestWasiProfile(t *testing.T) {
+	le := binary.LittleEndian
+	want := make([]byte, 88)
+	le.PutUint64(want[16:], 1)              // clock_res_get resolution
+	le.PutUint32(want[24:], wasiErrnoInval) // clock_time_get with invalid clock
+	le.PutUint32(want[68:], 5)              // fd_write to stdout, written bytes
+	le.PutUint32(want[72:], wasiErrnoBadf)  // fd_write to an invalid fd
+	le.PutUint32(want[80:], wasiErrnoFault) // clock_time_get out of memory
+	le.PutUint32(want[84:], wasiErrnoNosys) // poll_oneoff
+
+	code := readAbiTestModule(t, "wasi.wat")
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc, err := rt.new(code, SandboxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			env := newUntrustedEnvironment(1_000_000)
+			for i := 0; i < 2; i++ {
+				output, err := pc.Run(env, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(output, want) {
+					t.Fatalf("unexpected output: %x", output)
+				}
+			}
+			_, err = pc.Run(env, []byte{0x01})
+			if !errors.Is(err, ErrTrap) || !strings.Contains(err.Error(), ErrExit.Error()) {
+				t.Fatalf("expected exit, got: %v", err)
+			}
+			if _, err := pc.Run(env, nil); err != nil {
+				t.Fatalf("unexpected error after exit: %v", err)
+			}
+
+			trusted := rt.newTrusted(code)
+			env = mock.NewMockEnvironment(common.Address{}, api.EnvConfig{Trusted: true}, true, 1_000_000)
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrExit) {
+					t.Fatalf("expected exit panic, got: %v", err)
+				}
+			}()
+			trusted.Run(env, []byte{0x01})
+		})
+	}
+}
+
+// TestWasiExitParity checks that proc_exit stops the guest on every runtime,
+// before it writes memory and calls UseGas(1000).
+func TestWasiExitParity(t *testing.T) {
+	code, err := wasmer.Wat2Wasm(`(module
+  (import "wasi_snapshot_preview1" "proc_exit" (func $proc_exit (param i32)))
+  (import "env" "concrete_Environment" (func $environment (param i64) (result i64)))
+  (memory (export "memory") 1)
+  ;; Arguments of UseGas(1000): the opcode, the gas and the list of both
+  (data (i32.const 64) "\50")
+  (data (i32.const 72) "\00\00\00\00\00\00\03\e8")
+  (data (i32.const 96) "\00\00\00\40\00\00\00\01\00\00\00\48\00\00\00\08")
+  (func (export "concrete_AbiVersion") (result i64) (i64.const 1))
+  (func (export "concrete_Malloc") (param i64) (result i64) (i64.const 0x0000040000000000))
+  (func (export "concrete_Free") (param i64))
+  (func (export "concrete_Prune"))
+  (func (export "concrete_IsStatic") (param i64) (result i64) (i64.const 0))
+  (func (export "concrete_Finalise") (result i64) (i64.const 0))
+  (func (export "concrete_Commit") (result i64) (i64.const 0))
+  (func (export "concrete_Run") (param i64) (result i64)
+    (call $proc_exit (i32.const 3))
+    (i32.store (i32.const 64) (i32.const 0x50))
+    (drop (call $environment (i64.const 0x0000006000000010)))
+    (i64.const 0)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []string
+	for _, rt := range sandboxRuntimes {
+		pc, err := rt.new(code, SandboxConfig{Unmetered: true})
+		if err != nil {
+			t.Fatalf("%s: %v", rt.name, err)
+		}
+		env := newUntrustedEnvironment(1_000_000)
+		_, err = pc.Run(env, nil)
+		if !errors.Is(err, ErrTrap) || !strings.Contains(err.Error(), ErrExit.Error()) {
+			t.Fatalf("%s: expected exit, got: %v", rt.name, err)
+		}
+		results = append(results, fmt.Sprintf("%d gas left", env.Gas()))
+	}
+	for i, result := range results {
+		if result != "1000000 gas left" {
+			t.Fatalf("%s: guest ran on after exiting, %s", sandboxRuntimes[i].name, result)
+		}
+	}
+}
+
+func TestForbiddenImports(t *testing.T) {
+	imports := []string{
+		`(import "wasi_snapshot_preview1" "path_unlink_file" (func (param i32 i32 i32) (result i32)))`,
+		`(import "wasi_snapshot_preview1" "sock_accept" (func (param i32 i32 i32) (result i32)))`,
+		`(import "wasi_snapshot_preview1" "fd_read" (func (param i32 i32 i32) (result i32)))`,
+		`(import "wasi_snapshot_preview1" "clock_time_get" (func (param i32 i32) (result i32)))`,
+		`(import "wasi_snapshot_preview1" "proc_exit" (func (param i32) (result i32)))`,
+		`(import "wasi_unstable" "fd_write" (func (param i32 i32 i32 i32) (result i32)))`,
+		`(import "env" "concrete_Environment" (func (param i32) (result i32)))`,
+		`(import "env" "abort" (func))`,
+		`(import "env" "table" (table 1 funcref))`,
+		`(import "env" "global" (global i64))`,
+	}
+	for _, imp := range imports {
+		code, err := wasmer.Wat2Wasm(fmt.Sprintf("(module %s)", imp))
+		if err != nil {
+			t.Fatalf("%s: %v", imp, err)
+		}
+		for _, rt := range sandboxRuntimes {
+			if _, err := rt.new(code, SandboxConfig{}); !errors.Is(err, ErrForbiddenImport) {
+				t.Fatalf("%s/%s: unexpected error: %v", imp, rt.name, err)
+			}
+		}
+	}
+
+	// Sandboxed runtimes already reject imported memories as they cannot be
+	// limited
+	code, err := wasmer.Wat2Wasm(`(module (import "env" "memory" (memory 1)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkImports(code); !errors.Is(err, ErrForbiddenImport) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type wasiTestMemory []byte
+
+func (m wasiTestMemory) Read(offset, byteCount uint32) ([]byte, bool) {
+	if uint64(offset)+uint64(byteCount) > uint64(len(m)) {
+		return nil, false
+	}
+	return m[offset : offset+byteCount], true
+}
+
+func (m wasiTestMemory) Write(offset uint32, data []byte) bool {
+	if uint64(offset)+uint64(len(data)) > uint64(len(m)) {
+		return false
+	}
+	copy(m[offset:], data)
+	return true
+}
+
+func TestWasiStdio(t *testing.T) {
+	call := func(mem wasiMemory, name string, args ...uint64) uint32 {
+		errno, err := wasiFunctions[name].call(mem, args)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return errno
+	}
+	mem := make(wasiTestMemory, 128)
+	for i := range mem {
+		mem[i] = 0xff
+	}
+
+	if errno := call(mem, "random_get", 0, 16); errno != wasiErrnoSuccess || !bytes.Equal(mem[:16], make([]byte, 16)) {
+		t.Fatalf("random_get: errno %d, buffer %x", errno, mem[:16])
+	}
+	if errno := call(mem, "random_get", 120, 16); errno != wasiErrnoFault {
+		t.Fatalf("random_get out of memory: errno %d", errno)
+	}
+	if errno := call(mem, "fd_read", 0, 0, 0, 16); errno != wasiErrnoSuccess || binary.LittleEndian.Uint32(mem[16:]) != 0 {
+		t.Fatalf("fd_read from stdin: errno %d", errno)
+	}
+	if errno := call(mem, "fd_read", 1, 0, 0, 16); errno != wasiErrnoBadf {
+		t.Fatalf("fd_read from stdout: errno %d", errno)
+	}
+	if errno := call(mem, "fd_write", 0, 0, 0, 16); errno != wasiErrnoBadf {
+		t.Fatalf("fd_write to stdin: errno %d", errno)
+	}
+	if errno := call(mem, "fd_filestat_get", 2, 32); errno != wasiErrnoSuccess || mem[48] != wasiFiletypeCharacterDevice {
+		t.Fatalf("fd_filestat_get: errno %d", errno)
+	}
+	if errno := call(mem, "fd_filestat_get", 3, 32); errno != wasiErrnoBadf {
+		t.Fatalf("fd_filestat_get of an invalid fd: errno %d", errno)
+	}
+	if errno := call(mem, "path_open", 3, 0, 0, 0, 0, 0, 0, 0, 0); errno != wasiErrnoBadf {
+		t.Fatalf("path_open: errno %d", errno)
+	}
+}
+
+// TestTinygoWasi loads the tinygo blank precompile, built with -target=wasi,
+// and checks that it only imports the WASI profile and runs in it.
+func TestTinygoWasi(t *testing.T) {
+	if len(blankCode) == 0 {
+		t.Skip("testdata/blank.wasm is empty, run make concrete-wasm")
+	}
+	if err := checkImports(blankCode); err != nil {
+		t.Fatal(err)
+	}
+	for _, rt := range sandboxRuntimes {
+		t.Run(rt.name, func(t *testing.T) {
+			pc, err := rt.new(blankCode, SandboxConfig{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2; i++ {
+				env := newUntrustedEnvironment(10_000_000)
+				if _, _, err := concrete.RunPrecompile(pc, env, nil, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -21,116 +21,677 @@
 package wasm
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/concrete"
 	"github.com/ethereum/go-ethereum/concrete/api"
 	"github.com/ethereum/go-ethereum/concrete/wasm/host"
 	"github.com/ethereum/go-ethereum/concrete/wasm/memory"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/wasmerio/wasmer-go/wasmer"
 )
 
 func NewWasmerPrecompile(code []byte) concrete.Precompile {
 	config := wasmer.NewConfig().UseCraneliftCompiler()
-	return newWasmerPrecompile(code, config)
+	return NewWasmerPrecompileWithConfig(code, config)
 }
 
 func NewWasmerPrecompileWithConfig(code []byte, config *wasmer.Config) concrete.Precompile {
-	return newWasmerPrecompile(code, config)
+	pc, err := newWasmerPrecompile(code, config, nil, nil, DefaultPoolSize)
+	if err != nil {
+		panic(err)
+	}
+	return pc
 }
 
-func newWasmerModule(envCall host.WasmerHostFunc, code []byte, engineConfig *wasmer.Config) (*wasmer.Module, *wasmer.Instance, error) {
-	engine := wasmer.NewEngineWithConfig(engineConfig)
-	store := wasmer.NewStore(engine)
+// NewWasmerPrecompileWithCache is like NewWasmerPrecompileWithConfig, but
+// reuses the module compiled by previous runs from the cache directory. Nodes
+// pass stack.ResolvePath(CacheDirName), see geth.NewConcreteGethAppWithNode.
+// DefaultCacheDir is used if empty, when there is no node. A nil config uses the cranelift compiler.
+func NewWasmerPrecompileWithCache(code []byte, config *wasmer.Config, cacheDir string) concrete.Precompile {
+	if config == nil {
+		config = wasmer.NewConfig().UseCraneliftCompiler()
+	}
+	pc, err := newWasmerPrecompile(code, config, openCacheOrWarn(cacheDir), nil, DefaultPoolSize)
+	if err != nil {
+		panic(err)
+	}
+	return pc
+}
+
+// NewWasmerPrecompileWithPool is like NewWasmerPrecompileWithConfig, but runs
+// up to poolSize calls concurrently, each in its own instance of the module.
+// A nil config uses the cranelift compiler.
+func NewWasmerPrecompileWithPool(code []byte, config *wasmer.Config, poolSize int) concrete.Precompile {
+	if config == nil {
+		config = wasmer.NewConfig().UseCraneliftCompiler()
+	}
+	pc, err := newWasmerPrecompile(code, config, nil, nil, poolSize)
+	if err != nil {
+		panic(err)
+	}
+	return pc
+}
+
+// NewWasmerSandboxedPrecompile creates a precompile running an untrusted
+// module, see SandboxConfig. A nil config uses the cranelift compiler.
+// Wasmer cannot interrupt running calls, so timeouts are not supported.
+func NewWasmerSandboxedPrecompile(code []byte, config *wasmer.Config, sandbox SandboxConfig) (concrete.Precompile, error) {
+	if sandbox.Timeout > 0 {
+		return nil, ErrTimeoutUnsupported
+	}
+	if config == nil {
+		config = wasmer.NewConfig().UseCraneliftCompiler()
+	}
+	code, err := sandboxCode(code, &sandbox)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := newWasmerPrecompile(code, config, nil, &sandbox, sandbox.PoolSize)
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// wasmerModule loads a module compiled by a previous run, or compiles it and
+// stores it in the cache. Corrupted entries are compiled again.
+func (c *Cache) wasmerModule(store *wasmer.Store, code []byte) (*wasmer.Module, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.wasmerEntry(code)
+	data, err := readEntry(entry)
+	if err == nil {
+		module, err := wasmer.DeserializeModule(store, data)
+		if err == nil {
+			return module, nil
+		}
+		log.Warn("Failed to load wasm cache entry", "path", entry, "err", err)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Warn("Failed to load wasm cache entry", "path", entry, "err", err)
+	}
+
 	module, err := wasmer.NewModule(store, code)
+	if err != nil {
+		return nil, err
+	}
+	data, err = module.Serialize()
+	if err == nil {
+		if err = os.MkdirAll(c.wasmerDir, 0o700); err == nil {
+			err = writeEntry(entry, data)
+		}
+	}
+	if err != nil {
+		log.Warn("Failed to write wasm cache entry", "path", entry, "err", err)
+	}
+	return module, nil
+}
 
+// newWasmerModule compiles and instantiates a module. Host failures are
+// passed to fail.
+func newWasmerModule(envCall host.WasmerHostFunc, fail func(error), code []byte, engineConfig *wasmer.Config, cache *Cache) (*wasmer.Module, *wasmer.Instance, error) {
+	module, newImports, err := compileWasmerModule(code, engineConfig, cache)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	wasiEnv, err := wasmer.NewWasiStateBuilder("wasi-program").Finalize()
+	imports, err := newImports(envCall, fail, func() bool { return false })
 	if err != nil {
 		return nil, nil, err
 	}
-	importObject, err := wasiEnv.GenerateImportObject(store, module)
+	instance, err := imports.instantiate(module)
 	if err != nil {
 		return nil, nil, err
 	}
+	return module, instance, nil
+}
+
+// compileWasmerModule compiles a module, and returns a function creating the
+// imports of its instances, calling the given environment host function. Host
+// functions report failures to fail, and the guest traps once they return if
+// failed returns true, see wasmerShim.
+func compileWasmerModule(code []byte, engineConfig *wasmer.Config, cache *Cache) (*wasmer.Module, wasmerImportsFunc, error) {
+	if err := checkImports(code); err != nil {
+		return nil, nil, err
+	}
+	engine := wasmer.NewEngineWithConfig(engineConfig)
+	store := wasmer.NewStore(engine)
+	var (
+		module *wasmer.Module
+		err    error
+	)
+	if cache != nil {
+		module, err = cache.wasmerModule(store, code)
+	} else {
+		module, err = wasmer.NewModule(store, code)
+	}
 
-	wasmerEnv := host.NewWasmerEnvironment()
+	if err != nil {
+		return nil, nil, err
+	}
+	shimCode, err := wasmerShim()
+	if err != nil {
+		return nil, nil, err
+	}
+	shimModule, err := wasmer.NewModule(store, shimCode)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	importObject.Register(
-		"env",
-		map[string]wasmer.IntoExtern{
-			Environment_WasmFuncName: wasmer.NewFunctionWithEnvironment(
+	newImports := func(envCall host.WasmerHostFunc, fail func(error), failed func() bool) (*wasmerImports, error) {
+		imports := &wasmerImports{
+			object:  wasmer.NewImportObject(),
+			env:     host.NewWasmerEnvironment(),
+			wasiMem: &wasmerWasiMemory{},
+		}
+		hostFunctions := map[string]wasmer.IntoExtern{
+			wasmerShimFailed: wasmer.NewFunction(
+				store,
+				wasmer.NewFunctionType(wasmer.NewValueTypes(), wasmer.NewValueTypes(wasmer.I32)),
+				func([]wasmer.Value) ([]wasmer.Value, error) {
+					if failed() {
+						return []wasmer.Value{wasmer.NewI32(1)}, nil
+					}
+					return []wasmer.Value{wasmer.NewI32(0)}, nil
+				},
+			),
+			wasmerShimName("env", Environment_WasmFuncName): wasmer.NewFunctionWithEnvironment(
 				store,
 				wasmer.NewFunctionType(
 					wasmer.NewValueTypes(wasmer.I64),
 					wasmer.NewValueTypes(wasmer.I64),
 				),
-				wasmerEnv,
+				imports.env,
 				envCall,
 			),
-		},
-	)
+		}
+		for name, function := range wasmerWasiFunctions(store, imports.wasiMem, fail) {
+			hostFunctions[wasmerShimName(wasiModuleName, name)] = function
+		}
+		shimImports := wasmer.NewImportObject()
+		shimImports.Register(wasmerShimModule, hostFunctions)
+		shim, err := wasmer.NewInstance(shimModule, shimImports)
+		if err != nil {
+			return nil, err
+		}
+		imports.shim = shim
+
+		externs := make(map[string]map[string]wasmer.IntoExtern)
+		for _, function := range wasmerShimFunctions {
+			extern, err := shim.Exports.Get(wasmerShimName(function.module, function.name))
+			if err != nil {
+				return nil, err
+			}
+			if externs[function.module] == nil {
+				externs[function.module] = make(map[string]wasmer.IntoExtern)
+			}
+			externs[function.module][function.name] = extern
+		}
+		for module, functions := range externs {
+			imports.object.Register(module, functions)
+		}
+		return imports, nil
+	}
+
+	return module, newImports, nil
+}
 
-	instance, err := wasmer.NewInstance(module, importObject)
+// Host functions called by guests do not return errors, as wasmer-go frees
+// the traps created from them twice. Guests instead import the host functions
+// through a shim module, whose wrappers call wasmerShimFailed once a host
+// function returns and trap if it failed. Guests then stop at the failed host
+// call, like on wazero.
+
+// wasmerShimModule is the module of the host functions imported by the shim.
+// Wrapped functions are imported and exported by the shim as
+// wasmerShimName(module, name).
+const (
+	wasmerShimModule = "host"
+	wasmerShimFailed = "failed"
+)
+
+// wasmerShimFunction is a host function wrapped by the shim.
+type wasmerShimFunction struct {
+	module string
+	name   string
+	typ    funcType
+}
+
+// wasmerShimFunctions are the environment and the functions of the
+// deterministic WASI profile.
+var wasmerShimFunctions = func() []wasmerShimFunction {
+	functions := []wasmerShimFunction{
+		{"env", Environment_WasmFuncName, funcType{params: []byte{i64ValueType}, results: []byte{i64ValueType}}},
+	}
+	names := make([]string, 0, len(wasiFunctions))
+	for name := range wasiFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		functions = append(functions, wasmerShimFunction{wasiModuleName, name, wasiFunctions[name].typ})
+	}
+	return functions
+}()
+
+func wasmerShimName(module, name string) string {
+	return module + "." + name
+}
+
+var (
+	wasmerShimOnce sync.Once
+	wasmerShimCode []byte
+	wasmerShimErr  error
+)
+
+// wasmerShim returns the code of the shim module.
+func wasmerShim() ([]byte, error) {
+	wasmerShimOnce.Do(func() {
+		signature := func(typ funcType) string {
+			var b strings.Builder
+			for _, kinds := range []struct {
+				keyword string
+				types   []byte
+			}{{"param", typ.params}, {"result", typ.results}} {
+				for _, t := range kinds.types {
+					fmt.Fprintf(&b, " (%s %s)", kinds.keyword, watValueType(t))
+				}
+			}
+			return b.String()
+		}
+		var imports, funcs strings.Builder
+		fmt.Fprintf(&imports, "  (import %q %q (func $failed (result i32)))\n", wasmerShimModule, wasmerShimFailed)
+		for i, function := range wasmerShimFunctions {
+			name, sig := wasmerShimName(function.module, function.name), signature(function.typ)
+			fmt.Fprintf(&imports, "  (import %q %q (func $f%d%s))\n", wasmerShimModule, name, i, sig)
+			fmt.Fprintf(&funcs, "  (func (export %q)%s\n", name, sig)
+			for j := range function.typ.params {
+				fmt.Fprintf(&funcs, "    local.get %d\n", j)
+			}
+			fmt.Fprintf(&funcs, "    call $f%d\n    call $failed\n    if\n      unreachable\n    end)\n", i)
+		}
+		wasmerShimCode, wasmerShimErr = wasmer.Wat2Wasm("(module\n" + imports.String() + funcs.String() + ")")
+	})
+	return wasmerShimCode, wasmerShimErr
+}
+
+func watValueType(typ byte) string {
+	switch typ {
+	case i64ValueType:
+		return "i64"
+	case f32ValueType:
+		return "f32"
+	case f64ValueType:
+		return "f64"
+	default:
+		return "i32"
+	}
+}
+
+// wasmerImportsFunc creates the imports of the instances of a module, see
+// compileWasmerModule.
+type wasmerImportsFunc func(envCall host.WasmerHostFunc, fail func(error), failed func() bool) (*wasmerImports, error)
+
+// wasmerImports are the imports of the instances of a module. They are
+// created once and shared by the instances replacing each other in a pool,
+// as wasmer-go registers host functions in a global store that gets slower
+// to update the more functions it holds.
+type wasmerImports struct {
+	object  *wasmer.ImportObject
+	shim    *wasmer.Instance
+	env     *host.WasmerEnvironment
+	wasiMem *wasmerWasiMemory
+}
+
+// instantiate creates a new instance of the module, and binds the host
+// functions to it.
+func (i *wasmerImports) instantiate(module *wasmer.Module) (*wasmer.Instance, error) {
+	i.wasiMem.memory = nil
+	instance, err := wasmer.NewInstance(module, i.object)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	i.env.Init(instance)
+	if i.wasiMem.memory, err = instance.Exports.GetMemory("memory"); err != nil {
+		instance.Close()
+		return nil, err
 	}
-	wasmerEnv.Init(instance)
+	return instance, nil
+}
 
-	return module, instance, nil
+// wasmerWasiMemory is the memory of an instance, set once instantiated.
+// Functions called by the start function of the module see no memory.
+type wasmerWasiMemory struct {
+	memory *wasmer.Memory
+}
+
+func (m *wasmerWasiMemory) Read(offset, byteCount uint32) ([]byte, bool) {
+	if m.memory == nil {
+		return nil, false
+	}
+	data := m.memory.Data()
+	if uint64(offset)+uint64(byteCount) > uint64(len(data)) {
+		return nil, false
+	}
+	return data[offset : offset+byteCount], true
+}
+
+func (m *wasmerWasiMemory) Write(offset uint32, v []byte) bool {
+	if m.memory == nil {
+		return false
+	}
+	data := m.memory.Data()
+	if uint64(offset)+uint64(len(v)) > uint64(len(data)) {
+		return false
+	}
+	copy(data[offset:], v)
+	return true
+}
+
+// wasmerWasiFunctions returns the functions of the deterministic WASI profile,
+// imported by guests through the shim. Errors are reported to fail.
+func wasmerWasiFunctions(store *wasmer.Store, mem wasiMemory, fail func(error)) map[string]wasmer.IntoExtern {
+	externs := make(map[string]wasmer.IntoExtern, len(wasiFunctions))
+	for name, function := range wasiFunctions {
+		function := function
+		valueTypes := func(types []byte) []*wasmer.ValueType {
+			kinds := make([]wasmer.ValueKind, len(types))
+			for i, typ := range types {
+				kinds[i] = wasmer.I32
+				if typ == i64ValueType {
+					kinds[i] = wasmer.I64
+				}
+			}
+			return wasmer.NewValueTypes(kinds...)
+		}
+		funcType := wasmer.NewFunctionType(valueTypes(function.typ.params), valueTypes(function.typ.results))
+		externs[name] = wasmer.NewFunction(store, funcType, func(values []wasmer.Value) ([]wasmer.Value, error) {
+			args := make([]uint64, len(values))
+			for i, value := range values {
+				if value.Kind() == wasmer.I64 {
+					args[i] = uint64(value.I64())
+				} else {
+					args[i] = uint64(uint32(value.I32()))
+				}
+			}
+			errno, err := function.call(mem, args)
+			if err != nil {
+				fail(err)
+			}
+			if len(function.typ.results) == 0 {
+				return []wasmer.Value{}, nil
+			}
+			return []wasmer.Value{wasmer.NewI32(int32(errno))}, nil
+		})
+	}
+	return externs
 }
 
 type wasmerPrecompile struct {
+	module     *wasmer.Module
+	newImports wasmerImportsFunc
+	sandbox    *SandboxConfig
+	pool       *instancePool[*wasmerInstance]
+}
+
+func newWasmerPrecompile(code []byte, engineConfig *wasmer.Config, cache *Cache, sandbox *SandboxConfig, poolSize int) (*wasmerPrecompile, error) {
+	module, newImports, err := compileWasmerModule(code, engineConfig, cache)
+	if err != nil {
+		return nil, err
+	}
+	pc := &wasmerPrecompile{module: module, newImports: newImports, sandbox: sandbox}
+	pc.pool, err = newInstancePool(poolSize, pc.newInstance)
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+func (p *wasmerPrecompile) newInstance() (*wasmerInstance, error) {
+	inst := &wasmerInstance{sandbox: p.sandbox}
+	if p.sandbox != nil {
+		inst.gas.limit = p.sandbox.maxUnmeteredGas()
+	}
+
+	envCaller := host.NewWasmerEnvironmentCaller(func() api.Environment { return inst.environment })
+	envCall := func(env interface{}, args []wasmer.Value) (ret []wasmer.Value, err error) {
+		if inst.sandbox != nil {
+			// Panics cannot unwind through wasmer. The failure traps the
+			// guest once the function returns, and is returned by the call.
+			defer func() {
+				if r := recover(); r != nil {
+					inst.hostErr = sandboxError(r)
+					ret, err = []wasmer.Value{wasmer.NewI64(0)}, nil
+				}
+			}()
+		}
+		inst.gas.charge()
+		defer inst.gas.charge()
+		return envCaller(env, args)
+	}
+	fail := func(err error) {
+		if inst.sandbox != nil {
+			err = sandboxError(err)
+		}
+		inst.hostErr = err
+	}
+	failed := func() bool {
+		return inst.hostErr != nil || (inst.environment != nil && inst.environment.Error() != nil)
+	}
+	imports, err := p.newImports(envCall, fail, failed)
+	if err != nil {
+		return nil, err
+	}
+	inst.instantiate = func() (*wasmer.Instance, error) { return imports.instantiate(p.module) }
+
+	instance, err := inst.instantiate()
+	if err != nil {
+		return nil, err
+	}
+	if err := inst.init(instance); err != nil {
+		instance.Close()
+		return nil, err
+	}
+	if err := inst.checkAbiVersion(); err != nil {
+		instance.Close()
+		return nil, err
+	}
+	inst.updateMemoryUsage()
+	return inst, nil
+}
+
+func (p *wasmerPrecompile) memoryStats() MemoryStats {
+	return poolMemoryStats(p.pool)
+}
+
+func (p *wasmerPrecompile) IsStatic(input []byte) bool {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.IsStatic(input)
+}
+
+func (p *wasmerPrecompile) Finalise(env api.Environment) error {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.Finalise(env)
+}
+
+func (p *wasmerPrecompile) Commit(env api.Environment) error {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.Commit(env)
+}
+
+func (p *wasmerPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.Run(env, input)
+}
+
+var (
+	_ concrete.Precompile = (*wasmerPrecompile)(nil)
+	_ memoryReporter      = (*wasmerPrecompile)(nil)
+)
+
+// wasmerInstance is an instance of the module of a precompile. Instances are
+// used by a single call at a time.
+type wasmerInstance struct {
+	memoryUsage uint64 // Accessed atomically, first for 64-bit alignment
 	instance    *wasmer.Instance
-	module      *wasmer.Module
-	mutex       sync.Mutex
+	instantiate func() (*wasmer.Instance, error)
+	sandbox     *SandboxConfig
 	memory      memory.Memory
 	allocator   memory.Allocator
+	linearMem   *wasmer.Memory
 	environment *api.Env
+	gas         gasMeter
+	hostErr     error
+	resetErr    error
 	expIsStatic wasmer.NativeFunction
 	expFinalise wasmer.NativeFunction
 	expCommit   wasmer.NativeFunction
 	expRun      wasmer.NativeFunction
 }
 
-func newWasmerPrecompile(code []byte, engineConfig *wasmer.Config) *wasmerPrecompile {
-	pc := &wasmerPrecompile{}
-
-	envCall := host.NewWasmerEnvironmentCaller(func() api.Environment { return pc.environment })
-	module, instance, err := newWasmerModule(envCall, code, engineConfig)
+// init binds the instance to a new instance of the module, and runs its start
+// function.
+func (p *wasmerInstance) init(instance *wasmer.Instance) error {
+	linearMem, err := instance.Exports.GetMemory("memory")
 	if err != nil {
-		panic(err)
+		return err
 	}
+	p.instance = instance
+	p.linearMem = linearMem
+	p.memory, p.allocator = host.NewWasmerMemory(instance)
 
-	pc.instance = instance
-	pc.module = module
-	pc.memory, pc.allocator = host.NewWasmerMemory(instance)
+	p.gas.global = nil
+	if global, err := instance.Exports.GetGlobal(Gas_WasmGlobalName); err == nil {
+		p.gas.global = wasmerGasGlobal{global}
+	}
 
-	pc.expIsStatic, err = instance.Exports.GetFunction(IsStatic_WasmFuncName)
-	if err != nil {
-		panic(err)
+	if p.expIsStatic, err = instance.Exports.GetFunction(IsStatic_WasmFuncName); err != nil {
+		return err
+	}
+	if p.expFinalise, err = instance.Exports.GetFunction(Finalise_WasmFuncName); err != nil {
+		return err
+	}
+	if p.expCommit, err = instance.Exports.GetFunction(Commit_WasmFuncName); err != nil {
+		return err
 	}
-	pc.expFinalise, err = instance.Exports.GetFunction(Finalise_WasmFuncName)
+	if p.expRun, err = instance.Exports.GetFunction(Run_WasmFuncName); err != nil {
+		return err
+	}
+	return p.start()
+}
+
+// start runs the start function of the module, if exported, without the
+// environment of the current call and with the gas of the unmetered calls,
+// as on wazero.
+func (p *wasmerInstance) start() error {
+	expStart, err := p.instance.Exports.GetFunction(Start_WasmFuncName)
 	if err != nil {
-		panic(err)
+		return nil
 	}
-	pc.expCommit, err = instance.Exports.GetFunction(Commit_WasmFuncName)
+	env := p.environment
+	p.environment = nil
+	defer func() { p.environment = env }()
+	return p.gas.initialize(func() error {
+		_, err := expStart()
+		if p.hostErr != nil {
+			err, p.hostErr = p.hostErr, nil
+		}
+		return err
+	})
+}
+
+// checkAbiVersion returns an error if the module does not implement the host
+// ABI version of the host.
+func (p *wasmerInstance) checkAbiVersion() error {
+	expAbiVersion, err := p.instance.Exports.GetFunction(AbiVersion_WasmFuncName)
 	if err != nil {
-		panic(err)
+		return ErrMissingAbiVersion
+	}
+	p.gas.start(nil)
+	defer p.gas.stop()
+	ret, err := expAbiVersion()
+	if p.hostErr != nil {
+		err, p.hostErr = p.hostErr, nil
 	}
-	pc.expRun, err = instance.Exports.GetFunction(Run_WasmFuncName)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	version, ok := ret.(int64)
+	if !ok {
+		return fmt.Errorf("%w: invalid %s signature", ErrUnsupportedAbiVersion, AbiVersion_WasmFuncName)
 	}
+	return checkAbiVersion(uint64(version))
+}
 
-	return pc
+// renew replaces the instance of the module by a new one once a call
+// returns, carrying over the gas meter. Calls then never see the globals or
+// memory left by previous calls, whichever instance of the pool they run in.
+// If the module cannot be instantiated, the next calls fail.
+func (p *wasmerInstance) renew() {
+	p.updateMemoryUsage()
+	p.gas.charge()
+	p.instance.Close()
+	instance, err := p.instantiate()
+	if err == nil {
+		err = p.init(instance)
+	}
+	if p.resetErr = err; err == nil {
+		p.gas.refill()
+	}
+}
+
+// sandboxed runs a call into the module, and prunes the guest allocations
+// once it returns. Each call runs in a new instance of the module. In sandbox
+// mode, panics are returned as errors.
+func (p *wasmerInstance) sandboxed(call func()) (err error) {
+	if p.resetErr != nil {
+		if p.sandbox == nil {
+			panic(p.resetErr)
+		}
+		return p.resetErr
+	}
+	defer p.renew()
+	if p.sandbox == nil {
+		defer func() {
+			if p.hostErr != nil {
+				// The host failure trapped the module
+				err := p.hostErr
+				p.hostErr = nil
+				recover()
+				panic(err)
+			}
+		}()
+		call()
+		p.allocator.Prune()
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = sandboxError(r)
+		}
+		if p.hostErr != nil {
+			// The host failure trapped the module
+			err, p.hostErr = p.hostErr, nil
+		}
+	}()
+	call()
+	p.allocator.Prune()
+	return nil
 }
 
-func (p *wasmerPrecompile) call__Uint64(expFunc wasmer.NativeFunction) uint64 {
+func (p *wasmerInstance) call__Uint64(expFunc wasmer.NativeFunction) uint64 {
 	_ret, err := expFunc()
+	p.gas.charge()
 	if err != nil {
 		panic(err)
 	}
@@ -138,7 +699,7 @@ func (p *wasmerPrecompile) call__Uint64(expFunc wasmer.NativeFunction) uint64 {
 	return uint64(ret)
 }
 
-func (p *wasmerPrecompile) call__Err(expFunc wasmer.NativeFunction) error {
+func (p *wasmerInstance) call__Err(expFunc wasmer.NativeFunction) error {
 	_retPointer := p.call__Uint64(expFunc)
 	retPointer := memory.MemPointer(_retPointer)
 	retErr := memory.GetError(p.memory, retPointer)
@@ -146,10 +707,11 @@ func (p *wasmerPrecompile) call__Err(expFunc wasmer.NativeFunction) error {
 	return retErr
 }
 
-func (p *wasmerPrecompile) call_Bytes_Uint64(expFunc wasmer.NativeFunction, input []byte) uint64 {
+func (p *wasmerInstance) call_Bytes_Uint64(expFunc wasmer.NativeFunction, input []byte) uint64 {
 	pointer := memory.PutValue(p.memory, input)
 	defer p.allocator.Free(pointer)
 	_ret, err := expFunc(int64(pointer))
+	p.gas.charge()
 	if err != nil && p.environment.Error() == nil {
 		panic(err)
 	}
@@ -157,7 +719,7 @@ func (p *wasmerPrecompile) call_Bytes_Uint64(expFunc wasmer.NativeFunction, inpu
 	return uint64(ret)
 }
 
-func (p *wasmerPrecompile) call_Bytes_BytesErr(expFunc wasmer.NativeFunction, input []byte) ([]byte, error) {
+func (p *wasmerInstance) call_Bytes_BytesErr(expFunc wasmer.NativeFunction, input []byte) ([]byte, error) {
 	_retPointer := p.call_Bytes_Uint64(expFunc, input)
 	retPointer := memory.MemPointer(_retPointer)
 	retValues, retErr := memory.GetReturnWithError(p.memory, retPointer, true)
@@ -167,46 +729,89 @@ func (p *wasmerPrecompile) call_Bytes_BytesErr(expFunc wasmer.NativeFunction, in
 	return retValues[0], retErr
 }
 
-func (p *wasmerPrecompile) before(env api.Environment) {
+func (p *wasmerInstance) before(env api.Environment) {
 	var envImpl *api.Env
 	if env != nil {
 		envImpl = env.(*api.Env)
-		if !envImpl.Config().Trusted {
+		if !envImpl.Config().Trusted && p.sandbox == nil {
 			panic("untrusted environment")
 		}
 	}
-	p.mutex.Lock()
 	p.environment = envImpl
+	p.gas.start(envImpl)
 }
 
-func (p *wasmerPrecompile) after(env api.Environment) {
+func (p *wasmerInstance) after(env api.Environment) {
+	p.gas.stop()
 	p.environment = nil
-	// p.allocator.Prune()
-	p.mutex.Unlock()
 }
 
-func (p *wasmerPrecompile) IsStatic(input []byte) bool {
+func (p *wasmerInstance) updateMemoryUsage() {
+	atomic.StoreUint64(&p.memoryUsage, uint64(p.linearMem.DataSize()))
+}
+
+func (p *wasmerInstance) memorySize() uint64 {
+	return atomic.LoadUint64(&p.memoryUsage)
+}
+
+func (p *wasmerInstance) IsStatic(input []byte) bool {
 	p.before(nil)
 	defer p.after(nil)
-	return p.call_Bytes_Uint64(p.expIsStatic, input) != 0
+	var isStatic bool
+	if err := p.sandboxed(func() { isStatic = p.call_Bytes_Uint64(p.expIsStatic, input) != 0 }); err != nil {
+		return false
+	}
+	return isStatic
 }
 
-func (p *wasmerPrecompile) Finalise(env api.Environment) error {
+func (p *wasmerInstance) Finalise(env api.Environment) error {
 	p.before(env)
 	defer p.after(env)
-	return p.call__Err(p.expFinalise)
+	var err error
+	if failure := p.sandboxed(func() { err = p.call__Err(p.expFinalise) }); failure != nil {
+		return failure
+	}
+	return err
 }
 
-func (p *wasmerPrecompile) Commit(env api.Environment) error {
+func (p *wasmerInstance) Commit(env api.Environment) error {
 	p.before(env)
 	defer p.after(env)
-	return p.call__Err(p.expCommit)
+	var err error
+	if failure := p.sandboxed(func() { err = p.call__Err(p.expCommit) }); failure != nil {
+		return failure
+	}
+	return err
 }
 
-func (p *wasmerPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+func (p *wasmerInstance) Run(env api.Environment, input []byte) ([]byte, error) {
 	p.before(env)
 	defer p.after(env)
-	return p.call_Bytes_BytesErr(p.expRun, input)
+	var (
+		output []byte
+		err    error
+	)
+	if failure := p.sandboxed(func() { output, err = p.call_Bytes_BytesErr(p.expRun, input) }); failure != nil {
+		return nil, failure
+	}
+	return output, err
+}
+
+type wasmerGasGlobal struct {
+	global *wasmer.Global
+}
+
+func (g wasmerGasGlobal) get() uint64 {
+	value, err := g.global.Get()
+	if err != nil {
+		panic(err)
+	}
+	gas, _ := value.(int64)
+	return uint64(gas)
 }
 
-var _ concrete.Precompile = (*wasmerPrecompile)(nil)
+func (g wasmerGasGlobal) set(gas uint64) {
+	if err := g.global.Set(int64(gas), wasmer.I64); err != nil {
+		panic(err)
+	}
+}
@@ -17,7 +17,9 @@ package wasm
 
 import (
 	"context"
-	"sync"
+	"errors"
+	"fmt"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/concrete"
 	"github.com/ethereum/go-ethereum/concrete/api"
@@ -25,93 +27,400 @@ import (
 	"github.com/ethereum/go-ethereum/concrete/wasm/memory"
 	"github.com/tetratelabs/wazero"
 	wz_api "github.com/tetratelabs/wazero/api"
-	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
 )
 
 // Note: For trusted use only. Precompiles can trigger a panic in the host.
+// Use NewWazeroSandboxedPrecompile to run untrusted modules.
 
 func NewWazeroPrecompile(code []byte) concrete.Precompile {
 	config := wazero.NewRuntimeConfigCompiler()
-	return newWazeroPrecompile(code, config)
+	return NewWazeroPrecompileWithConfig(code, config)
 }
 
 func NewWazeroPrecompileWithConfig(code []byte, config wazero.RuntimeConfig) concrete.Precompile {
-	return newWazeroPrecompile(code, config)
+	pc, err := newWazeroPrecompile(code, config, nil, nil, DefaultPoolSize)
+	if err != nil {
+		panic(err)
+	}
+	return pc
 }
 
-func newWazeroModule(envCall host.WazeroHostFunc, code []byte, runtimeConfig wazero.RuntimeConfig) (wz_api.Module, wazero.Runtime, error) {
+// NewWazeroPrecompileWithCache is like NewWazeroPrecompileWithConfig, but
+// reuses the code compiled by previous runs from the cache directory. Nodes
+// pass stack.ResolvePath(CacheDirName), see geth.NewConcreteGethAppWithNode.
+// DefaultCacheDir is used if empty, when there is no node. A nil config uses the compiler.
+func NewWazeroPrecompileWithCache(code []byte, config wazero.RuntimeConfig, cacheDir string) concrete.Precompile {
+	if config == nil {
+		config = wazero.NewRuntimeConfigCompiler()
+	}
+	pc, err := newWazeroPrecompile(code, config, openCacheOrWarn(cacheDir), nil, DefaultPoolSize)
+	if err != nil {
+		panic(err)
+	}
+	return pc
+}
+
+// NewWazeroPrecompileWithPool is like NewWazeroPrecompileWithConfig, but runs
+// up to poolSize calls concurrently, each in its own instance of the module.
+// A nil config uses the compiler.
+func NewWazeroPrecompileWithPool(code []byte, config wazero.RuntimeConfig, poolSize int) concrete.Precompile {
+	if config == nil {
+		config = wazero.NewRuntimeConfigCompiler()
+	}
+	pc, err := newWazeroPrecompile(code, config, nil, nil, poolSize)
+	if err != nil {
+		panic(err)
+	}
+	return pc
+}
+
+// NewWazeroSandboxedPrecompile creates a precompile running an untrusted
+// module, see SandboxConfig. A nil config uses the compiler.
+func NewWazeroSandboxedPrecompile(code []byte, config wazero.RuntimeConfig, sandbox SandboxConfig) (concrete.Precompile, error) {
+	if config == nil {
+		config = wazero.NewRuntimeConfigCompiler()
+	}
+	code, err := sandboxCode(code, &sandbox)
+	if err != nil {
+		return nil, err
+	}
+	config = config.WithMemoryLimitPages(sandbox.maxMemoryPages())
+	if sandbox.Timeout > 0 {
+		config = config.WithCloseOnContextDone(true)
+	}
+	pc, err := newWazeroPrecompile(code, config, nil, &sandbox, sandbox.PoolSize)
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+func newWazeroModule(envCall host.WazeroHostFunc, code []byte, runtimeConfig wazero.RuntimeConfig, cache *Cache) (wz_api.Module, wazero.CompiledModule, wazero.Runtime, error) {
+	ctx := context.Background()
+	compiled, r, err := compileWazeroModule(envCall, code, runtimeConfig, cache)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	mod, err := r.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().WithName(""))
+	if err != nil {
+		r.Close(ctx)
+		return nil, nil, nil, err
+	}
+	return mod, compiled, r, nil
+}
+
+// compileWazeroModule compiles a module in a new runtime providing the host
+// modules. The module can be instantiated several times in the runtime.
+func compileWazeroModule(envCall host.WazeroHostFunc, code []byte, runtimeConfig wazero.RuntimeConfig, cache *Cache) (wazero.CompiledModule, wazero.Runtime, error) {
+	if err := checkImports(code); err != nil {
+		return nil, nil, err
+	}
+	if cache == nil {
+		return compileWazeroModuleInRuntime(envCall, code, runtimeConfig)
+	}
+	var (
+		compiled wazero.CompiledModule
+		r        wazero.Runtime
+	)
+	err := cache.compileWazero(runtimeConfig, func(config wazero.RuntimeConfig) error {
+		var err error
+		compiled, r, err = compileWazeroModuleInRuntime(envCall, code, config)
+		return err
+	})
+	return compiled, r, err
+}
+
+func compileWazeroModuleInRuntime(envCall host.WazeroHostFunc, code []byte, runtimeConfig wazero.RuntimeConfig) (wazero.CompiledModule, wazero.Runtime, error) {
 	ctx := context.Background()
 	r := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
 	_, err := r.NewHostModuleBuilder("env").
 		NewFunctionBuilder().WithFunc(envCall).Export(Environment_WasmFuncName).
 		Instantiate(ctx)
-	if err != nil {
-		return nil, nil, err
+	if err == nil {
+		err = instantiateWazeroWasi(ctx, r)
+	}
+	var compiled wazero.CompiledModule
+	if err == nil {
+		compiled, err = r.CompileModule(ctx, code)
 	}
-	wasi_snapshot_preview1.MustInstantiate(ctx, r)
-	mod, err := r.Instantiate(ctx, code)
 	if err != nil {
+		r.Close(ctx)
 		return nil, nil, err
 	}
-	return mod, r, nil
+	return compiled, r, nil
+}
+
+// instantiateWazeroWasi instantiates the deterministic WASI profile in the
+// runtime.
+func instantiateWazeroWasi(ctx context.Context, r wazero.Runtime) error {
+	builder := r.NewHostModuleBuilder(wasiModuleName)
+	for name, function := range wasiFunctions {
+		function := function
+		call := func(ctx context.Context, mod wz_api.Module, stack []uint64) {
+			errno, err := function.call(mod.Memory(), stack)
+			if err != nil {
+				panic(err)
+			}
+			if len(function.typ.results) > 0 {
+				stack[0] = uint64(errno)
+			}
+		}
+		builder.NewFunctionBuilder().
+			WithGoModuleFunction(wz_api.GoModuleFunc(call), function.typ.params, function.typ.results).
+			Export(name)
+	}
+	_, err := builder.Instantiate(ctx)
+	return err
 }
 
 type wazeroPrecompile struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	sandbox  *SandboxConfig
+	pool     *instancePool[*wazeroInstance]
+}
+
+// wazeroInstanceKey is the context key of the instance running a call, used
+// by the environment host function shared by all the instances.
+type wazeroInstanceKey struct{}
+
+func newWazeroPrecompile(code []byte, runtimeConfig wazero.RuntimeConfig, cache *Cache, sandbox *SandboxConfig, poolSize int) (*wazeroPrecompile, error) {
+	envCall := func(ctx context.Context, module wz_api.Module, pointer uint64) uint64 {
+		inst := ctx.Value(wazeroInstanceKey{}).(*wazeroInstance)
+		inst.gas.charge()
+		defer inst.gas.charge()
+		return inst.envCall(ctx, module, pointer)
+	}
+	compiled, r, err := compileWazeroModule(envCall, code, runtimeConfig, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &wazeroPrecompile{runtime: r, compiled: compiled, sandbox: sandbox}
+	pc.pool, err = newInstancePool(poolSize, pc.newInstance)
+	if err != nil {
+		r.Close(context.Background())
+		return nil, err
+	}
+	return pc, nil
+}
+
+func (p *wazeroPrecompile) newInstance() (*wazeroInstance, error) {
+	inst := &wazeroInstance{runtime: p.runtime, compiled: p.compiled, sandbox: p.sandbox}
+	if p.sandbox != nil {
+		inst.gas.limit = p.sandbox.maxUnmeteredGas()
+	}
+	inst.baseCtx = context.WithValue(context.Background(), wazeroInstanceKey{}, inst)
+	inst.ctx = inst.baseCtx
+	inst.envCall = host.NewWazeroEnvironmentCaller(func() api.Environment { return inst.environment })
+	if err := inst.instantiate(); err != nil {
+		return nil, err
+	}
+	if err := inst.checkAbiVersion(); err != nil {
+		inst.module.Close(context.Background())
+		return nil, err
+	}
+	inst.updateMemoryUsage()
+	return inst, nil
+}
+
+func (p *wazeroPrecompile) memoryStats() MemoryStats {
+	return poolMemoryStats(p.pool)
+}
+
+func (p *wazeroPrecompile) IsStatic(input []byte) bool {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.IsStatic(input)
+}
+
+func (p *wazeroPrecompile) Finalise(env api.Environment) error {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.Finalise(env)
+}
+
+func (p *wazeroPrecompile) Commit(env api.Environment) error {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.Commit(env)
+}
+
+func (p *wazeroPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+	inst := p.pool.get()
+	defer p.pool.put(inst)
+	return inst.Run(env, input)
+}
+
+var (
+	_ concrete.Precompile = (*wazeroPrecompile)(nil)
+	_ memoryReporter      = (*wazeroPrecompile)(nil)
+)
+
+// wazeroInstance is an instance of the module of a precompile. Instances are
+// used by a single call at a time.
+type wazeroInstance struct {
+	memoryUsage uint64 // Accessed atomically, first for 64-bit alignment
 	runtime     wazero.Runtime
+	compiled    wazero.CompiledModule
+	sandbox     *SandboxConfig
 	module      wz_api.Module
-	mutex       sync.Mutex
+	baseCtx     context.Context
+	ctx         context.Context
+	envCall     host.WazeroHostFunc
 	memory      memory.Memory
 	allocator   memory.Allocator
 	environment *api.Env
+	gas         gasMeter
+	resetErr    error
 	expIsStatic wz_api.Function
 	expFinalise wz_api.Function
 	expCommit   wz_api.Function
 	expRun      wz_api.Function
 }
 
-func newWazeroPrecompile(code []byte, runtimeConfig wazero.RuntimeConfig) *wazeroPrecompile {
-	pc := &wazeroPrecompile{}
-
-	envCall := host.NewWazeroEnvironmentCaller(func() api.Environment { return pc.environment })
-	mod, r, err := newWazeroModule(envCall, code, runtimeConfig)
+// instantiate binds the instance to a new instance of the module, and runs
+// its start function.
+func (p *wazeroInstance) instantiate() error {
+	// The start function runs once the gas global is set, see start
+	config := wazero.NewModuleConfig().WithName("").WithStartFunctions()
+	mod, err := p.runtime.InstantiateModule(p.baseCtx, p.compiled, config)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	p.module = mod
+	p.memory, p.allocator = host.NewWazeroMemory(p.ctx, mod)
 
-	pc.runtime = r
-	pc.module = mod
-	pc.memory, pc.allocator = host.NewWazeroMemory(context.Background(), mod)
+	p.gas.global = nil
+	if global, ok := mod.ExportedGlobal(Gas_WasmGlobalName).(wz_api.MutableGlobal); ok {
+		p.gas.global = wazeroGasGlobal{global}
+	}
 
-	pc.expIsStatic = mod.ExportedFunction(IsStatic_WasmFuncName)
-	if pc.expIsStatic == nil {
-		panic("isStatic not exported")
+	p.expIsStatic = mod.ExportedFunction(IsStatic_WasmFuncName)
+	if p.expIsStatic == nil {
+		return errors.New("isStatic not exported")
+	}
+	p.expFinalise = mod.ExportedFunction(Finalise_WasmFuncName)
+	if p.expFinalise == nil {
+		return errors.New("finalise not exported")
 	}
-	pc.expFinalise = mod.ExportedFunction(Finalise_WasmFuncName)
-	if pc.expFinalise == nil {
-		panic("finalise not exported")
+	p.expCommit = mod.ExportedFunction(Commit_WasmFuncName)
+	if p.expCommit == nil {
+		return errors.New("commit not exported")
 	}
-	pc.expCommit = mod.ExportedFunction(Commit_WasmFuncName)
-	if pc.expCommit == nil {
-		panic("commit not exported")
+	p.expRun = mod.ExportedFunction(Run_WasmFuncName)
+	if p.expRun == nil {
+		return errors.New("run not exported")
 	}
-	pc.expRun = mod.ExportedFunction(Run_WasmFuncName)
-	if pc.expRun == nil {
-		panic("run not exported")
+	if err := p.start(); err != nil {
+		mod.Close(context.Background())
+		return err
 	}
+	return nil
+}
 
-	return pc
+// start runs the start function of the module, if exported, without the
+// environment of the current call and with the gas of the unmetered calls.
+func (p *wazeroInstance) start() error {
+	expStart := p.module.ExportedFunction(Start_WasmFuncName)
+	if expStart == nil {
+		return nil
+	}
+	ctx, cancel := p.baseCtx, context.CancelFunc(func() {})
+	if p.sandbox != nil && p.sandbox.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(p.baseCtx, p.sandbox.Timeout)
+	}
+	defer cancel()
+	env := p.environment
+	p.environment = nil
+	defer func() { p.environment = env }()
+	return p.gas.initialize(func() error {
+		_, err := expStart.Call(ctx)
+		return err
+	})
 }
 
-func (p *wazeroPrecompile) call__Uint64(expFunc wz_api.Function) uint64 {
-	ctx := context.Background()
-	_ret, err := expFunc.Call(ctx)
+// checkAbiVersion returns an error if the module does not implement the host
+// ABI version of the host.
+func (p *wazeroInstance) checkAbiVersion() error {
+	expAbiVersion := p.module.ExportedFunction(AbiVersion_WasmFuncName)
+	if expAbiVersion == nil {
+		return ErrMissingAbiVersion
+	}
+	p.gas.start(nil)
+	defer p.gas.stop()
+	ret, err := expAbiVersion.Call(p.ctx)
+	if err != nil {
+		return err
+	}
+	if len(ret) != 1 {
+		return fmt.Errorf("%w: invalid %s signature", ErrUnsupportedAbiVersion, AbiVersion_WasmFuncName)
+	}
+	return checkAbiVersion(ret[0])
+}
+
+// renew replaces the instance of the module by a new one once a call
+// returns, carrying over the gas meter. Calls then never see the globals or
+// memory left by previous calls, whichever instance of the pool they run in.
+// If the module cannot be instantiated, the next calls fail.
+func (p *wazeroInstance) renew() {
+	p.updateMemoryUsage()
+	p.gas.charge()
+	p.module.Close(context.Background())
+	if p.resetErr = p.instantiate(); p.resetErr == nil {
+		p.gas.refill()
+	}
+}
+
+// sandboxed runs a call into the module, and prunes the guest allocations
+// once it returns. Each call runs in a new instance of the module. In sandbox
+// mode, the call is aborted after the timeout and panics are returned as
+// errors.
+func (p *wazeroInstance) sandboxed(call func()) (err error) {
+	if p.resetErr != nil {
+		if p.sandbox == nil {
+			panic(p.resetErr)
+		}
+		return p.resetErr
+	}
+	defer p.renew()
+	if p.sandbox == nil {
+		call()
+		p.allocator.Prune()
+		return nil
+	}
+	ctx, cancel := p.baseCtx, context.CancelFunc(func() {})
+	if p.sandbox.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(p.baseCtx, p.sandbox.Timeout)
+	}
+	p.ctx = ctx
+	p.memory, p.allocator = host.NewWazeroMemory(ctx, p.module)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = sandboxError(r)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = ErrTimeout
+			}
+		}
+		cancel()
+		p.ctx = p.baseCtx
+	}()
+	call()
+	p.allocator.Prune()
+	return nil
+}
+
+func (p *wazeroInstance) call__Uint64(expFunc wz_api.Function) uint64 {
+	_ret, err := expFunc.Call(p.ctx)
+	p.gas.charge()
 	if err != nil {
 		panic(err)
 	}
 	return _ret[0]
 }
 
-func (p *wazeroPrecompile) call__Err(expFunc wz_api.Function) error {
+func (p *wazeroInstance) call__Err(expFunc wz_api.Function) error {
 	_retPointer := p.call__Uint64(expFunc)
 	retPointer := memory.MemPointer(_retPointer)
 	retErr := memory.GetError(p.memory, retPointer)
@@ -119,7 +428,7 @@ func (p *wazeroPrecompile) call__Err(expFunc wz_api.Function) error {
 	return retErr
 }
 
-func (p *wazeroPrecompile) call_Bytes_Uint64(expFunc wz_api.Function, input []byte) (ret uint64) {
+func (p *wazeroInstance) call_Bytes_Uint64(expFunc wz_api.Function, input []byte) (ret uint64) {
 	defer func() {
 		if r := recover(); r != nil {
 			if p.environment.Error() == nil {
@@ -128,17 +437,17 @@ func (p *wazeroPrecompile) call_Bytes_Uint64(expFunc wz_api.Function, input []by
 			ret = memory.NullPointer.Uint64()
 		}
 	}()
-	ctx := context.Background()
 	pointer := memory.PutValue(p.memory, input)
 	defer p.allocator.Free(pointer)
-	_ret, err := expFunc.Call(ctx, pointer.Uint64())
+	_ret, err := expFunc.Call(p.ctx, pointer.Uint64())
+	p.gas.charge()
 	if err != nil {
 		panic(err)
 	}
 	return _ret[0]
 }
 
-func (p *wazeroPrecompile) call_Bytes_BytesErr(expFunc wz_api.Function, input []byte) ([]byte, error) {
+func (p *wazeroInstance) call_Bytes_BytesErr(expFunc wz_api.Function, input []byte) ([]byte, error) {
 	_retPointer := p.call_Bytes_Uint64(expFunc, input)
 	retPointer := memory.MemPointer(_retPointer)
 	retValues, retErr := memory.GetReturnWithError(p.memory, retPointer, true)
@@ -148,46 +457,86 @@ func (p *wazeroPrecompile) call_Bytes_BytesErr(expFunc wz_api.Function, input []
 	return retValues[0], retErr
 }
 
-func (p *wazeroPrecompile) before(env api.Environment) {
+func (p *wazeroInstance) before(env api.Environment) {
 	var envImpl *api.Env
 	if env != nil {
 		envImpl = env.(*api.Env)
-		if !envImpl.Config().Trusted {
+		if !envImpl.Config().Trusted && p.sandbox == nil {
 			panic("untrusted environment")
 		}
 	}
-	p.mutex.Lock()
 	p.environment = envImpl
+	p.gas.start(envImpl)
 }
 
-func (p *wazeroPrecompile) after(env api.Environment) {
+func (p *wazeroInstance) after(env api.Environment) {
+	p.gas.stop()
 	p.environment = nil
-	// p.allocator.Prune()
-	p.mutex.Unlock()
 }
 
-func (p *wazeroPrecompile) IsStatic(input []byte) bool {
+func (p *wazeroInstance) updateMemoryUsage() {
+	var size uint64
+	if mem := p.module.Memory(); mem != nil {
+		size = uint64(mem.Size())
+	}
+	atomic.StoreUint64(&p.memoryUsage, size)
+}
+
+func (p *wazeroInstance) memorySize() uint64 {
+	return atomic.LoadUint64(&p.memoryUsage)
+}
+
+func (p *wazeroInstance) IsStatic(input []byte) bool {
 	p.before(nil)
 	defer p.after(nil)
-	return p.call_Bytes_Uint64(p.expIsStatic, input) != 0
+	var isStatic bool
+	if err := p.sandboxed(func() { isStatic = p.call_Bytes_Uint64(p.expIsStatic, input) != 0 }); err != nil {
+		return false
+	}
+	return isStatic
 }
 
-func (p *wazeroPrecompile) Finalise(env api.Environment) error {
+func (p *wazeroInstance) Finalise(env api.Environment) error {
 	p.before(env)
 	defer p.after(env)
-	return p.call__Err(p.expFinalise)
+	var err error
+	if failure := p.sandboxed(func() { err = p.call__Err(p.expFinalise) }); failure != nil {
+		return failure
+	}
+	return err
 }
 
-func (p *wazeroPrecompile) Commit(env api.Environment) error {
+func (p *wazeroInstance) Commit(env api.Environment) error {
 	p.before(env)
 	defer p.after(env)
-	return p.call__Err(p.expCommit)
+	var err error
+	if failure := p.sandboxed(func() { err = p.call__Err(p.expCommit) }); failure != nil {
+		return failure
+	}
+	return err
 }
 
-func (p *wazeroPrecompile) Run(env api.Environment, input []byte) ([]byte, error) {
+func (p *wazeroInstance) Run(env api.Environment, input []byte) ([]byte, error) {
 	p.before(env)
 	defer p.after(env)
-	return p.call_Bytes_BytesErr(p.expRun, input)
+	var (
+		output []byte
+		err    error
+	)
+	if failure := p.sandboxed(func() { output, err = p.call_Bytes_BytesErr(p.expRun, input) }); failure != nil {
+		return nil, failure
+	}
+	return output, err
+}
+
+type wazeroGasGlobal struct {
+	global wz_api.MutableGlobal
+}
+
+func (g wazeroGasGlobal) get() uint64 {
+	return g.global.Get()
 }
 
-var _ concrete.Precompile = (*wazeroPrecompile)(nil)
+func (g wazeroGasGlobal) set(gas uint64) {
+	g.global.Set(gas)
+}
@@ -266,7 +266,10 @@ func (s *StateDB) FinaliseConcretePrecompiles(concretePrecompiles concrete.Preco
 		env := cc_api.NewNoCallEnvironment(
 			addr,
 			cc_api.EnvConfig{
-				Static:    true,
+				// Precompiles can write to storage on Finalise e.g., to flush
+				// deferred updates. Writes must be idempotent, as Finalise
+				// runs again when the state is committed.
+				Static:    false,
 				Ephemeral: true,
 				Trusted:   true,
 			},
@@ -289,6 +292,8 @@ func (s *StateDB) CommitConcretePrecompiles(concretePrecompiles concrete.Precomp
 		env := cc_api.NewNoCallEnvironment(
 			addr,
 			cc_api.EnvConfig{
+				// Commit runs after the state root has been checked, so it
+				// must not write to storage.
 				Static:    true,
 				Ephemeral: true,
 				Trusted:   true,
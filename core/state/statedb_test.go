@@ -30,8 +30,12 @@ import (
 	"testing/quick"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/concrete"
+	cc_api "github.com/ethereum/go-ethereum/concrete/api"
+	"github.com/ethereum/go-ethereum/concrete/lib"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // Tests that updating a state trie does not leak any database writes prior to
@@ -998,3 +1002,159 @@ func TestStateDBTransientStorage(t *testing.T) {
 		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
 	}
 }
+
+var (
+	concreteTestPendingSlot = common.Hash{0x01}
+	concreteTestRootSlot    = common.Hash{0x02}
+)
+
+// concreteTestPrecompile folds the pending value into a root on Finalise, like
+// a deferred commitment, and optionally writes to storage on Commit.
+type concreteTestPrecompile struct {
+	writeOnCommit bool
+}
+
+func (p *concreteTestPrecompile) IsStatic(input []byte) bool {
+	return true
+}
+
+func (p *concreteTestPrecompile) Finalise(env concrete.Environment) error {
+	pending := env.PersistentLoad(concreteTestPendingSlot)
+	if pending == (common.Hash{}) {
+		return nil
+	}
+	root := env.PersistentLoad(concreteTestRootSlot)
+	env.PersistentStore(concreteTestRootSlot, crypto.Keccak256Hash(root.Bytes(), pending.Bytes()))
+	env.PersistentStore(concreteTestPendingSlot, common.Hash{})
+	return nil
+}
+
+func (p *concreteTestPrecompile) Commit(env concrete.Environment) error {
+	if p.writeOnCommit {
+		env.PersistentStore(concreteTestRootSlot, common.Hash{})
+		return fmt.Errorf("commit write")
+	}
+	return nil
+}
+
+func (p *concreteTestPrecompile) Run(env concrete.Environment, input []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// Tests that the root committed with concrete precompiles is the root checked
+// after block processing, when precompiles write to storage on Finalise.
+func TestConcretePrecompileFinaliseRoot(t *testing.T) {
+	var (
+		addr     = common.HexToAddress("0xcc")
+		pcs      = concrete.PrecompileMap{addr: &concreteTestPrecompile{}}
+		db       = NewDatabase(rawdb.NewMemoryDatabase())
+		state, _ = New(types.EmptyRootHash, db, nil)
+	)
+	// Each transaction leaves a pending value, folded in on Finalise as in
+	// block processing
+	for i := byte(1); i <= 3; i++ {
+		state.SetState(addr, concreteTestPendingSlot, common.Hash{i})
+		state.FinaliseWithConcrete(pcs, true)
+	}
+	if state.GetState(addr, concreteTestRootSlot) == (common.Hash{}) {
+		t.Fatal("pending value not flushed on Finalise")
+	}
+	root := state.IntermediateRoot(true)
+
+	committed, err := state.CommitWithConcrete(pcs, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed != root {
+		t.Fatalf("committed root %x differs from block root %x", committed, root)
+	}
+	reopened, err := New(committed, db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.GetState(addr, concreteTestRootSlot) != state.GetState(addr, concreteTestRootSlot) {
+		t.Fatal("flushed value not committed")
+	}
+}
+
+// Tests that precompiles cannot write to storage on Commit, once the state
+// root has been checked.
+func TestConcretePrecompileCommitStatic(t *testing.T) {
+	var (
+		addr     = common.HexToAddress("0xcc")
+		pcs      = concrete.PrecompileMap{addr: &concreteTestPrecompile{writeOnCommit: true}}
+		state, _ = New(types.EmptyRootHash, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	)
+	state.SetState(addr, concreteTestRootSlot, common.Hash{0x01})
+	state.FinaliseWithConcrete(pcs, true)
+
+	if _, err := state.CommitWithConcrete(pcs, true); err == nil {
+		t.Fatal("expected commit error")
+	}
+	if state.GetState(addr, concreteTestRootSlot) != (common.Hash{0x01}) {
+		t.Fatal("storage written on Commit")
+	}
+}
+
+// concreteTestSMTPrecompile flushes the pending updates of a deferred sparse
+// merkle tree on Finalise.
+type concreteTestSMTPrecompile struct{}
+
+func newConcreteTestSMT(env concrete.Environment) *lib.SparseMerkleTree {
+	return lib.NewDeferredSparseMerkleTree(lib.NewPersistentDatastore(env).Get([]byte("smt")))
+}
+
+func (p *concreteTestSMTPrecompile) IsStatic(input []byte) bool {
+	return true
+}
+
+func (p *concreteTestSMTPrecompile) Finalise(env concrete.Environment) error {
+	newConcreteTestSMT(env).Commit()
+	return nil
+}
+
+func (p *concreteTestSMTPrecompile) Commit(env concrete.Environment) error {
+	return nil
+}
+
+func (p *concreteTestSMTPrecompile) Run(env concrete.Environment, input []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// Tests that Finalise running again when the state is committed leaves the
+// root of deferred sparse merkle trees, and so the state root, unchanged.
+func TestConcretePrecompileFinaliseTwice(t *testing.T) {
+	var (
+		addr     = common.HexToAddress("0xcc")
+		pcs      = concrete.PrecompileMap{addr: &concreteTestSMTPrecompile{}}
+		db       = NewDatabase(rawdb.NewMemoryDatabase())
+		state, _ = New(types.EmptyRootHash, db, nil)
+		env      = cc_api.NewNoCallEnvironment(addr, cc_api.EnvConfig{Ephemeral: true, Trusted: true}, state, false, 0)
+		tree     = newConcreteTestSMT(env)
+	)
+	for i := byte(1); i <= 3; i++ {
+		tree.Set([]byte{i}, []byte{i})
+	}
+	state.FinaliseWithConcrete(pcs, true)
+	smtRoot := tree.Root()
+	if smtRoot == (common.Hash{}) {
+		t.Fatal("pending updates not flushed on Finalise")
+	}
+	once := state.Copy().IntermediateRoot(true)
+
+	// IntermediateRoot and Commit run Finalise again
+	twice := state.IntermediateRootWithConcrete(pcs, true)
+	if tree.Root() != smtRoot {
+		t.Fatalf("tree root changed from %x to %x", smtRoot, tree.Root())
+	}
+	if twice != once {
+		t.Fatalf("root %x differs from root %x with a single Finalise", twice, once)
+	}
+	committed, err := state.CommitWithConcrete(pcs, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed != once {
+		t.Fatalf("committed root %x differs from root %x with a single Finalise", committed, once)
+	}
+}